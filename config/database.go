@@ -42,11 +42,49 @@ func ConnectDatabase() (*gorm.DB, error) {
 		&models.TokenBlacklist{},
 		&models.Music{},
 		&models.AmbientSound{},
+		&models.ExperienceLog{},
+		&models.Achievement{},
+		&models.UserAchievement{},
+		&models.Friendship{},
+		&models.Subject{},
+		&models.SubjectDailyStudyData{},
+		&models.StudyDataCorrection{},
+		&models.StudyDataFlag{},
+		&models.StudyReport{},
+		&models.HourlyStudyData{},
+		&models.MediaPlayStat{},
+		&models.YearReview{},
+		&models.StudySession{},
+		&models.TodoRecurrence{},
+		&models.TodoList{},
+		&models.TodoListMember{},
+		&models.TodoActivity{},
+		&models.TodoTag{},
+		&models.TodoTagging{},
+		&models.TodoDailyStudyData{},
+		&models.TodoReminder{},
+		&models.Notification{},
+		&models.NotificationSetting{},
 	)
+	// 全文搜索使用ngram分词器以支持中文
+	createFulltextIndex(db, &models.Note{}, "idx_note_fulltext", "notes", "title, content")
+	createFulltextIndex(db, &models.Todo{}, "idx_todo_fulltext", "todos", "event, description")
 	log.Println("Database migrated successfully")
 	return db, nil
 }
 
+// 创建全文索引，已存在时跳过
+// 创建失败时不影响启动，repository.NewSearchRepository 检查到索引不存在时改用 LIKE 匹配
+func createFulltextIndex(db *gorm.DB, model interface{}, name, table, columns string) {
+	if db.Migrator().HasIndex(model, name) {
+		return
+	}
+	sql := fmt.Sprintf("CREATE FULLTEXT INDEX %s ON %s (%s) WITH PARSER ngram", name, table, columns)
+	if err := db.Exec(sql).Error; err != nil {
+		log.Printf("创建全文索引%s失败，%s的搜索将使用LIKE匹配: %v", name, table, err)
+	}
+}
+
 func CloseDatabase(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strconv"
+)
+
+// ExperienceConfig 经验值与等级曲线配置
+type ExperienceConfig struct {
+	PerStudyMinute int // 每分钟学习获得的经验
+	StudyDailyCap  int // 学习时长经验每日上限
+	PerTomato      int // 每个番茄钟获得的经验
+	TomatoDailyCap int // 番茄钟经验每日上限
+	PerTodo        int // 每完成一个待办获得的经验
+	TodoDailyCap   int // 待办经验每日上限
+
+	// 升到第 n 级所需的累计经验 = LevelBase * (n-1)^LevelExponent
+	LevelBase     int
+	LevelExponent float64
+	MaxLevel      int
+}
+
+func LoadExperienceConfig() *ExperienceConfig {
+	return &ExperienceConfig{
+		PerStudyMinute: getIntEnv("XP_PER_STUDY_MINUTE", 1),
+		StudyDailyCap:  getIntEnv("XP_STUDY_DAILY_CAP", 300),
+		PerTomato:      getIntEnv("XP_PER_TOMATO", 10),
+		TomatoDailyCap: getIntEnv("XP_TOMATO_DAILY_CAP", 120),
+		PerTodo:        getIntEnv("XP_PER_TODO", 5),
+		TodoDailyCap:   getIntEnv("XP_TODO_DAILY_CAP", 50),
+		LevelBase:      getIntEnv("XP_LEVEL_BASE", 100),
+		LevelExponent:  getFloatEnv("XP_LEVEL_EXPONENT", 1.5),
+		MaxLevel:       getIntEnv("XP_MAX_LEVEL", 100),
+	}
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// InsightConfig AI学习周报配置
+type InsightConfig struct {
+	Model            string        // 使用的模型
+	RecentDays       int           // 提供给模型的最近学习数据天数
+	MaxTodos         int           // 提供给模型的待办事项数量上限
+	CacheTTL         time.Duration // 周报缓存时间，需覆盖一整周
+	RegeneratePerDay int           // 每个用户每天最多重新生成次数
+}
+
+func LoadInsightConfig() *InsightConfig {
+	return &InsightConfig{
+		Model:            getEnv("INSIGHT_MODEL", "qwen-plus"),
+		RecentDays:       getIntEnv("INSIGHT_RECENT_DAYS", 14),
+		MaxTodos:         getIntEnv("INSIGHT_MAX_TODOS", 20),
+		CacheTTL:         time.Duration(getIntEnv("INSIGHT_CACHE_DAYS", 8)) * 24 * time.Hour,
+		RegeneratePerDay: getIntEnv("INSIGHT_REGENERATE_PER_DAY", 3),
+	}
+}
@@ -0,0 +1,12 @@
+package config
+
+// NoteConfig 备忘录配置
+type NoteConfig struct {
+	MaxContentLength int // 备忘录内容的最大字符数
+}
+
+func LoadNoteConfig() *NoteConfig {
+	return &NoteConfig{
+		MaxContentLength: getIntEnv("NOTE_MAX_CONTENT_LENGTH", 50000),
+	}
+}
@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+// NotificationConfig 待办提醒和通知渠道配置
+type NotificationConfig struct {
+	ReminderInterval    time.Duration // 检查到期提醒的间隔
+	ReminderBatchSize   int           // 每次最多处理的到期提醒数量
+	ReminderSendTimeout time.Duration // 发送中的提醒超过该时间仍未完成时恢复为待发送
+	MaxRemindersPerTodo int           // 每个待办最多设置的提醒数量
+	MaxOffsetMinutes    int           // 提前提醒的最长时间
+
+	// 邮件渠道，SMTPHost 为空时不发送邮件
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTimeout  time.Duration // 连接和发送一封邮件的最长时间
+}
+
+func LoadNotificationConfig() *NotificationConfig {
+	return &NotificationConfig{
+		ReminderInterval:    time.Duration(getIntEnv("REMINDER_INTERVAL_SECONDS", 60)) * time.Second,
+		ReminderBatchSize:   getIntEnv("REMINDER_BATCH_SIZE", 200),
+		ReminderSendTimeout: time.Duration(getIntEnv("REMINDER_SEND_TIMEOUT_SECONDS", 600)) * time.Second,
+		MaxRemindersPerTodo: getIntEnv("REMINDER_MAX_PER_TODO", 5),
+		MaxOffsetMinutes:    getIntEnv("REMINDER_MAX_OFFSET_MINUTES", 7*24*60),
+
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getIntEnv("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:     getEnv("SMTP_FROM", ""),
+		SMTPTimeout:  time.Duration(getIntEnv("SMTP_TIMEOUT_SECONDS", 10)) * time.Second,
+	}
+}
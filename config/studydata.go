@@ -0,0 +1,37 @@
+package config
+
+import "time"
+
+// StudyDataConfig 学习数据落库配置
+type StudyDataConfig struct {
+	FlushInterval        time.Duration // 后台落库间隔
+	FlushBatchSize       int           // 每批落库的条目数
+	FlushMaxBackoff      time.Duration // 落库失败后重试间隔的上限
+	ShutdownFlushTimeout time.Duration // 关闭服务时落库的最长等待时间
+	CorrectionWindowDays int           // 允许修改最近多少天的学习数据
+
+	// 防作弊规则
+	MaxMinutesPerSubmission int // 单次提交的学习时长上限
+	MaxMinutesPerDay        int // 每日学习时长上限，超过直接拒绝
+	SuspiciousMinutesPerDay int // 每日学习时长超过该值时标记为可疑
+	MinMinutesPerTomato     int // 每个番茄钟至少对应的学习时长
+	SubmissionsPerMinute    int // 每个用户每分钟最多提交次数
+	OverlapToleranceMinutes int // 学习时长超过与上次提交间隔的容差
+}
+
+func LoadStudyDataConfig() *StudyDataConfig {
+	return &StudyDataConfig{
+		FlushInterval:        time.Duration(getIntEnv("STUDY_FLUSH_INTERVAL_SECONDS", 5)) * time.Second,
+		FlushBatchSize:       getIntEnv("STUDY_FLUSH_BATCH_SIZE", 200),
+		FlushMaxBackoff:      time.Duration(getIntEnv("STUDY_FLUSH_MAX_BACKOFF_SECONDS", 60)) * time.Second,
+		ShutdownFlushTimeout: time.Duration(getIntEnv("STUDY_FLUSH_SHUTDOWN_TIMEOUT_SECONDS", 15)) * time.Second,
+		CorrectionWindowDays: getIntEnv("STUDY_CORRECTION_WINDOW_DAYS", 7),
+
+		MaxMinutesPerSubmission: getIntEnv("STUDY_MAX_MINUTES_PER_SUBMISSION", 240),
+		MaxMinutesPerDay:        getIntEnv("STUDY_MAX_MINUTES_PER_DAY", 1200),
+		SuspiciousMinutesPerDay: getIntEnv("STUDY_SUSPICIOUS_MINUTES_PER_DAY", 720),
+		MinMinutesPerTomato:     getIntEnv("STUDY_MIN_MINUTES_PER_TOMATO", 15),
+		SubmissionsPerMinute:    getIntEnv("STUDY_SUBMISSIONS_PER_MINUTE", 10),
+		OverlapToleranceMinutes: getIntEnv("STUDY_OVERLAP_TOLERANCE_MINUTES", 5),
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+// TodoConfig 待办事项配置
+type TodoConfig struct {
+	TrashRetentionDays int // 回收站中的待办保留天数，超过后彻底删除
+	BatchLimit         int // 批量操作一次最多处理的待办数量
+}
+
+func LoadTodoConfig() *TodoConfig {
+	return &TodoConfig{
+		TrashRetentionDays: getIntEnv("TODO_TRASH_RETENTION_DAYS", 30),
+		BatchLimit:         getIntEnv("TODO_BATCH_LIMIT", 100),
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementService interface {
+	EvaluateAchievements(userID uint, studiedAt []time.Time, now time.Time) ([]service.AchievementInfo, error)
+	EvaluateTodoAchievements(userID uint, at time.Time) ([]service.AchievementInfo, error)
+	GetAchievements(userID uint) ([]service.AchievementInfo, error)
+}
+
+type AchievementHandler struct {
+	service AchievementService
+}
+
+func NewAchievementHandler(service AchievementService) *AchievementHandler {
+	return &AchievementHandler{service: service}
+}
+
+// GetAchievements 获取当前用户的成就列表
+// @Router /api/achievements [get]
+func (h *AchievementHandler) GetAchievements(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询成就
+	achievements, err := h.service.GetAchievements(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取成就失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, achievements)
+}
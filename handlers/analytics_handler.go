@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AnalyticsService interface {
+	GetStudyAnalytics(userID uint, weeks int, now time.Time) (*service.StudyAnalyticsInfo, error)
+}
+
+type AnalyticsHandler struct {
+	service AnalyticsService
+}
+
+func NewAnalyticsHandler(service AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// GetStudyAnalytics 获取分时段、按星期的学习分布和最近几周的趋势
+// @Router /api/studydata/analytics [get]
+func (h *AnalyticsHandler) GetStudyAnalytics(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req StudyAnalyticsQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 统计
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	analytics, err := h.service.GetStudyAnalytics(claims.UserID, req.Weeks, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "获取学习分析失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, analytics)
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/models"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AntiCheatService interface {
+	GetFlags(status string, page, pageSize int) ([]models.StudyDataFlag, int64, error)
+	VoidFlag(flagID uint, now time.Time) error
+	DismissFlag(flagID uint, now time.Time) error
+}
+
+type AntiCheatHandler struct {
+	service AntiCheatService
+}
+
+func NewAntiCheatHandler(service AntiCheatService) *AntiCheatHandler {
+	return &AntiCheatHandler{service: service}
+}
+
+// GetStudyDataFlags 获取可疑学习数据列表
+// @Router /api/admin/studydata/flags [get]
+func (h *AntiCheatHandler) GetStudyDataFlags(c *gin.Context) {
+	// 1. 绑定请求参数
+	var req StudyDataFlagQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 2. 查询可疑记录
+	flags, total, err := h.service.GetFlags(req.Status, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取可疑记录失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  flags,
+	})
+}
+
+// VoidStudyDataFlag 作废可疑学习数据，从当天数据中扣除
+// @Router /api/admin/studydata/flags/:id/void [post]
+func (h *AntiCheatHandler) VoidStudyDataFlag(c *gin.Context) {
+	// 1. 解析记录ID
+	flagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的记录ID")
+		return
+	}
+	// 2. 作废
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	if err := h.service.VoidFlag(uint(flagID), time.Now().In(loc)); err != nil {
+		FailWithMessage(c, "作废失败: "+err.Error())
+		return
+	}
+	OkWithMessage(c, "作废成功")
+}
+
+// DismissStudyDataFlag 忽略可疑学习数据
+// @Router /api/admin/studydata/flags/:id/dismiss [post]
+func (h *AntiCheatHandler) DismissStudyDataFlag(c *gin.Context) {
+	// 1. 解析记录ID
+	flagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的记录ID")
+		return
+	}
+	// 2. 忽略
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	if err := h.service.DismissFlag(uint(flagID), time.Now().In(loc)); err != nil {
+		FailWithMessage(c, "忽略失败: "+err.Error())
+		return
+	}
+	OkWithMessage(c, "已忽略")
+}
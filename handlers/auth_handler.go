@@ -15,6 +15,7 @@ type UserService interface {
 	UpdateUserPassword(userID uint, oldPassword, newPassword string) (message string)
 	UpdateUserEmail(userID uint, newEmail string, password string) (message string)
 	UpdateUserInfo(userID uint, username, telenum, gender string) (message string)
+	UpdateDailyGoal(userID uint, dailyGoal int) (message string)
 	GetUserInfo(userID uint) (*service.UserInfo, error)
 }
 
@@ -222,6 +223,31 @@ func (h *AuthHandler) UpdateUserInfoHandler(c *gin.Context) {
 	OkWithMessage(c, msg)
 }
 
+// UpdateDailyGoalHandler 修改每日学习目标
+// @Router /api/user/update_goal [post]
+func (h *AuthHandler) UpdateDailyGoalHandler(c *gin.Context) {
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+
+	var req UpdateDailyGoal
+	err = c.ShouldBindJSON(&req)
+	if err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+
+	msg := h.Userservice.UpdateDailyGoal(claims.UserID, req.DailyGoal)
+	if msg != "更新每日目标成功" {
+		FailWithMessage(c, msg)
+		return
+	}
+
+	OkWithMessage(c, msg)
+}
+
 // GetUserInfoHandler 获取当前用户信息
 // @Router /api/user/info [get]
 func (h *AuthHandler) GetUserInfoHandler(c *gin.Context) {
@@ -252,8 +278,11 @@ func (h *AuthHandler) GetUserInfoHandler(c *gin.Context) {
 		"avatarpath": userinfo.Avatar,
 		"experience": userinfo.Experience,
 		"level":      userinfo.Level,
+		"dailygoal":  userinfo.DailyGoal,
 		"createdat":  userinfo.CreatedAt,
 		"studytime":  studyData.StudyTime,
 		"tomatoes":   studyData.Tomatoes,
+
+		"leaderboardoptout": userinfo.LeaderboardOptOut,
 	})
 }
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CorrectionService interface {
+	AddHistoryStudyData(userID uint, date time.Time, studyTime, tomatoes int, reason string, now time.Time) (*service.StudyDataCorrectionInfo, error)
+	SetHistoryStudyData(userID uint, date time.Time, studyTime, tomatoes int, reason string, now time.Time) (*service.StudyDataCorrectionInfo, error)
+	RemoveHistoryStudyData(userID uint, date time.Time, reason string, now time.Time) (*service.StudyDataCorrectionInfo, error)
+	GetCorrections(userID uint, page, pageSize int) ([]service.StudyDataCorrectionInfo, int64, error)
+}
+
+type CorrectionHandler struct {
+	service CorrectionService
+}
+
+func NewCorrectionHandler(service CorrectionService) *CorrectionHandler {
+	return &CorrectionHandler{service: service}
+}
+
+// AddStudyHistory 补录过去某天的学习数据
+// @Router /api/studydata/history [post]
+func (h *CorrectionHandler) AddStudyHistory(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req StudyHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	date, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+	if err != nil {
+		FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+		return
+	}
+	// 3. 补录
+	correction, err := h.service.AddHistoryStudyData(claims.UserID, date, req.StudyTime, req.Tomatoes, req.Reason, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "补录学习数据失败: "+err.Error())
+		return
+	}
+	// 4. 返回修改记录
+	Ok(c, "补录学习数据成功", correction)
+}
+
+// UpdateStudyHistory 修改过去某天的学习数据
+// @Router /api/studydata/history [put]
+func (h *CorrectionHandler) UpdateStudyHistory(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req StudyHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	date, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+	if err != nil {
+		FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+		return
+	}
+	// 3. 修改
+	correction, err := h.service.SetHistoryStudyData(claims.UserID, date, req.StudyTime, req.Tomatoes, req.Reason, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "修改学习数据失败: "+err.Error())
+		return
+	}
+	// 4. 返回修改记录
+	Ok(c, "修改学习数据成功", correction)
+}
+
+// RemoveStudyHistory 清空过去某天的学习数据
+// @Router /api/studydata/history [delete]
+func (h *CorrectionHandler) RemoveStudyHistory(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req RemoveStudyHistoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	date, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+	if err != nil {
+		FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+		return
+	}
+	// 3. 清空
+	correction, err := h.service.RemoveHistoryStudyData(claims.UserID, date, req.Reason, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "清空学习数据失败: "+err.Error())
+		return
+	}
+	// 4. 返回修改记录
+	Ok(c, "清空学习数据成功", correction)
+}
+
+// GetCorrections 获取学习数据修改记录
+// @Router /api/studydata/corrections [get]
+func (h *CorrectionHandler) GetCorrections(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定分页参数
+	var req PageQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 查询修改记录
+	corrections, total, err := h.service.GetCorrections(claims.UserID, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取修改记录失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  corrections,
+	})
+}
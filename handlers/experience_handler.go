@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExperienceService interface {
+	AwardStudyExperienceForDays(userID uint, days []time.Time) (*service.ExperienceResult, error)
+	AwardTodoExperience(userID, todoID uint, date time.Time) (*service.ExperienceResult, error)
+	GetExperienceLogs(userID uint, page, pageSize int) ([]service.ExperienceLogInfo, int64, error)
+}
+
+type ExperienceHandler struct {
+	service ExperienceService
+}
+
+func NewExperienceHandler(service ExperienceService) *ExperienceHandler {
+	return &ExperienceHandler{service: service}
+}
+
+// GetExperienceLogs 获取经验流水
+// @Router /api/user/experience [get]
+func (h *ExperienceHandler) GetExperienceLogs(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定分页参数
+	var req PageQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 查询经验流水
+	logs, total, err := h.service.GetExperienceLogs(claims.UserID, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取经验记录失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  logs,
+	})
+}
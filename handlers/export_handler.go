@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExportService interface {
+	ExportStudyData(userID uint, format string, start, end, now time.Time) (*service.StudyDataExport, error)
+}
+
+type ExportHandler struct {
+	service ExportService
+}
+
+func NewExportHandler(service ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// ExportStudyData 导出学习数据为 CSV 或 iCalendar 文件，日期为空时默认最近30天
+// @Router /api/studydata/export [get]
+func (h *ExportHandler) ExportStudyData(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req ExportStudyDataQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if req.End != "" {
+		if end, err = time.ParseInLocation("2006-01-02", req.End, loc); err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+	}
+	start := end.AddDate(0, 0, -29)
+	if req.Start != "" {
+		if start, err = time.ParseInLocation("2006-01-02", req.Start, loc); err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+	}
+	// 3. 查询要导出的数据
+	export, err := h.service.ExportStudyData(claims.UserID, req.Format, start, end, now)
+	if err != nil {
+		FailWithMessage(c, "导出学习数据失败: "+err.Error())
+		return
+	}
+	// 4. 以附件形式写出文件
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName+`"`)
+	c.Status(http.StatusOK)
+	if err := export.Write(c.Writer); err != nil {
+		logger.Log.Errorf("导出学习数据失败: user=%d err=%v", claims.UserID, err)
+	}
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FriendService interface {
+	AddFriend(userID, friendID uint) error
+	RemoveFriend(userID, friendID uint) error
+	GetFriends(userID uint) ([]service.FriendInfo, error)
+}
+
+type FriendHandler struct {
+	service FriendService
+}
+
+func NewFriendHandler(service FriendService) *FriendHandler {
+	return &FriendHandler{service: service}
+}
+
+// AddFriend 添加好友
+// @Router /api/friends [post]
+func (h *FriendHandler) AddFriend(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req AddFriendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 添加好友
+	if err := h.service.AddFriend(claims.UserID, req.FriendID); err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "添加成功")
+}
+
+// RemoveFriend 删除好友
+// @Router /api/friends/:id [delete]
+func (h *FriendHandler) RemoveFriend(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取好友ID
+	friendID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的用户ID")
+		return
+	}
+	// 3. 删除好友
+	if err := h.service.RemoveFriend(claims.UserID, uint(friendID)); err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
+
+// GetFriends 获取好友列表
+// @Router /api/friends [get]
+func (h *FriendHandler) GetFriends(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询好友
+	friends, err := h.service.GetFriends(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, friends)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InsightService interface {
+	GetWeeklyInsight(ctx context.Context, userID uint, now time.Time) (*service.StudyInsightInfo, error)
+	RegenerateWeeklyInsight(ctx context.Context, userID uint, now time.Time) (*service.StudyInsightInfo, error)
+}
+
+type InsightHandler struct {
+	service InsightService
+}
+
+func NewInsightHandler(service InsightService) *InsightHandler {
+	return &InsightHandler{service: service}
+}
+
+// GetWeeklyInsight 获取本周的AI学习周报，本周还没有生成时立即生成
+// @Router /api/insights/weekly [get]
+func (h *InsightHandler) GetWeeklyInsight(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取周报
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	insight, err := h.service.GetWeeklyInsight(c.Request.Context(), claims.UserID, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "获取学习周报失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, insight)
+}
+
+// RegenerateWeeklyInsight 重新生成本周的AI学习周报
+// @Router /api/insights/weekly/regenerate [post]
+func (h *InsightHandler) RegenerateWeeklyInsight(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 重新生成
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	insight, err := h.service.RegenerateWeeklyInsight(c.Request.Context(), claims.UserID, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "生成学习周报失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	Ok(c, "生成成功", insight)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LeaderboardService interface {
+	GetLeaderboard(userID uint, period, scope string, limit int) (*service.LeaderboardInfo, error)
+	GetMyRank(userID uint, period, scope string, neighbours int) (*service.LeaderboardInfo, error)
+	SetOptOut(userID uint, optOut bool) error
+	Rebuild() error
+}
+
+type LeaderboardHandler struct {
+	service LeaderboardService
+}
+
+func NewLeaderboardHandler(service LeaderboardService) *LeaderboardHandler {
+	return &LeaderboardHandler{service: service}
+}
+
+// GetLeaderboard 获取排行榜前N名
+// @Router /api/leaderboard [get]
+func (h *LeaderboardHandler) GetLeaderboard(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	req := LeaderboardQuery{Period: "daily", Scope: service.LeaderboardScopeGlobal, Limit: 20}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 查询排行榜
+	data, err := h.service.GetLeaderboard(claims.UserID, req.Period, req.Scope, req.Limit)
+	if err != nil {
+		FailWithMessage(c, "获取排行榜失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, data)
+}
+
+// GetMyRank 获取我的名次及前后用户
+// @Router /api/leaderboard/me [get]
+func (h *LeaderboardHandler) GetMyRank(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	req := MyRankQuery{Period: "daily", Scope: service.LeaderboardScopeGlobal, Neighbours: 3}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 查询名次
+	data, err := h.service.GetMyRank(claims.UserID, req.Period, req.Scope, req.Neighbours)
+	if err != nil {
+		FailWithMessage(c, "获取名次失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, data)
+}
+
+// UpdatePrivacy 设置是否参与排行榜
+// @Router /api/user/update_privacy [post]
+func (h *LeaderboardHandler) UpdatePrivacy(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req UpdatePrivacyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 更新设置
+	if err := h.service.SetOptOut(claims.UserID, *req.LeaderboardOptOut); err != nil {
+		FailWithMessage(c, "更新隐私设置失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "更新隐私设置成功")
+}
+
+// RebuildLeaderboard 根据数据库重建排行榜
+// @Router /api/admin/leaderboard/rebuild [post]
+func (h *LeaderboardHandler) RebuildLeaderboard(c *gin.Context) {
+	if err := h.service.Rebuild(); err != nil {
+		FailWithMessage(c, "重建排行榜失败: "+err.Error())
+		return
+	}
+	OkWithMessage(c, "重建排行榜成功")
+}
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NoteService interface {
+	CreateNote(userID uint, title, content string, date time.Time) (*service.NoteInfo, error)
+	UpdateNote(userID, noteID uint, title, content *string, date *time.Time) (*service.NoteInfo, error)
+	PinNote(userID, noteID uint, pinned bool) error
+	GetNote(userID, noteID uint) (*service.NoteInfo, error)
+	GetNotes(userID uint, start, end *time.Time, page, pageSize int) ([]service.NoteInfo, int64, error)
+	DeleteNote(userID, noteID uint) error
+}
+
+type NoteHandler struct {
+	service NoteService
+}
+
+func NewNoteHandler(service NoteService) *NoteHandler {
+	return &NoteHandler{service: service}
+}
+
+// CreateNote 创建备忘录
+// @Router /api/notes [post]
+func (h *NoteHandler) CreateNote(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req CreateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	date := time.Now().In(loc)
+	if req.Date != "" {
+		if date, err = time.ParseInLocation("2006-01-02", req.Date, loc); err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+	}
+	// 3. 创建备忘录
+	note, err := h.service.CreateNote(claims.UserID, req.Title, req.Content, date)
+	if err != nil {
+		FailWithMessage(c, "创建失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "创建成功", note)
+}
+
+// GetNotes 分页获取备忘录，置顶的在前
+// @Router /api/notes [get]
+func (h *NoteHandler) GetNotes(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req NoteQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	var start, end *time.Time
+	if req.Start != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.Start, loc)
+		if err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+		start = &date
+	}
+	if req.End != "" {
+		date, err := time.ParseInLocation("2006-01-02", req.End, loc)
+		if err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+		end = &date
+	}
+	// 3. 查询备忘录
+	notes, total, err := h.service.GetNotes(claims.UserID, start, end, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  notes,
+	})
+}
+
+// GetNote 获取备忘录详情
+// @Router /api/notes/:id [get]
+func (h *NoteHandler) GetNote(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取备忘录ID
+	noteID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的备忘录ID")
+		return
+	}
+	// 3. 查询备忘录
+	note, err := h.service.GetNote(claims.UserID, uint(noteID))
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, note)
+}
+
+// UpdateNote 更新备忘录
+// @Router /api/notes/:id [put]
+func (h *NoteHandler) UpdateNote(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取备忘录ID
+	noteID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的备忘录ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	var date *time.Time
+	if req.Date != nil {
+		loc, _ := time.LoadLocation("Asia/Shanghai")
+		parsed, err := time.ParseInLocation("2006-01-02", *req.Date, loc)
+		if err != nil {
+			FailWithMessage(c, "日期格式有误，应为YYYY-MM-DD")
+			return
+		}
+		date = &parsed
+	}
+	// 4. 更新备忘录
+	note, err := h.service.UpdateNote(claims.UserID, uint(noteID), req.Title, req.Content, date)
+	if err != nil {
+		FailWithMessage(c, "更新失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	Ok(c, "更新成功", note)
+}
+
+// PinNote 置顶或取消置顶备忘录
+// @Router /api/notes/:id/pin [put]
+func (h *NoteHandler) PinNote(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取备忘录ID
+	noteID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的备忘录ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req PinNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 更新置顶状态
+	if err := h.service.PinNote(claims.UserID, uint(noteID), req.Pinned); err != nil {
+		FailWithMessage(c, "操作失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "操作成功")
+}
+
+// DeleteNote 删除备忘录
+// @Router /api/notes/:id [delete]
+func (h *NoteHandler) DeleteNote(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取备忘录ID
+	noteID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的备忘录ID")
+		return
+	}
+	// 3. 删除备忘录
+	if err := h.service.DeleteNote(claims.UserID, uint(noteID)); err != nil {
+		FailWithMessage(c, "删除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
@@ -0,0 +1,325 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type NotificationService interface {
+	GetNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]service.NotificationInfo, int64, int64, error)
+	MarkNotificationRead(userID, notificationID uint, now time.Time) error
+	MarkAllNotificationsRead(userID uint, now time.Time) (int64, error)
+	GetNotificationSetting(userID uint) (*service.NotificationSettingInfo, error)
+	UpdateNotificationSetting(userID uint, input service.NotificationSettingInput) (*service.NotificationSettingInfo, error)
+}
+
+type ReminderService interface {
+	GetTodoReminders(userID, todoID uint) ([]service.TodoReminderInfo, error)
+	AddTodoReminder(userID, todoID uint, offsetMinutes int) (*service.TodoReminderInfo, error)
+	DeleteTodoReminder(userID, reminderID uint) error
+}
+
+// 订阅用户的WebSocket推送
+type PushSubscriber interface {
+	Subscribe(userID uint) (<-chan []byte, func())
+}
+
+// WebSocket心跳间隔，超过两个间隔没有收到响应时断开连接
+const pushPingInterval = 30 * time.Second
+
+// WebSocketAuthProtocol 浏览器通过子协议传递token时使用的协议名，握手时需要原样返回
+const WebSocketAuthProtocol = "bearer"
+
+type NotificationHandler struct {
+	service   NotificationService
+	reminders ReminderService
+	push      PushSubscriber
+	upgrader  websocket.Upgrader
+}
+
+// allowOrigins 为允许建立WebSocket连接的来源，与跨域请求的允许来源一致
+func NewNotificationHandler(service NotificationService, reminders ReminderService, push PushSubscriber, allowOrigins []string) *NotificationHandler {
+	return &NotificationHandler{
+		service:   service,
+		reminders: reminders,
+		push:      push,
+		upgrader: websocket.Upgrader{
+			CheckOrigin:  func(r *http.Request) bool { return checkOrigin(r, allowOrigins) },
+			Subprotocols: []string{WebSocketAuthProtocol},
+		},
+	}
+}
+
+// 浏览器会带上 Origin，只允许列表中的来源；非浏览器客户端没有 Origin 时不限制
+func checkOrigin(r *http.Request, allowOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || slices.Contains(allowOrigins, origin)
+}
+
+// GetTodoReminders 获取待办的提醒
+// @Router /api/todos/:id/reminders [get]
+func (h *NotificationHandler) GetTodoReminders(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 查询提醒
+	reminders, err := h.reminders.GetTodoReminders(claims.UserID, uint(todoID))
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, reminders)
+}
+
+// AddTodoReminder 为待办添加提醒
+// @Router /api/todos/:id/reminders [post]
+func (h *NotificationHandler) AddTodoReminder(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req TodoReminderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 添加提醒
+	reminder, err := h.reminders.AddTodoReminder(claims.UserID, uint(todoID), req.OffsetMinutes)
+	if err != nil {
+		FailWithMessage(c, "添加失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	Ok(c, "添加成功", reminder)
+}
+
+// DeleteTodoReminder 删除待办提醒
+// @Router /api/todos/reminders/:id [delete]
+func (h *NotificationHandler) DeleteTodoReminder(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取提醒ID
+	reminderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的提醒ID")
+		return
+	}
+	// 3. 删除提醒
+	if err := h.reminders.DeleteTodoReminder(claims.UserID, uint(reminderID)); err != nil {
+		FailWithMessage(c, "删除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
+
+// GetNotifications 获取站内信列表
+// @Router /api/notifications [get]
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req NotificationQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 查询通知
+	notifications, total, unread, err := h.service.GetNotifications(claims.UserID, req.Unread, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total":  total,
+		"unread": unread,
+		"list":   notifications,
+	})
+}
+
+// MarkNotificationRead 把通知标记为已读
+// @Router /api/notifications/:id/read [post]
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取通知ID
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的通知ID")
+		return
+	}
+	// 3. 标记已读
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	if err := h.service.MarkNotificationRead(claims.UserID, uint(notificationID), time.Now().In(loc)); err != nil {
+		FailWithMessage(c, "操作失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "操作成功")
+}
+
+// MarkAllNotificationsRead 把所有通知标记为已读
+// @Router /api/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllNotificationsRead(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 标记已读
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	count, err := h.service.MarkAllNotificationsRead(claims.UserID, time.Now().In(loc))
+	if err != nil {
+		FailWithMessage(c, "操作失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, gin.H{"count": count})
+}
+
+// GetNotificationSetting 获取通知设置
+// @Router /api/notifications/settings [get]
+func (h *NotificationHandler) GetNotificationSetting(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询设置
+	setting, err := h.service.GetNotificationSetting(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, setting)
+}
+
+// UpdateNotificationSetting 更新通知渠道和免打扰时间
+// @Router /api/notifications/settings [put]
+func (h *NotificationHandler) UpdateNotificationSetting(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req NotificationSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 更新设置
+	setting, err := h.service.UpdateNotificationSetting(claims.UserID, service.NotificationSettingInput{
+		EmailEnabled: req.EmailEnabled,
+		PushEnabled:  req.PushEnabled,
+		QuietStart:   req.QuietStart,
+		QuietEnd:     req.QuietEnd,
+	})
+	if err != nil {
+		FailWithMessage(c, "更新失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "更新成功", setting)
+}
+
+// SubscribeNotifications 建立WebSocket连接接收实时通知，浏览器可以通过子协议 ["bearer", token] 传递登录凭证
+// @Router /api/notifications/ws [get]
+func (h *NotificationHandler) SubscribeNotifications(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 升级为WebSocket连接，失败时 Upgrade 已经返回了错误响应
+	conn, err := h.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.Log.Errorf("建立WebSocket连接失败: user=%d err=%v", claims.UserID, err)
+		return
+	}
+	defer conn.Close()
+	messages, cancel := h.push.Subscribe(claims.UserID)
+	defer cancel()
+
+	// 3. 读取客户端消息以处理心跳响应和关闭连接
+	closed := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(2 * pushPingInterval))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * pushPingInterval))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 4. 推送通知并定时发送心跳
+	ticker := time.NewTicker(pushPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case message, ok := <-messages:
+			if !ok {
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(10*time.Second)); err != nil {
+				return
+			}
+		}
+	}
+}
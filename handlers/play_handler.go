@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlayService interface {
+	RecordPlay(userID uint, mediaType string, mediaID uint, seconds int, now time.Time) error
+}
+
+type PlayHandler struct {
+	service PlayService
+}
+
+func NewPlayHandler(service PlayService) *PlayHandler {
+	return &PlayHandler{service: service}
+}
+
+// RecordPlay 上报一次音乐/白噪音播放
+// @Router /api/plays [post]
+func (h *PlayHandler) RecordPlay(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req RecordPlayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 记录播放
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	if err := h.service.RecordPlay(claims.UserID, req.Type, req.ID, req.Seconds, time.Now().In(loc)); err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "记录成功")
+}
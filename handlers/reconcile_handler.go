@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReconcileService interface {
+	Reconcile(userID uint, repair bool) (*service.ReconcileReport, error)
+}
+
+type ReconcileHandler struct {
+	service ReconcileService
+}
+
+func NewReconcileHandler(service ReconcileService) *ReconcileHandler {
+	return &ReconcileHandler{service: service}
+}
+
+// ReconcileStudyData 核对每日、每月、总学习数据及缓存，可选择修复
+// @Router /api/admin/studydata/reconcile [post]
+func (h *ReconcileHandler) ReconcileStudyData(c *gin.Context) {
+	// 1. 绑定请求参数
+	var req ReconcileStudyDataRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 2. 对账
+	report, err := h.service.Reconcile(req.UserID, req.Repair)
+	if err != nil {
+		FailWithMessage(c, "学习数据对账失败: "+err.Error())
+		return
+	}
+	// 3. 返回报告
+	OkWithData(c, report)
+}
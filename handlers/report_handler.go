@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReportService interface {
+	GetReports(userID uint, reportType string, page, pageSize int) ([]service.StudyReportInfo, int64, error)
+}
+
+type ReportHandler struct {
+	service ReportService
+}
+
+func NewReportHandler(service ReportService) *ReportHandler {
+	return &ReportHandler{service: service}
+}
+
+// GetReports 获取周报/月报历史
+// @Router /api/reports [get]
+func (h *ReportHandler) GetReports(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req ReportQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 查询报告
+	reports, total, err := h.service.GetReports(claims.UserID, req.Type, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取报告失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  reports,
+	})
+}
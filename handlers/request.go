@@ -1,5 +1,7 @@
 package handler
 
+import "time"
+
 //============用户认证请求结构体=============
 type RegisterUser struct {
 	Username string `json:"username"`
@@ -32,20 +34,179 @@ type CancelUser struct {
 	Password string `json:"password"`
 }
 
+type UpdateDailyGoal struct {
+	DailyGoal int `json:"dailygoal" binding:"required"`
+}
+
 //============待办事项请求结构体=============
 type CreateTodoRequest struct {
-	Event string `json:"event" binding:"required"`
+	ParentID          *uint      `json:"parentid"` // 可选，创建为该待办的子任务
+	ListID            *uint      `json:"listid"`   // 可选，放入该清单，只对顶层待办有效
+	Tags              []string   `json:"tags" binding:"max=10"`
+	Event             string     `json:"event" binding:"required,max=255"`
+	Description       string     `json:"description"`
+	Priority          int        `json:"priority" binding:"min=0,max=3"` // 0无 1低 2中 3高
+	DueAt             *time.Time `json:"dueat"`
+	EstimatedTomatoes int        `json:"estimatedtomatoes" binding:"min=0"`
+	// 可选，设置后按规则重复
+	Recurrence *TodoRecurrenceRequest `json:"recurrence"`
 }
 
-// UpdateTodoRequest 更新待办事项请求
+// 重复方式，weekly 时 days 为重复的星期（1为周一，7为周日），rrule 时填写 RRULE 规则
+type TodoRecurrenceRequest struct {
+	Preset string `json:"preset" binding:"required,oneof=daily weekdays weekly monthly rrule"`
+	Days   []int  `json:"days" binding:"omitempty,dive,min=1,max=7"`
+	RRule  string `json:"rrule" binding:"omitempty,max=255"`
+}
+
+// UpdateTodoRequest 更新待办事项请求，未传的字段保持不变
 type UpdateTodoRequest struct {
-	Event string `json:"event"`
+	Event             *string    `json:"event" binding:"omitempty,max=255"`
+	Description       *string    `json:"description"`
+	Priority          *int       `json:"priority" binding:"omitempty,min=0,max=3"`
+	DueAt             *time.Time `json:"dueat"`
+	ClearDueAt        bool       `json:"cleardueat"` // 为true时清除截止时间
+	EstimatedTomatoes *int       `json:"estimatedtomatoes" binding:"omitempty,min=0"`
+	// 修改重复方式，需要 scope=future
+	Recurrence *TodoRecurrenceRequest `json:"recurrence"`
+}
+
+// 修改或删除重复待办时的范围，this 只影响这一次，future 影响这一次及以后
+type TodoScopeQuery struct {
+	Scope string `form:"scope" binding:"omitempty,oneof=this future"`
+}
+
+// 移动顶层待办，listid 不传时留在原清单，为0时移到收集箱；afterid 不传时移到最前
+type MoveTodoRequest struct {
+	ListID  *uint `json:"listid"`
+	AfterID *uint `json:"afterid"`
+}
+
+// 批量操作待办，move 时 listid 为0表示收集箱，tag 时 tagmode 默认为 add
+type BatchTodoRequest struct {
+	Action  string   `json:"action" binding:"required,oneof=complete delete move tag"`
+	IDs     []uint   `json:"ids" binding:"required,min=1"`
+	ListID  *uint    `json:"listid"`
+	Tags    []string `json:"tags" binding:"max=10"`
+	TagMode string   `json:"tagmode" binding:"omitempty,oneof=add remove set"`
+}
+
+// 分配共享清单中的待办，assigneeid 为空时取消分配
+type AssignTodoRequest struct {
+	AssigneeID *uint `json:"assigneeid"`
+}
+
+type AssignedTodoQuery struct {
+	Status string `form:"status" binding:"omitempty,oneof=active completed"`
+}
+
+// 待办的全部标签，不存在的标签自动创建
+type SetTodoTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=10"`
+}
+
+// 按顺序给出的全部子任务ID
+type ReorderSubtasksRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// 待办事项列表查询，duebefore 为 RFC3339 格式
+type TodoQuery struct {
+	Status    string     `form:"status" binding:"omitempty,oneof=active completed"`
+	DueBefore *time.Time `form:"duebefore"`
+	Priority  *int       `form:"priority" binding:"omitempty,min=0,max=3"`
+	ListID    *uint      `form:"listid"` // 0表示收集箱
+	TagID     *uint      `form:"tagid"`
+	Sort      string     `form:"sort" binding:"omitempty,oneof=created_at due_at priority position"`
+	Order     string     `form:"order" binding:"omitempty,oneof=asc desc"`
 }
 
 //============学习数据请求结构体=============
 type AddStudyDataRequest struct {
-	StudyTime int `json:"studytime" binding:"required"`
-	Tomatoes  int `json:"tomatoes" binding:"required"`
+	StudyTime      int    `json:"studytime" binding:"required"`
+	Tomatoes       int    `json:"tomatoes" binding:"required"`
+	SubjectID      uint   `json:"subjectid"`      // 可选，归属的科目
+	TodoID         uint   `json:"todoid"`         // 可选，学习所针对的待办
+	IdempotencyKey string `json:"idempotencykey"` // 可选，也可以放在请求头 Idempotency-Key
+}
+
+// 离线记录的一次学习
+type OfflineStudySession struct {
+	IdempotencyKey string     `json:"idempotencykey" binding:"required,max=64"`
+	StudyTime      int        `json:"studytime"`
+	Tomatoes       int        `json:"tomatoes"`
+	SubjectID      uint       `json:"subjectid"`
+	TodoID         uint       `json:"todoid"`
+	EndedAt        time.Time  `json:"endedat" binding:"required"` // 学习结束时间，决定计入哪一天
+	StartedAt      *time.Time `json:"startedat"`                  // 可选，有开始时间时同时保存为一次学习记录
+	Abandoned      bool       `json:"abandoned"`
+}
+
+// 条数上限由 service.MaxBatchSubmissions 限制
+type AddStudyDataBatchRequest struct {
+	Sessions []OfflineStudySession `json:"sessions" binding:"required,min=1,dive"`
+}
+
+// 一次带起止时间的专注学习，学习时长由起止时间计算
+type StudySessionRequest struct {
+	StartedAt      time.Time `json:"startedat" binding:"required"`
+	EndedAt        time.Time `json:"endedat" binding:"required"`
+	Tomatoes       int       `json:"tomatoes" binding:"min=0"`
+	Abandoned      bool      `json:"abandoned"` // 最后一个番茄钟是否中途放弃
+	SubjectID      uint      `json:"subjectid"`
+	TodoID         uint      `json:"todoid"` // 可选，学习所针对的待办
+	IdempotencyKey string    `json:"idempotencykey" binding:"max=64"`
+}
+
+// 学习分析，Weeks 为统计最近几周，默认8周
+type StudyAnalyticsQuery struct {
+	Weeks int `form:"weeks" binding:"omitempty,min=1,max=52"`
+}
+
+type HeatmapQuery struct {
+	Year int `form:"year" binding:"omitempty,min=2000,max=2100"`
+}
+
+// 导出学习数据，日期格式为 YYYY-MM-DD
+type ExportStudyDataQuery struct {
+	Format string `form:"format" binding:"required,oneof=csv ics"`
+	Start  string `form:"start"`
+	End    string `form:"end"`
+}
+
+type YearReviewQuery struct {
+	Year int `form:"year" binding:"omitempty,min=2000,max=2100"`
+}
+
+// 报告查询，Type 为空表示全部
+type ReportQuery struct {
+	PageQuery
+	Type string `form:"type" binding:"omitempty,oneof=weekly monthly"`
+}
+
+// 补录或修改过去某天的学习数据，具体上限由学习数据配置限制
+type StudyHistoryRequest struct {
+	Date      string `json:"date" binding:"required"` // YYYY-MM-DD
+	StudyTime int    `json:"studytime" binding:"min=0,max=1440"`
+	Tomatoes  int    `json:"tomatoes" binding:"min=0,max=96"`
+	Reason    string `json:"reason" binding:"required,max=200"`
+}
+
+type RemoveStudyHistoryRequest struct {
+	Date   string `json:"date" binding:"required"` // YYYY-MM-DD
+	Reason string `json:"reason" binding:"required,max=200"`
+}
+
+// 可疑学习数据查询，Status 为空表示全部
+type StudyDataFlagQuery struct {
+	PageQuery
+	Status string `form:"status" binding:"omitempty,oneof=pending voided dismissed"`
+}
+
+// 学习数据对账，UserID 为0表示所有用户
+type ReconcileStudyDataRequest struct {
+	UserID uint `json:"userid"`
+	Repair bool `json:"repair"`
 }
 
 //============音乐请求结构体=============
@@ -54,6 +215,13 @@ type UploadMusicRequest struct {
 	Title  string `form:"title" binding:"required"`
 }
 
+// 上报一次播放，Type 为 music 或 ambient
+type RecordPlayRequest struct {
+	Type    string `json:"type" binding:"required,oneof=music ambient"`
+	ID      uint   `json:"id" binding:"required"`
+	Seconds int    `json:"seconds" binding:"min=0"`
+}
+
 // ============环境音请求结构体=============
 type CreateAmbientSoundRequest struct {
 	Name string `form:"name" binding:"required"`
@@ -63,3 +231,146 @@ type CreateAmbientSoundRequest struct {
 type AIChatRequest struct {
 	Content string `json:"content" binding:"required"`
 }
+
+// ============分页请求结构体=============
+type PageQuery struct {
+	Page     int `form:"page"`
+	PageSize int `form:"pagesize"`
+}
+
+// 补齐默认分页参数，单页最多100条
+func (q *PageQuery) Normalize() {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 20
+	}
+	if q.PageSize > 100 {
+		q.PageSize = 100
+	}
+}
+
+// ============排行榜请求结构体=============
+type LeaderboardQuery struct {
+	Period string `form:"period" binding:"omitempty,oneof=daily weekly monthly all"`
+	Scope  string `form:"scope" binding:"omitempty,oneof=global friends"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type MyRankQuery struct {
+	Period     string `form:"period" binding:"omitempty,oneof=daily weekly monthly all"`
+	Scope      string `form:"scope" binding:"omitempty,oneof=global friends"`
+	Neighbours int    `form:"neighbours" binding:"omitempty,min=0,max=20"`
+}
+
+type UpdatePrivacyRequest struct {
+	LeaderboardOptOut *bool `json:"leaderboardoptout" binding:"required"`
+}
+
+type AddFriendRequest struct {
+	FriendID uint `json:"friendid" binding:"required"`
+}
+
+// ============科目请求结构体=============
+type CreateSubjectRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color"`
+}
+
+type UpdateSubjectRequest struct {
+	Name  string `json:"name" binding:"max=50"`
+	Color string `json:"color"`
+}
+
+type SubjectStatsQuery struct {
+	Range string `form:"range" binding:"omitempty,oneof=day week month year"`
+}
+
+//============待办清单请求结构体=============
+type CreateTodoListRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color"`
+}
+
+type UpdateTodoListRequest struct {
+	Name  string `json:"name" binding:"max=50"`
+	Color string `json:"color"`
+}
+
+// 把清单移到 afterid 之后，不传时移到最前
+type MoveTodoListRequest struct {
+	AfterID *uint `json:"afterid"`
+}
+
+type TodoTagRequest struct {
+	Name string `json:"name" binding:"required,max=32"`
+}
+
+// 邀请用户加入清单，viewer 只能查看，editor 可以修改待办
+type InviteTodoListMemberRequest struct {
+	UserID uint   `json:"userid" binding:"required"`
+	Role   string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+type UpdateTodoListMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=editor viewer"`
+}
+
+type RespondTodoListInviteRequest struct {
+	Accept bool `json:"accept"`
+}
+
+//============通知请求结构体=============
+// 在截止时间前 offsetminutes 分钟提醒，0表示截止时提醒
+type TodoReminderRequest struct {
+	OffsetMinutes int `json:"offsetminutes" binding:"min=0"`
+}
+
+// 通知列表查询，unread 为true时只返回未读通知
+type NotificationQuery struct {
+	PageQuery
+	Unread bool `form:"unread"`
+}
+
+// 免打扰时间为 HH:MM，可以跨过零点，都为空表示不开启免打扰
+type NotificationSettingRequest struct {
+	EmailEnabled bool   `json:"emailenabled"`
+	PushEnabled  bool   `json:"pushenabled"`
+	QuietStart   string `json:"quietstart"`
+	QuietEnd     string `json:"quietend"`
+}
+
+//============备忘录请求结构体=============
+// 日期为 YYYY-MM-DD，不传时为今天，内容为markdown原文
+type CreateNoteRequest struct {
+	Title   string `json:"title" binding:"required,max=100"`
+	Content string `json:"content"` // 长度由 NOTE_MAX_CONTENT_LENGTH 限制
+	Date    string `json:"date"`
+}
+
+// 更新备忘录，未传的字段保持不变
+type UpdateNoteRequest struct {
+	Title   *string `json:"title" binding:"omitempty,max=100"`
+	Content *string `json:"content"`
+	Date    *string `json:"date"`
+}
+
+type PinNoteRequest struct {
+	Pinned bool `json:"pinned"`
+}
+
+// 备忘录列表查询，start、end 为 YYYY-MM-DD，包含两端
+type NoteQuery struct {
+	PageQuery
+	Start string `form:"start"`
+	End   string `form:"end"`
+}
+
+//============搜索请求结构体=============
+// 关键词用空格分隔，types 可以重复传递，如 types=note&types=todo，不传时搜索全部
+type SearchQuery struct {
+	PageQuery
+	Q     string   `form:"q" binding:"required,max=100"`
+	Types []string `form:"types" binding:"omitempty,dive,oneof=note todo"`
+}
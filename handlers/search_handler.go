@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchService interface {
+	Search(userID uint, query string, types []string, page, pageSize int) ([]service.SearchResultInfo, int64, error)
+}
+
+type SearchHandler struct {
+	service SearchService
+}
+
+func NewSearchHandler(service SearchService) *SearchHandler {
+	return &SearchHandler{service: service}
+}
+
+// Search 全文搜索备忘录和待办，按相关度排序
+// @Router /api/search [get]
+func (h *SearchHandler) Search(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req SearchQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 搜索
+	results, total, err := h.service.Search(claims.UserID, req.Q, req.Types, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "搜索失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  results,
+	})
+}
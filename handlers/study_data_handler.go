@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"2026-FM247-BackEnd/logger"
 	"2026-FM247-BackEnd/service"
 	"2026-FM247-BackEnd/utils"
 	"time"
@@ -9,24 +10,43 @@ import (
 )
 
 type StudyDataService interface {
-	AddStudyData(userID uint, date time.Time, studyTime int, tomatoes int) (bool, string)
+	CheckRateLimit(userID uint, now time.Time) error
+	SubmitStudyData(userID uint, submission service.StudySubmission) service.StudySubmissionResult
+	SubmitStudyDataBatch(userID uint, submissions []service.StudySubmission, now time.Time) ([]service.StudySubmissionResult, error)
+	SubmitStudySession(userID uint, submission service.StudySubmission, now time.Time) service.StudySubmissionResult
 	GetDailyStudyData(userID uint, date time.Time) (service.DailyStudyDataInfo, string)
 	GetMonthlyStudyData(userID uint, date time.Time) (service.MonthlyStudyDataInfo, string)
 	GetTotalStudyData(userID uint) (service.TotalStudyDataInfo, string)
 	GetWeekStudyData(userID uint, date time.Time) ([]service.DailyStudyDataInfo, string)
 	GetMonthStudyData(userID uint, date time.Time) ([]service.DailyStudyDataInfo, string)
 	GetYearStudyData(userID uint, date time.Time) ([]service.MonthlyStudyDataInfo, string)
+	GetHeatmap(userID uint, year int, now time.Time) (*service.HeatmapInfo, error)
 }
 
 type StudyDataHandler struct {
-	service StudyDataService
+	service            StudyDataService
+	experienceService  ExperienceService
+	achievementService AchievementService
+	subjectService     SubjectService
 }
 
-func NewStudyDataHandler(service StudyDataService) *StudyDataHandler {
-	return &StudyDataHandler{service: service}
+func NewStudyDataHandler(service StudyDataService, experienceService ExperienceService, achievementService AchievementService, subjectService SubjectService) *StudyDataHandler {
+	return &StudyDataHandler{
+		service:            service,
+		experienceService:  experienceService,
+		achievementService: achievementService,
+		subjectService:     subjectService,
+	}
+}
+
+// 是否需要返回科目拆分，?breakdown=true 时开启，默认只返回总量
+func wantBreakdown(c *gin.Context) bool {
+	breakdown := c.Query("breakdown")
+	return breakdown == "true" || breakdown == "1"
 }
 
 // AddStudyData 增加学习数据
+// 请求头 Idempotency-Key（或请求体 idempotencykey）相同的重复提交只记录一次
 // @Router /api/studydata [post]
 func (h *StudyDataHandler) AddStudyData(c *gin.Context) {
 	// 1. 验证登录
@@ -41,16 +61,175 @@ func (h *StudyDataHandler) AddStudyData(c *gin.Context) {
 		FailWithMessage(c, "请求参数有误: "+err.Error())
 		return
 	}
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = req.IdempotencyKey
+	}
+	if len(idempotencyKey) > 64 {
+		FailWithMessage(c, "幂等key过长")
+		return
+	}
 	// 3. 增加学习数据
 	loc, _ := time.LoadLocation("Asia/Shanghai")
 	t := time.Now().In(loc)
-	success, msg := h.service.AddStudyData(claims.UserID, t, req.StudyTime, req.Tomatoes)
-	if !success {
-		FailWithMessage(c, "增加学习数据失败: "+msg)
+	if err := h.service.CheckRateLimit(claims.UserID, t); err != nil {
+		FailWithMessage(c, err.Error())
 		return
 	}
-	// 4. 返回结果
-	OkWithMessage(c, msg)
+	result := h.service.SubmitStudyData(claims.UserID, service.StudySubmission{
+		IdempotencyKey: idempotencyKey,
+		Date:           t,
+		StudyTime:      req.StudyTime,
+		Tomatoes:       req.Tomatoes,
+		SubjectID:      req.SubjectID,
+		TodoID:         req.TodoID,
+	})
+	switch result.Status {
+	case service.SubmissionFailed:
+		FailWithMessage(c, "增加学习数据失败: "+result.Message)
+		return
+	case service.SubmissionDuplicate:
+		Ok(c, result.Message, gin.H{"duplicate": true})
+		return
+	}
+	// 4. 返回结果，附带本次获得的经验和成就
+	Ok(c, result.Message, h.settleRewards(claims.UserID, []time.Time{t}, t))
+}
+
+// AddStudyDataBatch 批量补交离线记录的学习数据
+// @Router /api/studydata/batch [post]
+func (h *StudyDataHandler) AddStudyDataBatch(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req AddStudyDataBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 逐条记录，每条按结束时间所在的日期入账
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	if err := h.service.CheckRateLimit(claims.UserID, now); err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	submissions := make([]service.StudySubmission, 0, len(req.Sessions))
+	for _, session := range req.Sessions {
+		submissions = append(submissions, service.StudySubmission{
+			IdempotencyKey: session.IdempotencyKey,
+			Date:           session.EndedAt.In(loc),
+			StudyTime:      session.StudyTime,
+			Tomatoes:       session.Tomatoes,
+			SubjectID:      session.SubjectID,
+			TodoID:         session.TodoID,
+			StartedAt:      session.StartedAt,
+			Abandoned:      session.Abandoned,
+		})
+	}
+	results, err := h.service.SubmitStudyDataBatch(claims.UserID, submissions, now)
+	if err != nil {
+		FailWithMessage(c, "记录学习失败: "+err.Error())
+		return
+	}
+
+	// 4. 按成功入账的每次学习的结束时间结算奖励
+	var studiedAt []time.Time
+	for _, result := range results {
+		if result.Status == service.SubmissionApplied {
+			studiedAt = append(studiedAt, result.Date)
+		}
+	}
+	data := gin.H{"results": results}
+	if len(studiedAt) > 0 {
+		data["rewards"] = h.settleRewards(claims.UserID, studiedAt, now)
+	}
+	// 5. 返回结果
+	OkWithData(c, data)
+}
+
+// AddStudySession 记录一次带起止时间的专注学习，按结束时间所在的日期入账
+// @Router /api/studydata/sessions [post]
+func (h *StudyDataHandler) AddStudySession(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req StudySessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	idempotencyKey := c.GetHeader("Idempotency-Key")
+	if idempotencyKey == "" {
+		idempotencyKey = req.IdempotencyKey
+	}
+	if len(idempotencyKey) > 64 {
+		FailWithMessage(c, "幂等key过长")
+		return
+	}
+	// 3. 记录学习
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	if err := h.service.CheckRateLimit(claims.UserID, now); err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	startedAt := req.StartedAt.In(loc)
+	result := h.service.SubmitStudySession(claims.UserID, service.StudySubmission{
+		IdempotencyKey: idempotencyKey,
+		Date:           req.EndedAt.In(loc),
+		Tomatoes:       req.Tomatoes,
+		SubjectID:      req.SubjectID,
+		TodoID:         req.TodoID,
+		StartedAt:      &startedAt,
+		Abandoned:      req.Abandoned,
+	}, now)
+	switch result.Status {
+	case service.SubmissionFailed:
+		FailWithMessage(c, "记录学习失败: "+result.Message)
+		return
+	case service.SubmissionDuplicate:
+		Ok(c, result.Message, gin.H{"duplicate": true})
+		return
+	}
+	// 4. 返回结果，附带本次获得的经验和成就
+	Ok(c, result.Message, h.settleRewards(claims.UserID, []time.Time{result.Date}, now))
+}
+
+// 学习数据记录成功后结算经验和成就，任一环节失败只记录日志，不影响记录结果
+// studiedAt 为每次学习的结束时间，经验按其所在的日期发放，与时刻相关的成就按其判断
+func (h *StudyDataHandler) settleRewards(userID uint, studiedAt []time.Time, now time.Time) gin.H {
+	rewards := gin.H{}
+	seen := make(map[string]bool)
+	var days []time.Time
+	for _, at := range studiedAt {
+		day := at.Format("2006-01-02")
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, at)
+		}
+	}
+	experience, err := h.experienceService.AwardStudyExperienceForDays(userID, days)
+	if err != nil {
+		logger.Log.Errorf("发放学习经验失败: user=%d err=%v", userID, err)
+	} else {
+		rewards["experience"] = experience
+	}
+	achievements, err := h.achievementService.EvaluateAchievements(userID, studiedAt, now)
+	if err != nil {
+		logger.Log.Errorf("评估成就失败: user=%d err=%v", userID, err)
+	} else {
+		rewards["achievements"] = achievements
+	}
+	return rewards
 }
 
 // GetDailyStudyData 获取今日学习数据
@@ -70,6 +249,14 @@ func (h *StudyDataHandler) GetDailyStudyData(c *gin.Context) {
 		FailWithMessage(c, "获取每日学习数据失败: "+msg)
 		return
 	}
+	if wantBreakdown(c) {
+		days := []service.DailyStudyDataInfo{data}
+		if err := h.subjectService.AttachDailyBreakdown(claims.UserID, days); err != nil {
+			FailWithMessage(c, "获取科目拆分失败: "+err.Error())
+			return
+		}
+		data = days[0]
+	}
 	// 3. 返回结果
 	OkWithData(c, data)
 }
@@ -110,6 +297,12 @@ func (h *StudyDataHandler) GetWeekStudyData(c *gin.Context) {
 		FailWithMessage(c, "获取本周学习数据失败: "+msg)
 		return
 	}
+	if wantBreakdown(c) {
+		if err := h.subjectService.AttachDailyBreakdown(claims.UserID, data); err != nil {
+			FailWithMessage(c, "获取科目拆分失败: "+err.Error())
+			return
+		}
+	}
 	// 3. 返回结果
 	OkWithData(c, data)
 }
@@ -131,6 +324,12 @@ func (h *StudyDataHandler) GetMonthlyStudyData(c *gin.Context) {
 		FailWithMessage(c, "获取每月学习数据失败: "+msg)
 		return
 	}
+	if wantBreakdown(c) {
+		if err := h.subjectService.AttachDailyBreakdown(claims.UserID, data); err != nil {
+			FailWithMessage(c, "获取科目拆分失败: "+err.Error())
+			return
+		}
+	}
 	// 3. 返回结果
 	OkWithData(c, data)
 }
@@ -152,6 +351,42 @@ func (h *StudyDataHandler) GetYearStudyData(c *gin.Context) {
 		FailWithMessage(c, "获取本年学习数据失败: "+msg)
 		return
 	}
+	if wantBreakdown(c) {
+		if err := h.subjectService.AttachMonthlyBreakdown(claims.UserID, data); err != nil {
+			FailWithMessage(c, "获取科目拆分失败: "+err.Error())
+			return
+		}
+	}
 	// 3. 返回结果
 	OkWithData(c, data)
 }
+
+// GetHeatmap 获取年度学习热力图，year 为空时默认今年
+// @Router /api/studydata/heatmap [get]
+func (h *StudyDataHandler) GetHeatmap(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req HeatmapQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	if req.Year == 0 {
+		req.Year = now.Year()
+	}
+	// 3. 获取热力图
+	heatmap, err := h.service.GetHeatmap(claims.UserID, req.Year, now)
+	if err != nil {
+		FailWithMessage(c, "获取热力图失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, heatmap)
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SubjectService interface {
+	CreateSubject(userID uint, name, color string) (*service.SubjectInfo, error)
+	UpdateSubject(userID, subjectID uint, name, color string) error
+	DeleteSubject(userID, subjectID uint) error
+	GetSubjects(userID uint) ([]service.SubjectInfo, error)
+	GetSubjectStats(userID uint, rangeType string, date time.Time) (*service.SubjectStatsInfo, error)
+	AttachDailyBreakdown(userID uint, days []service.DailyStudyDataInfo) error
+	AttachMonthlyBreakdown(userID uint, months []service.MonthlyStudyDataInfo) error
+}
+
+type SubjectHandler struct {
+	service SubjectService
+}
+
+func NewSubjectHandler(service SubjectService) *SubjectHandler {
+	return &SubjectHandler{service: service}
+}
+
+// CreateSubject 创建科目
+// @Router /api/subjects [post]
+func (h *SubjectHandler) CreateSubject(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req CreateSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 创建科目
+	subject, err := h.service.CreateSubject(claims.UserID, req.Name, req.Color)
+	if err != nil {
+		FailWithMessage(c, "创建失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "创建成功", subject)
+}
+
+// GetSubjects 获取科目列表
+// @Router /api/subjects [get]
+func (h *SubjectHandler) GetSubjects(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询科目
+	subjects, err := h.service.GetSubjects(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, subjects)
+}
+
+// UpdateSubject 更新科目
+// @Router /api/subjects/:id [put]
+func (h *SubjectHandler) UpdateSubject(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取科目ID
+	subjectID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的科目ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req UpdateSubjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 更新科目
+	if err := h.service.UpdateSubject(claims.UserID, uint(subjectID), req.Name, req.Color); err != nil {
+		FailWithMessage(c, "更新失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "更新成功")
+}
+
+// DeleteSubject 删除科目
+// @Router /api/subjects/:id [delete]
+func (h *SubjectHandler) DeleteSubject(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取科目ID
+	subjectID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的科目ID")
+		return
+	}
+	// 3. 删除科目
+	if err := h.service.DeleteSubject(claims.UserID, uint(subjectID)); err != nil {
+		FailWithMessage(c, "删除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
+
+// GetSubjectStats 获取各科目学习统计
+// @Router /api/subjects/stats [get]
+func (h *SubjectHandler) GetSubjectStats(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	req := SubjectStatsQuery{Range: "week"}
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 查询统计
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	t := time.Now().In(loc)
+	stats, err := h.service.GetSubjectStats(claims.UserID, req.Range, t)
+	if err != nil {
+		FailWithMessage(c, "获取科目统计失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, stats)
+}
@@ -1,27 +1,51 @@
 package handler
 
 import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
 	"2026-FM247-BackEnd/service"
 	"2026-FM247-BackEnd/utils"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type TodoService interface {
-	CreateTodo(userID uint, event string) string
-	UpdateTodo(userID, todoID uint, event string) string
-	GetTodosByUserID(userID uint) ([]service.TodoInfo, string, bool)
+	CreateTodo(userID uint, input service.TodoInput, now time.Time) (service.TodoInfo, string)
+	UpdateTodo(userID, todoID uint, input service.TodoInput, scope string, now time.Time) string
+	GetTodosByUserID(userID uint, filter models.TodoFilter) ([]service.TodoInfo, string, bool)
 	GetTodoByID(userID, id uint) (service.TodoInfo, string)
-	DeleteTodo(userID, todoID uint) string
+	CompleteTodo(userID, todoID uint, now time.Time) (*service.TodoInfo, bool, error)
+	ReopenTodo(userID, todoID uint) (*service.TodoInfo, error)
+	ReorderSubtasks(userID, todoID uint, orderedIDs []uint) error
+	MoveTodo(userID, todoID uint, listID, afterID *uint) error
+	SetTodoTags(userID, todoID uint, names []string) ([]service.TodoTagInfo, error)
+	AssignTodo(userID, todoID uint, assigneeID *uint) (*service.TodoInfo, error)
+	GetAssignedTodos(userID uint, status string) ([]service.TodoInfo, error)
+	DeleteTodo(userID, todoID uint, scope string) string
+	GetRecurrenceHistory(userID, recurrenceID uint, page, pageSize int) (*service.TodoRecurrenceInfo, []service.TodoInfo, int64, error)
+}
+
+func newRecurrenceInput(req *TodoRecurrenceRequest) *service.RecurrenceInput {
+	if req == nil {
+		return nil
+	}
+	return &service.RecurrenceInput{
+		Preset: req.Preset,
+		Days:   req.Days,
+		RRule:  req.RRule,
+	}
 }
 
 type TodoHandler struct {
-	todoService *service.TodoService
+	todoService        *service.TodoService
+	experienceService  ExperienceService
+	achievementService AchievementService
 }
 
-func NewTodoHandler(todoService *service.TodoService) *TodoHandler {
-	return &TodoHandler{todoService: todoService}
+func NewTodoHandler(todoService *service.TodoService, experienceService ExperienceService, achievementService AchievementService) *TodoHandler {
+	return &TodoHandler{todoService: todoService, experienceService: experienceService, achievementService: achievementService}
 }
 
 // CreateTodo 创建待办事项
@@ -40,13 +64,24 @@ func (h *TodoHandler) CreateTodo(c *gin.Context) {
 		return
 	}
 	// 3. 创建待办事项
-	msg := h.todoService.CreateTodo(claims.UserID, req.Event)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	todo, msg := h.todoService.CreateTodo(claims.UserID, service.TodoInput{
+		ParentID:          req.ParentID,
+		ListID:            req.ListID,
+		Tags:              req.Tags,
+		Event:             &req.Event,
+		Description:       &req.Description,
+		Priority:          &req.Priority,
+		DueAt:             req.DueAt,
+		EstimatedTomatoes: &req.EstimatedTomatoes,
+		Recurrence:        newRecurrenceInput(req.Recurrence),
+	}, time.Now().In(loc))
 	if msg != "创建成功" {
 		FailWithMessage(c, "创建失败: "+msg)
 		return
 	}
 	// 4. 返回结果
-	OkWithMessage(c, msg)
+	Ok(c, msg, todo)
 }
 
 // GetTodos 获取用户的待办事项列表
@@ -58,8 +93,22 @@ func (h *TodoHandler) GetTodos(c *gin.Context) {
 		FailWithMessage(c, "无法获取用户信息: "+err.Error())
 		return
 	}
+	//绑定筛选和排序参数
+	var req TodoQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
 	//获取待办事项列表
-	todos, msg, ok := h.todoService.GetTodosByUserID(claims.UserID)
+	todos, msg, ok := h.todoService.GetTodosByUserID(claims.UserID, models.TodoFilter{
+		Status:    req.Status,
+		DueBefore: req.DueBefore,
+		Priority:  req.Priority,
+		ListID:    req.ListID,
+		TagID:     req.TagID,
+		SortBy:    req.Sort,
+		Order:     req.Order,
+	})
 	if msg != "" {
 		FailWithMessage(c, "获取失败: "+msg)
 		return
@@ -100,7 +149,7 @@ func (h *TodoHandler) GetTodoByID(c *gin.Context) {
 	OkWithData(c, todo)
 }
 
-// UpdateTodo 更新待办事项
+// UpdateTodo 更新待办事项，重复待办可以通过 scope=future 同时修改以后的每一次
 // @Router /api/todos/:id [put]
 func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 	// 1. 验证登录
@@ -122,8 +171,22 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 		FailWithMessage(c, "请求参数有误: "+err.Error())
 		return
 	}
+	var scope TodoScopeQuery
+	if err := c.ShouldBindQuery(&scope); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
 	// 4. 更新待办事项
-	msg := h.todoService.UpdateTodo(claims.UserID, uint(todoID), req.Event)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	msg := h.todoService.UpdateTodo(claims.UserID, uint(todoID), service.TodoInput{
+		Event:             req.Event,
+		Description:       req.Description,
+		Priority:          req.Priority,
+		DueAt:             req.DueAt,
+		ClearDueAt:        req.ClearDueAt,
+		EstimatedTomatoes: req.EstimatedTomatoes,
+		Recurrence:        newRecurrenceInput(req.Recurrence),
+	}, scope.Scope, time.Now().In(loc))
 	if msg != "更新成功" {
 		FailWithMessage(c, "更新失败: "+msg)
 		return
@@ -132,7 +195,217 @@ func (h *TodoHandler) UpdateTodo(c *gin.Context) {
 	OkWithMessage(c, msg)
 }
 
-// DeleteTodo 删除待办事项
+// CompleteTodo 完成待办事项，首次完成时发放经验并评估成就
+// @Router /api/todos/:id/complete [post]
+func (h *TodoHandler) CompleteTodo(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 完成待办事项
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	todo, changed, err := h.todoService.CompleteTodo(claims.UserID, uint(todoID), now)
+	if err != nil {
+		FailWithMessage(c, "完成失败: "+err.Error())
+		return
+	}
+	data := gin.H{"todo": todo}
+	// 4. 发放经验并评估成就，失败只记录日志
+	if changed {
+		experience, err := h.experienceService.AwardTodoExperience(claims.UserID, todo.ID, now)
+		if err != nil {
+			logger.Log.Errorf("发放待办经验失败: user=%d todo=%d err=%v", claims.UserID, todo.ID, err)
+		} else {
+			data["experience"] = experience
+		}
+		h.evaluateTodoAchievements(claims.UserID, now, data)
+	}
+	// 5. 返回结果
+	Ok(c, "已完成", data)
+}
+
+// ReopenTodo 把已完成的待办事项恢复为未完成
+// @Router /api/todos/:id/reopen [post]
+func (h *TodoHandler) ReopenTodo(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 恢复待办事项
+	todo, err := h.todoService.ReopenTodo(claims.UserID, uint(todoID))
+	if err != nil {
+		FailWithMessage(c, "恢复失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "已恢复为未完成", todo)
+}
+
+// ReorderSubtasks 调整子任务顺序
+// @Router /api/todos/:id/subtasks/order [put]
+func (h *TodoHandler) ReorderSubtasks(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req ReorderSubtasksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 调整顺序
+	if err := h.todoService.ReorderSubtasks(claims.UserID, uint(todoID), req.IDs); err != nil {
+		FailWithMessage(c, "调整顺序失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "调整成功")
+}
+
+// MoveTodo 调整顶层待办的顺序或移到其它清单
+// @Router /api/todos/:id/move [put]
+func (h *TodoHandler) MoveTodo(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req MoveTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 移动待办事项
+	if err := h.todoService.MoveTodo(claims.UserID, uint(todoID), req.ListID, req.AfterID); err != nil {
+		FailWithMessage(c, "移动失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "移动成功")
+}
+
+// SetTodoTags 设置待办事项的标签
+// @Router /api/todos/:id/tags [put]
+func (h *TodoHandler) SetTodoTags(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req SetTodoTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 设置标签
+	tags, err := h.todoService.SetTodoTags(claims.UserID, uint(todoID), req.Tags)
+	if err != nil {
+		FailWithMessage(c, "设置标签失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	Ok(c, "设置成功", tags)
+}
+
+// AssignTodo 把共享清单中的待办分配给成员，assigneeid 为空时取消分配
+// @Router /api/todos/:id/assignee [put]
+func (h *TodoHandler) AssignTodo(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req AssignTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 分配待办
+	todo, err := h.todoService.AssignTodo(claims.UserID, uint(todoID), req.AssigneeID)
+	if err != nil {
+		FailWithMessage(c, "分配失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	Ok(c, "分配成功", todo)
+}
+
+// GetAssignedTodos 获取分配给自己的待办
+// @Router /api/todos/assigned [get]
+func (h *TodoHandler) GetAssignedTodos(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req AssignedTodoQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 查询待办
+	todos, err := h.todoService.GetAssignedTodos(claims.UserID, req.Status)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, todos)
+}
+
+// DeleteTodo 把待办事项及其所有子任务移到回收站，重复待办可以通过 scope=future 停止重复
 // @Router /api/todos/:id [delete]
 func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	// 1. 验证登录
@@ -148,8 +421,13 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 		FailWithMessage(c, "无效的待办事项ID")
 		return
 	}
+	var scope TodoScopeQuery
+	if err := c.ShouldBindQuery(&scope); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
 	// 3. 删除待办事项
-	msg := h.todoService.DeleteTodo(claims.UserID, uint(todoID))
+	msg := h.todoService.DeleteTodo(claims.UserID, uint(todoID), scope.Scope)
 	if msg != "删除成功" {
 		FailWithMessage(c, "删除失败: "+msg)
 		return
@@ -157,3 +435,165 @@ func (h *TodoHandler) DeleteTodo(c *gin.Context) {
 	// 4. 返回结果
 	OkWithMessage(c, "删除成功")
 }
+
+// GetRecurrenceHistory 获取重复待办每一次的完成情况
+// @Router /api/todos/recurrences/:id/history [get]
+func (h *TodoHandler) GetRecurrenceHistory(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取重复规则ID
+	recurrenceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的重复规则ID")
+		return
+	}
+	// 3. 绑定分页参数
+	var req PageQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 4. 查询完成情况
+	recurrence, todos, total, err := h.todoService.GetRecurrenceHistory(claims.UserID, uint(recurrenceID), req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithData(c, gin.H{
+		"recurrence": recurrence,
+		"total":      total,
+		"list":       todos,
+	})
+}
+
+// GetTrash 获取回收站中的待办事项
+// @Router /api/todos/trash [get]
+func (h *TodoHandler) GetTrash(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定分页参数
+	var req PageQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 3. 查询回收站
+	todos, total, err := h.todoService.GetTrash(claims.UserID, req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  todos,
+	})
+}
+
+// RestoreTodo 从回收站恢复待办事项及与其一起删除的子任务
+// @Router /api/todos/:id/restore [post]
+func (h *TodoHandler) RestoreTodo(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取待办事项ID
+	todoID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的待办事项ID")
+		return
+	}
+	// 3. 恢复待办事项
+	todo, err := h.todoService.RestoreTodo(claims.UserID, uint(todoID))
+	if err != nil {
+		FailWithMessage(c, "恢复失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "恢复成功", todo)
+}
+
+// BatchTodos 批量完成、删除、移动待办事项或设置标签，返回每个待办的结果
+// @Router /api/todos/batch [post]
+func (h *TodoHandler) BatchTodos(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req BatchTodoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 执行批量操作
+	var results []service.TodoBatchResult
+	data := gin.H{}
+	switch req.Action {
+	case service.TodoBatchComplete:
+		loc, _ := time.LoadLocation("Asia/Shanghai")
+		now := time.Now().In(loc)
+		var changed []uint
+		results, changed, err = h.todoService.BatchCompleteTodos(claims.UserID, req.IDs, now)
+		// 首次完成的待办逐个发放经验，失败只记录日志
+		experiences := make([]*service.ExperienceResult, 0, len(changed))
+		for _, todoID := range changed {
+			experience, err := h.experienceService.AwardTodoExperience(claims.UserID, todoID, now)
+			if err != nil {
+				logger.Log.Errorf("发放待办经验失败: user=%d todo=%d err=%v", claims.UserID, todoID, err)
+				continue
+			}
+			experiences = append(experiences, experience)
+		}
+		data["experience"] = experiences
+		if len(changed) > 0 {
+			h.evaluateTodoAchievements(claims.UserID, now, data)
+		}
+	case service.TodoBatchDelete:
+		results, err = h.todoService.BatchDeleteTodos(claims.UserID, req.IDs)
+	case service.TodoBatchMove:
+		if req.ListID == nil {
+			FailWithMessage(c, "请选择目标清单")
+			return
+		}
+		results, err = h.todoService.BatchMoveTodos(claims.UserID, req.IDs, *req.ListID)
+	case service.TodoBatchTag:
+		mode := req.TagMode
+		if mode == "" {
+			mode = service.TodoTagModeAdd
+		}
+		results, err = h.todoService.BatchTagTodos(claims.UserID, req.IDs, req.Tags, mode)
+	}
+	if err != nil {
+		FailWithMessage(c, "批量操作失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	data["results"] = results
+	OkWithData(c, data)
+}
+
+// 完成待办后评估成就，新解锁的成就放入 data，失败只记录日志
+func (h *TodoHandler) evaluateTodoAchievements(userID uint, at time.Time, data gin.H) {
+	achievements, err := h.achievementService.EvaluateTodoAchievements(userID, at)
+	if err != nil {
+		logger.Log.Errorf("评估成就失败: user=%d err=%v", userID, err)
+		return
+	}
+	data["achievements"] = achievements
+}
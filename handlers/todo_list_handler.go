@@ -0,0 +1,470 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TodoListService interface {
+	CreateTodoList(userID uint, name, color string) (*service.TodoListInfo, error)
+	GetTodoLists(userID uint) ([]service.TodoListInfo, error)
+	UpdateTodoList(userID, listID uint, name, color string) error
+	DeleteTodoList(userID, listID uint) error
+	MoveTodoList(userID, listID uint, afterID *uint) error
+	CreateTodoTag(userID uint, name string) (*service.TodoTagInfo, error)
+	GetTodoTags(userID uint) ([]service.TodoTagInfo, error)
+	RenameTodoTag(userID, tagID uint, name string) error
+	DeleteTodoTag(userID, tagID uint) error
+	InviteTodoListMember(ownerID, listID, inviteeID uint, role string) (*service.TodoListMemberInfo, error)
+	GetTodoListInvites(userID uint) ([]service.TodoListInviteInfo, error)
+	RespondTodoListInvite(userID, listID uint, accept bool) error
+	GetTodoListMembers(userID, listID uint) ([]service.TodoListMemberInfo, error)
+	UpdateTodoListMemberRole(ownerID, listID, memberID uint, role string) error
+	RemoveTodoListMember(userID, listID, memberID uint) error
+	GetTodoActivities(userID, listID uint, page, pageSize int) ([]service.TodoActivityInfo, int64, error)
+}
+
+type TodoListHandler struct {
+	service TodoListService
+}
+
+func NewTodoListHandler(service TodoListService) *TodoListHandler {
+	return &TodoListHandler{service: service}
+}
+
+// CreateTodoList 创建待办清单
+// @Router /api/todo-lists [post]
+func (h *TodoListHandler) CreateTodoList(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req CreateTodoListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 创建清单
+	list, err := h.service.CreateTodoList(claims.UserID, req.Name, req.Color)
+	if err != nil {
+		FailWithMessage(c, "创建失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "创建成功", list)
+}
+
+// GetTodoLists 获取待办清单列表
+// @Router /api/todo-lists [get]
+func (h *TodoListHandler) GetTodoLists(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询清单
+	lists, err := h.service.GetTodoLists(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, lists)
+}
+
+// UpdateTodoList 更新待办清单
+// @Router /api/todo-lists/:id [put]
+func (h *TodoListHandler) UpdateTodoList(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req UpdateTodoListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 更新清单
+	if err := h.service.UpdateTodoList(claims.UserID, uint(listID), req.Name, req.Color); err != nil {
+		FailWithMessage(c, "更新失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "更新成功")
+}
+
+// DeleteTodoList 删除待办清单，清单中的待办移到收集箱
+// @Router /api/todo-lists/:id [delete]
+func (h *TodoListHandler) DeleteTodoList(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 删除清单
+	if err := h.service.DeleteTodoList(claims.UserID, uint(listID)); err != nil {
+		FailWithMessage(c, "删除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
+
+// MoveTodoList 调整待办清单的顺序
+// @Router /api/todo-lists/:id/move [put]
+func (h *TodoListHandler) MoveTodoList(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req MoveTodoListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 调整顺序
+	if err := h.service.MoveTodoList(claims.UserID, uint(listID), req.AfterID); err != nil {
+		FailWithMessage(c, "调整顺序失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "调整成功")
+}
+
+// CreateTodoTag 创建待办标签，已存在时直接返回
+// @Router /api/todo-tags [post]
+func (h *TodoListHandler) CreateTodoTag(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	var req TodoTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 3. 创建标签
+	tag, err := h.service.CreateTodoTag(claims.UserID, req.Name)
+	if err != nil {
+		FailWithMessage(c, "创建失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "创建成功", tag)
+}
+
+// GetTodoTags 获取待办标签列表
+// @Router /api/todo-tags [get]
+func (h *TodoListHandler) GetTodoTags(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询标签
+	tags, err := h.service.GetTodoTags(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, tags)
+}
+
+// RenameTodoTag 重命名待办标签
+// @Router /api/todo-tags/:id [put]
+func (h *TodoListHandler) RenameTodoTag(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取标签ID
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的标签ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req TodoTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 重命名标签
+	if err := h.service.RenameTodoTag(claims.UserID, uint(tagID), req.Name); err != nil {
+		FailWithMessage(c, "更新失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "更新成功")
+}
+
+// DeleteTodoTag 删除待办标签
+// @Router /api/todo-tags/:id [delete]
+func (h *TodoListHandler) DeleteTodoTag(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取标签ID
+	tagID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的标签ID")
+		return
+	}
+	// 3. 删除标签
+	if err := h.service.DeleteTodoTag(claims.UserID, uint(tagID)); err != nil {
+		FailWithMessage(c, "删除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "删除成功")
+}
+
+// InviteTodoListMember 邀请用户加入清单，只能邀请已把自己加为好友的用户，已邀请过时修改角色
+// @Router /api/todo-lists/:id/members [post]
+func (h *TodoListHandler) InviteTodoListMember(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req InviteTodoListMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 邀请成员
+	member, err := h.service.InviteTodoListMember(claims.UserID, uint(listID), req.UserID, req.Role)
+	if err != nil {
+		FailWithMessage(c, "邀请失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	Ok(c, "邀请成功", member)
+}
+
+// GetTodoListMembers 获取清单的创建者和成员
+// @Router /api/todo-lists/:id/members [get]
+func (h *TodoListHandler) GetTodoListMembers(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 查询成员
+	members, err := h.service.GetTodoListMembers(claims.UserID, uint(listID))
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, members)
+}
+
+// UpdateTodoListMember 修改成员的角色
+// @Router /api/todo-lists/:id/members/:user_id [put]
+func (h *TodoListHandler) UpdateTodoListMember(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID和成员ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的用户ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req UpdateTodoListMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 修改角色
+	if err := h.service.UpdateTodoListMemberRole(claims.UserID, uint(listID), uint(memberID), req.Role); err != nil {
+		FailWithMessage(c, "修改失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithMessage(c, "修改成功")
+}
+
+// RemoveTodoListMember 移除成员或取消邀请，成员移除自己表示退出清单
+// @Router /api/todo-lists/:id/members/:user_id [delete]
+func (h *TodoListHandler) RemoveTodoListMember(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID和成员ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	memberID, err := strconv.ParseUint(c.Param("user_id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的用户ID")
+		return
+	}
+	// 3. 移除成员
+	if err := h.service.RemoveTodoListMember(claims.UserID, uint(listID), uint(memberID)); err != nil {
+		FailWithMessage(c, "移除失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithMessage(c, "移除成功")
+}
+
+// GetTodoListInvites 获取收到的清单邀请
+// @Router /api/todo-lists/invites [get]
+func (h *TodoListHandler) GetTodoListInvites(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 查询邀请
+	invites, err := h.service.GetTodoListInvites(claims.UserID)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 3. 返回结果
+	OkWithData(c, invites)
+}
+
+// RespondTodoListInvite 接受或拒绝清单邀请
+// @Router /api/todo-lists/:id/invite [post]
+func (h *TodoListHandler) RespondTodoListInvite(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 绑定请求参数
+	var req RespondTodoListInviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	// 4. 处理邀请
+	if err := h.service.RespondTodoListInvite(claims.UserID, uint(listID), req.Accept); err != nil {
+		FailWithMessage(c, "操作失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	if req.Accept {
+		OkWithMessage(c, "已加入清单")
+		return
+	}
+	OkWithMessage(c, "已拒绝邀请")
+}
+
+// GetTodoActivities 获取清单中待办的修改记录
+// @Router /api/todo-lists/:id/activities [get]
+func (h *TodoListHandler) GetTodoActivities(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 获取清单ID
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		FailWithMessage(c, "无效的清单ID")
+		return
+	}
+	// 3. 绑定分页参数
+	var req PageQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return
+	}
+	req.Normalize()
+	// 4. 查询修改记录
+	activities, total, err := h.service.GetTodoActivities(claims.UserID, uint(listID), req.Page, req.PageSize)
+	if err != nil {
+		FailWithMessage(c, "获取失败: "+err.Error())
+		return
+	}
+	// 5. 返回结果
+	OkWithData(c, gin.H{
+		"total": total,
+		"list":  activities,
+	})
+}
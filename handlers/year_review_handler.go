@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"2026-FM247-BackEnd/service"
+	"2026-FM247-BackEnd/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type YearReviewService interface {
+	Generate(userID uint, year int, now time.Time) (*service.YearReviewInfo, error)
+	GetYearReview(userID uint, year int, now time.Time) (*service.YearReviewInfo, error)
+	GetSharedYearReview(token string) (*service.YearReviewInfo, error)
+}
+
+type YearReviewHandler struct {
+	service YearReviewService
+}
+
+func NewYearReviewHandler(service YearReviewService) *YearReviewHandler {
+	return &YearReviewHandler{service: service}
+}
+
+// 解析要查看的年份，为空时默认今年，不能查看未来的年份
+func bindYearReviewQuery(c *gin.Context) (int, time.Time, bool) {
+	var req YearReviewQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		FailWithMessage(c, "请求参数有误: "+err.Error())
+		return 0, time.Time{}, false
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	now := time.Now().In(loc)
+	if req.Year == 0 {
+		req.Year = now.Year()
+	}
+	if req.Year > now.Year() {
+		FailWithMessage(c, "不能查看未来的年度回顾")
+		return 0, time.Time{}, false
+	}
+	return req.Year, now, true
+}
+
+// GetYearReview 获取年度回顾，还没有生成时立即生成
+// @Router /api/year-review [get]
+func (h *YearReviewHandler) GetYearReview(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	year, now, ok := bindYearReviewQuery(c)
+	if !ok {
+		return
+	}
+	// 3. 获取年度回顾
+	review, err := h.service.GetYearReview(claims.UserID, year, now)
+	if err != nil {
+		FailWithMessage(c, "获取年度回顾失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	OkWithData(c, review)
+}
+
+// RegenerateYearReview 重新生成年度回顾，分享链接保持不变
+// @Router /api/year-review/regenerate [post]
+func (h *YearReviewHandler) RegenerateYearReview(c *gin.Context) {
+	// 1. 验证登录
+	claims, err := utils.GetClaimsFromContext(c)
+	if err != nil {
+		FailWithMessage(c, "无法获取用户信息: "+err.Error())
+		return
+	}
+	// 2. 绑定请求参数
+	year, now, ok := bindYearReviewQuery(c)
+	if !ok {
+		return
+	}
+	// 3. 重新生成
+	review, err := h.service.Generate(claims.UserID, year, now)
+	if err != nil {
+		FailWithMessage(c, "生成年度回顾失败: "+err.Error())
+		return
+	}
+	// 4. 返回结果
+	Ok(c, "生成成功", review)
+}
+
+// GetSharedYearReview 通过分享链接查看年度回顾，无需登录
+// @Router /api/share/year-review/:token [get]
+func (h *YearReviewHandler) GetSharedYearReview(c *gin.Context) {
+	review, err := h.service.GetSharedYearReview(c.Param("token"))
+	if err != nil {
+		FailWithMessage(c, err.Error())
+		return
+	}
+	OkWithData(c, review)
+}
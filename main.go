@@ -8,14 +8,32 @@ import (
 	"2026-FM247-BackEnd/router"
 	"2026-FM247-BackEnd/service"
 	"2026-FM247-BackEnd/storage"
+	"context"
+	"flag"
 	"fmt"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
 
+// 允许跨域请求和建立WebSocket连接的来源
+var allowOrigins = []string{
+	"http://localhost:5173", // 前端vite的默认启动地址
+	"http://localhost:3000", // 前端自己定义的启动地址
+}
+
 func main() {
+	rebuildLeaderboard := flag.Bool("rebuild-leaderboard", false, "根据数据库重建排行榜后退出")
+	reconcileStudyData := flag.Bool("reconcile-studydata", false, "核对学习数据并输出差异后退出")
+	reconcileUserID := flag.Uint("reconcile-user", 0, "只核对指定用户的学习数据，0表示所有用户")
+	reconcileRepair := flag.Bool("repair", false, "与 -reconcile-studydata 一起使用，修复发现的差异")
+	flag.Parse()
+
 	// 优先从 go.env 加载环境变量（开发环境）
 	_ = godotenv.Overload("go.env")
 
@@ -51,27 +69,109 @@ func main() {
 	userRepo := repository.NewUserRepository(db)
 	tokenRepo := repository.NewTokenBlacklistRepository(db)
 	todoRepo := repository.NewTodoRepository(db)
+	todoListRepo := repository.NewTodoListRepository(db)
 	studyDataRepo := repository.NewStudyDataRepository(db, redisClient)
 	musicRepo := repository.NewMusicRepository(db)
 	ambientSoundRepo := repository.NewAmbientSoundRepository(db)
 	aichatRepo := repository.NewAIChatRepository(redisClient)
+	experienceRepo := repository.NewExperienceRepository(db)
+	achievementRepo := repository.NewAchievementRepository(db)
+	leaderboardRepo := repository.NewLeaderboardRepository(db, redisClient)
+	friendRepo := repository.NewFriendRepository(db)
+	subjectRepo := repository.NewSubjectRepository(db)
+	antiCheatRepo := repository.NewAntiCheatRepository(db, redisClient)
+	reportRepo := repository.NewReportRepository(db, redisClient)
+	playRepo := repository.NewPlayRepository(db)
+	yearReviewRepo := repository.NewYearReviewRepository(db, redisClient)
+	insightRepo := repository.NewInsightRepository(db, redisClient)
+	notificationRepo := repository.NewNotificationRepository(db)
+	noteRepo := repository.NewNoteRepository(db)
+	searchRepo := repository.NewSearchRepository(db)
 
 	//service层初始化
-	userService := service.NewUserService(userRepo, tokenRepo, storage)
+	studyDataConfig := config.LoadStudyDataConfig()
+	studyDataFlusher := service.NewStudyDataFlusher(studyDataRepo, studyDataConfig)
+	leaderboardService := service.NewLeaderboardService(leaderboardRepo, friendRepo, studyDataFlusher, storage)
+	userService := service.NewUserService(userRepo, tokenRepo, storage, leaderboardService)
 	tokenService := service.NewTokenBlacklistService(tokenRepo)
-	todoService := service.NewTodoService(todoRepo)
+	notificationConfig := config.LoadNotificationConfig()
+	pushHub := service.NewPushHub()
+	notificationService := service.NewNotificationService(notificationRepo, notificationConfig,
+		service.NewInboxChannel(notificationRepo), service.NewEmailChannel(notificationConfig), pushHub)
+	reminderService := service.NewReminderService(notificationRepo, todoRepo, todoListRepo, notificationService, notificationConfig)
+	todoService := service.NewTodoService(todoRepo, todoListRepo, reminderService, config.LoadTodoConfig())
+	todoListService := service.NewTodoListService(todoListRepo, friendRepo, notificationService)
+	noteService := service.NewNoteService(noteRepo, config.LoadNoteConfig())
+	searchService := service.NewSearchService(searchRepo)
 	musicService := service.NewMusicService(musicRepo, storage)
-	studyDataService := service.NewStudyDataService(studyDataRepo)
+	friendService := service.NewFriendService(friendRepo, userRepo, storage)
+	subjectService := service.NewSubjectService(subjectRepo, studyDataRepo)
+	reconcileService := service.NewReconcileService(studyDataRepo, studyDataFlusher)
+	correctionService := service.NewCorrectionService(studyDataRepo, studyDataFlusher, leaderboardService, todoService, subjectService, studyDataConfig)
+	antiCheatService := service.NewAntiCheatService(antiCheatRepo, correctionService, studyDataConfig)
+	studyDataService := service.NewStudyDataService(studyDataRepo, leaderboardService, subjectService, todoService, antiCheatService)
+	reportService := service.NewReportService(reportRepo, studyDataRepo, studyDataFlusher)
+	playService := service.NewPlayService(playRepo)
+	exportService := service.NewExportService(studyDataRepo)
+	analyticsService := service.NewAnalyticsService(studyDataRepo)
+	yearReviewService := service.NewYearReviewService(yearReviewRepo, studyDataRepo, achievementRepo, userRepo, reportRepo, playService)
 	ambientSoundService := service.NewAmbientSoundService(ambientSoundRepo, storage)
 	aichatService := service.NewAIChatService(aichatRepo, aiClient)
+	insightService := service.NewInsightService(insightRepo, studyDataRepo, aiClient, config.LoadInsightConfig())
+	experienceService := service.NewExperienceService(experienceRepo, studyDataRepo, config.LoadExperienceConfig())
+	achievementService := service.NewAchievementService(achievementRepo, studyDataRepo, userRepo)
+	if err := achievementService.SeedAchievements(); err != nil {
+		fmt.Printf("初始化成就目录失败: %v\n", err)
+	}
+
+	if *rebuildLeaderboard {
+		if err := leaderboardService.Rebuild(); err != nil {
+			fmt.Printf("重建排行榜失败: %v\n", err)
+			return
+		}
+		fmt.Println("排行榜重建完成")
+		return
+	}
+
+	if *reconcileStudyData {
+		report, err := reconcileService.Reconcile(*reconcileUserID, *reconcileRepair)
+		if err != nil {
+			fmt.Printf("学习数据对账失败: %v\n", err)
+			return
+		}
+		for _, d := range report.Discrepancies {
+			fmt.Printf("用户%d %s %s: 应为 %d分钟/%d个番茄钟，实际 %d分钟/%d个番茄钟\n",
+				d.UserID, d.Source, d.Period, d.ExpectedStudyTime, d.ExpectedTomatoes, d.ActualStudyTime, d.ActualTomatoes)
+		}
+		fmt.Printf("共核对%d个用户，发现%d处差异，修复%d个用户\n", report.CheckedUsers, len(report.Discrepancies), report.RepairedUsers)
+		return
+	}
 	//handler层初始化
 	authhandler := handler.NewAuthHandler(tokenService, userService, studyDataService)
 	avatarHandler := handler.NewAvatarHandler(userService)
-	todohandler := handler.NewTodoHandler(todoService)
-	studydatahandler := handler.NewStudyDataHandler(studyDataService)
+	todohandler := handler.NewTodoHandler(todoService, experienceService, achievementService)
+	todoListHandler := handler.NewTodoListHandler(todoListService)
+	studydatahandler := handler.NewStudyDataHandler(studyDataService, experienceService, achievementService, subjectService)
 	musichandler := handler.NewMusicHandler(musicService)
 	ambientSoundHandler := handler.NewAmbientSoundHandler(ambientSoundService)
 	aiChatHandler := handler.NewAIChatHandler(aichatService)
+	experienceHandler := handler.NewExperienceHandler(experienceService)
+	achievementHandler := handler.NewAchievementHandler(achievementService)
+	leaderboardHandler := handler.NewLeaderboardHandler(leaderboardService)
+	friendHandler := handler.NewFriendHandler(friendService)
+	subjectHandler := handler.NewSubjectHandler(subjectService)
+	reconcileHandler := handler.NewReconcileHandler(reconcileService)
+	correctionHandler := handler.NewCorrectionHandler(correctionService)
+	antiCheatHandler := handler.NewAntiCheatHandler(antiCheatService)
+	reportHandler := handler.NewReportHandler(reportService)
+	playHandler := handler.NewPlayHandler(playService)
+	yearReviewHandler := handler.NewYearReviewHandler(yearReviewService)
+	exportHandler := handler.NewExportHandler(exportService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService)
+	insightHandler := handler.NewInsightHandler(insightService)
+	notificationHandler := handler.NewNotificationHandler(notificationService, reminderService, pushHub, allowOrigins)
+	noteHandler := handler.NewNoteHandler(noteService)
+	searchHandler := handler.NewSearchHandler(searchService)
 
 	// 启动服务器
 	// r := gin.New()
@@ -81,20 +181,51 @@ func main() {
 	r.Static("/uploads", "./uploads")
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins: []string{ // 允许的请求源
-			"http://localhost:5173", // 前端vite的默认启动地址
-			"http://localhost:3000", // 前端自己定义的启动地址
-		},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},                             // 允许的请求方法
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Cookie"}, // 允许的请求头
+		AllowOrigins:     allowOrigins,                                                                                       // 允许的请求源
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},                                                // 允许的请求方法
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Cookie", "Idempotency-Key"}, // 允许的请求头
 		AllowCredentials: true,
 	}))
 
-	router.RegisterRoutes(r, authhandler, avatarHandler, todohandler, studydatahandler, musichandler, ambientSoundHandler, aiChatHandler)
+	router.RegisterRoutes(r, authhandler, avatarHandler, todohandler, studydatahandler, musichandler, ambientSoundHandler, aiChatHandler, experienceHandler, achievementHandler, leaderboardHandler, friendHandler, subjectHandler, reconcileHandler, correctionHandler, antiCheatHandler, reportHandler, playHandler, yearReviewHandler, exportHandler, analyticsHandler, insightHandler, todoListHandler, notificationHandler, noteHandler, searchHandler)
+	studyDataFlusher.Start()
+	reportService.Start()
+	yearReviewService.Start()
+	todoService.Start()
+	reminderService.Start()
+
 	port := ":" + config.AppConfig.ServerPort
-	fmt.Printf("服务器正在运行，监听端口 %s\n", port)
-	if err := r.Run(port); err != nil {
-		fmt.Printf("服务器启动失败: %v\n", err)
+	server := &http.Server{
+		Addr:    port,
+		Handler: r,
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		fmt.Printf("服务器正在运行，监听端口 %s\n", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("服务器启动失败: %v\n", err)
+			stop()
+		}
+	}()
+	<-ctx.Done()
+
+	fmt.Println("正在关闭服务器")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		fmt.Printf("关闭服务器时出错: %v\n", err)
+	}
+
+	reportService.Stop()
+	yearReviewService.Stop()
+	todoService.Stop()
+	reminderService.Stop()
+
+	// 请求处理完毕后再落库，保证关闭前写入的数据不会丢失
+	fmt.Println("正在将学习数据写入数据库")
+	if err := studyDataFlusher.Stop(); err != nil {
+		fmt.Printf("学习数据落库失败: %v\n", err)
 	}
 
 	fmt.Println("正在与数据库断开连接")
@@ -12,6 +12,11 @@ func AuthMiddleware(tokenblacklistservice handler.TokenService) gin.HandlerFunc
 	return func(c *gin.Context) {
 		// 从请求头中获取Authorization字段
 		authHeader := c.GetHeader("Authorization")
+		// 浏览器建立WebSocket连接时无法设置请求头，通过子协议传递 "bearer, <token>"
+		// 不使用URL参数，避免token出现在访问日志中
+		if authHeader == "" && c.IsWebsocket() {
+			authHeader = websocketToken(c.GetHeader("Sec-WebSocket-Protocol"))
+		}
 		if authHeader == "" {
 			handler.FailWithMessage(c, "请先登录")
 			c.Abort()
@@ -46,3 +51,14 @@ func AuthMiddleware(tokenblacklistservice handler.TokenService) gin.HandlerFunc
 		c.Next()
 	}
 }
+
+// 从 Sec-WebSocket-Protocol 中取出 bearer 之后的token
+func websocketToken(header string) string {
+	protocols := strings.Split(header, ",")
+	for i := 0; i+1 < len(protocols); i++ {
+		if strings.TrimSpace(protocols[i]) == handler.WebSocketAuthProtocol {
+			return strings.TrimSpace(protocols[i+1])
+		}
+	}
+	return ""
+}
@@ -43,10 +43,27 @@ type Note struct {
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	UserID    uint           `json:"user_id"`
+	UserID    uint           `json:"user_id" gorm:"index:idx_user_date,priority:1"`
 	Title     string         `json:"title"`
-	Date      time.Time      `json:"date"`
-	Content   string         `json:"content"`
+	Date      time.Time      `json:"date" gorm:"index:idx_user_date,priority:2"`
+	Content   string         `json:"content"` // markdown原文，原样保存
+	Pinned    bool           `json:"pinned" gorm:"not null;default:false"`
+}
+
+// SearchFilter 全文搜索的条件，不对应数据表
+type SearchFilter struct {
+	Keywords []string // 同时包含所有关键词才匹配
+	Types    []string // note 备忘录 / todo 待办，为空表示全部
+}
+
+// SearchHit 全文搜索的一条结果，待办的 Title 为事件，Content 为备注，查询结果，不对应数据表
+type SearchHit struct {
+	Type      string
+	ID        uint
+	Title     string
+	Content   string
+	Score     float64 // 相关度，越大越靠前
+	UpdatedAt time.Time
 }
 
 type User struct {
@@ -60,22 +77,190 @@ type User struct {
 	Level      int       `gorm:"default:1" json:"level"`      // 等级
 	Avatar     string    `gorm:"type:varchar(500);default:'default-avatar.png'" json:"avatar_path"`
 	IsAdmin    bool      `gorm:"default:false" json:"is_admin"` // 是否为管理员
+	DailyGoal  int       `gorm:"default:120" json:"daily_goal"` // 每日学习目标，单位分钟
 	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// 扩展字段（根据需求添加）
-	LastLoginAt *time.Time `json:"last_login_at"`
-	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	LastLoginAt       *time.Time `json:"last_login_at"`
+	IsActive          bool       `gorm:"default:true" json:"is_active"`
+	LeaderboardOptOut bool       `gorm:"default:false" json:"leaderboard_opt_out"` // 不参与排行榜
 	// Settings    string     `gorm:"type:json" json:"settings"` // 用户设置，JSON格式存储
 }
 
+// TodoPositionGap 相邻待办、清单之间的位置间隔，插入时不需要移动其它待办
+const TodoPositionGap = 1024
+
 // Todo 待办事项表
 type Todo struct {
+	ID                uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            uint           `gorm:"not null;index" json:"user_id"`           // 关联用户ID
+	ParentID          *uint          `gorm:"index" json:"parent_id"`                  // 所属的上级待办，为空表示顶层待办
+	ListID            *uint          `gorm:"index" json:"list_id"`                    // 所属清单，只对顶层待办有效，为空表示收集箱
+	Position          int            `gorm:"default:0" json:"position"`               // 在同级子任务或清单中的顺序，越小越靠前
+	Event             string         `gorm:"type:varchar(255);not null" json:"event"` // 事件
+	Description       string         `gorm:"type:text" json:"description"`            // 备注
+	Priority          int            `gorm:"default:0;index" json:"priority"`         // 优先级：0无 1低 2中 3高
+	DueAt             *time.Time     `gorm:"index" json:"due_at"`                     // 截止时间
+	EstimatedTomatoes int            `gorm:"default:0" json:"estimated_tomatoes"`     // 预计需要的番茄钟数量
+	ActualStudyTime   int            `gorm:"default:0" json:"actual_study_time"`      // 在该待办上实际学习的分钟数
+	ActualTomatoes    int            `gorm:"default:0" json:"actual_tomatoes"`        // 在该待办上实际完成的番茄钟数量
+	Completed         bool           `gorm:"default:false;index" json:"completed"`    // 是否已完成
+	CompletedAt       *time.Time     `json:"completed_at"`                            // 完成时间
+	RecurrenceID      *uint          `gorm:"index" json:"recurrence_id"`              // 由重复规则生成时对应的规则
+	OccurrenceAt      *time.Time     `json:"occurrence_at"`                           // 对应重复规则中的哪一次
+	AssigneeID        *uint          `gorm:"index" json:"assignee_id"`                // 共享清单中负责该待办的成员
+	CreatedBy         uint           `json:"created_by"`                              // 创建者，共享清单中可能不是 UserID
+	UpdatedBy         uint           `json:"updated_by"`                              // 最后修改的用户
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`        // 创建时间
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"deleted_at"` // 移到回收站的时间
+	User              User           `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TodoRecurrence 重复待办的规则和模板，每次重复生成一条 Todo
+type TodoRecurrence struct {
+	ID                uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            uint      `gorm:"not null;index" json:"user_id"`
+	RRule             string    `gorm:"type:varchar(255);not null" json:"rrule"` // RFC 5545 RRULE 的子集
+	StartAt           time.Time `gorm:"not null" json:"start_at"`                // 规则的起点，时刻部分为每次的截止时刻
+	Event             string    `gorm:"type:varchar(255);not null" json:"event"` // 以下为生成待办时使用的模板
+	Description       string    `gorm:"type:text" json:"description"`
+	Priority          int       `gorm:"default:0" json:"priority"`
+	EstimatedTomatoes int       `gorm:"default:0" json:"estimated_tomatoes"`
+	ListID            *uint     `json:"list_id"`
+	LastOccurrenceAt  time.Time `gorm:"not null" json:"last_occurrence_at"` // 最近一次生成的待办对应的时间
+	Active            bool      `gorm:"default:true;index" json:"active"`   // 规则结束或被停止后为false
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TodoFilter 待办事项列表的查询条件，不对应数据表
+type TodoFilter struct {
+	Status    string     // active 未完成 / completed 已完成，为空表示全部
+	DueBefore *time.Time // 截止时间早于该时间
+	Priority  *int
+	ListID    *uint  // 所属清单，0表示收集箱，为空表示全部
+	TagID     *uint  // 带有该标签
+	SortBy    string // created_at / due_at / priority / position，默认 created_at
+	Order     string // asc / desc，默认 desc
+}
+
+// TodoDailyStudyData 按待办拆分的每日学习数据
+type TodoDailyStudyData struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_user_todo_date"` //联合索引,保证每个待办每天一条记录
+	TodoID    uint      `json:"todo_id" gorm:"uniqueIndex:idx_user_todo_date;index"`
+	Date      time.Time `json:"date" gorm:"uniqueIndex:idx_user_todo_date"`
+	StudyTime int       `json:"study_time"`
+	Tomatoes  int       `json:"tomatoes"`
+}
+
+// TodoStudySummary 一段时间内某个待办的学习汇总，查询结果，不对应数据表
+type TodoStudySummary struct {
+	TodoID            uint
+	Event             string
+	EstimatedTomatoes int
+	StudyTime         int
+	Tomatoes          int
+}
+
+// TodoList 用户创建的待办清单，例如按课程划分
+type TodoList struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	Name      string    `gorm:"type:varchar(50);not null" json:"name"`
+	Color     string    `gorm:"type:varchar(7);default:'#409EFF'" json:"color"` // 十六进制颜色，如 #409EFF
+	Position  int       `gorm:"default:0" json:"position"`                      // 清单的顺序，越小越靠前
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TodoListMember 共享清单的成员，清单创建者不在其中
+// Role：viewer 只能查看 / editor 可以编辑；Status：pending 已邀请 / accepted 已加入
+type TodoListMember struct {
 	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	UserID    uint      `gorm:"not null;index" json:"user_id"`           // 关联用户ID
-	Event     string    `gorm:"type:varchar(255);not null" json:"event"` // 事件
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`        // 创建时间
-	User      User      `gorm:"foreignKey:UserID" json:"-"`
+	ListID    uint      `gorm:"not null;uniqueIndex:idx_list_user" json:"list_id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_list_user;index" json:"user_id"`
+	Role      string    `gorm:"type:varchar(16);not null" json:"role"`
+	Status    string    `gorm:"type:varchar(16);not null" json:"status"`
+	InvitedBy uint      `json:"invited_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TodoActivity 清单中待办的修改记录，共享清单中用于查看是谁做的修改
+type TodoActivity struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ListID    uint      `gorm:"not null;index:idx_list_created" json:"list_id"`
+	TodoID    uint      `gorm:"not null;index" json:"todo_id"`
+	UserID    uint      `gorm:"not null" json:"user_id"`                 // 做修改的用户
+	Action    string    `gorm:"type:varchar(16);not null" json:"action"` // create / update / complete / reopen / delete / restore / move / tag / assign
+	Event     string    `gorm:"type:varchar(255)" json:"event"`          // 修改时待办的事件
+	CreatedAt time.Time `gorm:"autoCreateTime;index:idx_list_created" json:"created_at"`
+}
+
+// TodoTag 待办标签，同一用户下名称唯一
+type TodoTag struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_tag_name" json:"user_id"`
+	Name      string    `gorm:"type:varchar(32);not null;uniqueIndex:idx_user_tag_name" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TodoTagging 待办与标签的关联
+type TodoTagging struct {
+	TodoID uint `gorm:"primaryKey" json:"todo_id"`
+	TagID  uint `gorm:"primaryKey;index" json:"tag_id"`
+	UserID uint `gorm:"not null;index" json:"user_id"`
+}
+
+// TodoTagName 待办上的标签，查询结果，不对应数据表
+type TodoTagName struct {
+	TodoID uint
+	TagID  uint
+	Name   string
+}
+
+// TodoReminder 待办提醒，在截止时间前 OffsetMinutes 分钟提醒，0表示截止时提醒
+// Status：pending 待发送 / sent 已发送 / skipped 待办已完成或已删除，不再提醒
+type TodoReminder struct {
+	ID            uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	TodoID        uint       `gorm:"not null;index" json:"todo_id"`
+	OffsetMinutes int        `gorm:"not null;default:0" json:"offset_minutes"`
+	RemindAt      time.Time  `gorm:"not null;index:idx_status_remind_at,priority:2" json:"remind_at"` // 免打扰时会推迟到免打扰结束
+	Status        string     `gorm:"type:varchar(16);not null;default:'pending';index:idx_status_remind_at,priority:1" json:"status"`
+	SentAt        *time.Time `json:"sent_at"`
+	ClaimedAt     *time.Time `json:"-"` // 开始发送的时间，发送中超时的提醒会恢复为待发送
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// Notification 站内信，所有通知都会保存一份，其它渠道按用户设置另行推送
+type Notification struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"not null;index:idx_user_read" json:"user_id"`
+	Type      string     `gorm:"type:varchar(32);not null" json:"type"` // todo_reminder
+	Title     string     `gorm:"type:varchar(255);not null" json:"title"`
+	Content   string     `gorm:"type:text" json:"content"`
+	TodoID    *uint      `json:"todo_id"`
+	IsRead    bool       `gorm:"not null;default:false;index:idx_user_read" json:"is_read"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// NotificationSetting 用户的通知设置，没有记录时只发送站内信和WebSocket推送
+// QuietStart/QuietEnd 为 HH:MM，可以跨过零点，为空表示不开启免打扰
+type NotificationSetting struct {
+	UserID       uint      `gorm:"primaryKey" json:"user_id"`
+	EmailEnabled bool      `gorm:"not null" json:"email_enabled"`
+	PushEnabled  bool      `gorm:"not null" json:"push_enabled"`
+	QuietStart   string    `gorm:"type:varchar(5)" json:"quiet_start"`
+	QuietEnd     string    `gorm:"type:varchar(5)" json:"quiet_end"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 // Music 音乐
@@ -108,3 +293,169 @@ type AmbientSound struct {
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
+
+// ExperienceLog 经验流水表，每一次经验发放都会留下记录
+// SourceKey 与 UserID 组成唯一索引，保证同一来源的经验只发放一次
+type ExperienceLog struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_source_key" json:"user_id"`
+	Source    string    `gorm:"type:varchar(32);not null;index" json:"source"` // 来源：study_time / tomato / todo / streak
+	SourceKey string    `gorm:"type:varchar(128);not null;uniqueIndex:idx_user_source_key" json:"source_key"`
+	Amount    int       `gorm:"not null" json:"amount"` // 本次发放的经验值
+	Date      time.Time `gorm:"index" json:"date"`      // 归属日期，用于统计每日上限
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Achievement 成就目录，Metric 指定统计口径，达到 Threshold 即解锁
+type Achievement struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Code        string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"code"`
+	Name        string    `gorm:"type:varchar(64);not null" json:"name"`
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	Icon        string    `gorm:"type:varchar(255)" json:"icon"`
+	Metric      string    `gorm:"type:varchar(32);not null" json:"metric"`
+	Threshold   int       `gorm:"not null" json:"threshold"`
+	SortOrder   int       `gorm:"default:0" json:"sort_order"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// UserAchievement 用户已解锁的成就
+type UserAchievement struct {
+	ID              uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID          uint      `gorm:"not null;uniqueIndex:idx_user_achievement" json:"user_id"`
+	AchievementCode string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_user_achievement" json:"achievement_code"`
+	UnlockedAt      time.Time `json:"unlocked_at"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Friendship 好友关系（单向关注），好友排行榜取自己与关注的人
+type Friendship struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_friend" json:"user_id"`
+	FriendID  uint      `gorm:"not null;uniqueIndex:idx_user_friend;index" json:"friend_id"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Subject 用户自定义的学习科目/标签
+type Subject struct {
+	ID        uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint           `gorm:"not null;index" json:"user_id"`
+	Name      string         `gorm:"type:varchar(50);not null" json:"name"`
+	Color     string         `gorm:"type:varchar(7);default:'#409EFF'" json:"color"` // 十六进制颜色，如 #409EFF
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+}
+
+// SubjectDailyStudyData 按科目拆分的每日学习数据，未指定科目的学习只计入 DailyStudyData
+type SubjectDailyStudyData struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_user_subject_date"` //联合索引,保证每个科目每天一条记录
+	SubjectID uint      `json:"subject_id" gorm:"uniqueIndex:idx_user_subject_date"`
+	Date      time.Time `json:"date" gorm:"uniqueIndex:idx_user_subject_date"`
+	StudyTime int       `json:"study_time"`
+	Tomatoes  int       `json:"tomatoes"`
+}
+
+// StudyDataCorrection 学习数据修改记录，记录修改前后的数值及原因
+type StudyDataCorrection struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	Date         time.Time `gorm:"not null" json:"date"` // 被修改的日期
+	OldStudyTime int       `json:"old_study_time"`
+	NewStudyTime int       `json:"new_study_time"`
+	OldTomatoes  int       `json:"old_tomatoes"`
+	NewTomatoes  int       `json:"new_tomatoes"`
+	Reason       string    `gorm:"type:varchar(200);not null" json:"reason"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StudyDataFlag 可疑的学习数据提交，等待管理员审核
+// Status：pending 待审核 / voided 已作废 / dismissed 已忽略
+type StudyDataFlag struct {
+	ID          uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	SubjectID   uint       `json:"subject_id"`           // 提交归属的科目，0表示不归属任何科目
+	TodoID      uint       `json:"todo_id"`              // 提交归属的待办，0表示不归属任何待办
+	Date        time.Time  `gorm:"not null" json:"date"` // 提交计入的日期
+	SubmittedAt *time.Time `json:"submitted_at"`         // 提交的结束时间，作废时据此扣除分时段数据和学习记录
+	StudyTime   int        `json:"study_time"`
+	Tomatoes    int        `json:"tomatoes"`
+	Rules       string     `gorm:"type:varchar(100);not null" json:"rules"` // 触发的规则，逗号分隔
+	Detail      string     `gorm:"type:varchar(255)" json:"detail"`
+	Status      string     `gorm:"type:varchar(16);not null;default:'pending';index" json:"status"`
+	ReviewedAt  *time.Time `json:"reviewed_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StudyReport 周报/月报快照，每个用户每个周期一份
+type StudyReport struct {
+	ID                uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID            uint       `gorm:"not null;uniqueIndex:idx_user_type_period" json:"user_id"`
+	Type              string     `gorm:"type:varchar(16);not null;uniqueIndex:idx_user_type_period" json:"type"` // weekly / monthly
+	PeriodStart       time.Time  `gorm:"not null;uniqueIndex:idx_user_type_period" json:"period_start"`
+	PeriodEnd         time.Time  `gorm:"not null" json:"period_end"`
+	StudyTime         int        `json:"study_time"`
+	Tomatoes          int        `json:"tomatoes"`
+	ActiveDays        int        `json:"active_days"`
+	DailyAverage      float64    `json:"daily_average"` // 周期内平均每天学习分钟数
+	BestDay           *time.Time `json:"best_day"`
+	BestDayStudyTime  int        `json:"best_day_study_time"`
+	PreviousStudyTime int        `json:"previous_study_time"` // 上一个周期的学习时长
+	DailyGoal         int        `json:"daily_goal"`
+	GoalDays          int        `json:"goal_days"`                       // 达成每日目标的天数
+	Streak            int        `json:"streak"`                          // 截至周期最后一天的连续学习天数
+	TodoBreakdown     string     `gorm:"type:text" json:"todo_breakdown"` // 学习时间最多的待办，JSON
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// HourlyStudyData 按一天中的小时统计的学习时长，按年累计，用于分析最高效的时段
+type HourlyStudyData struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_year_hour" json:"user_id"`
+	Year      int       `gorm:"not null;uniqueIndex:idx_user_year_hour" json:"year"`
+	Hour      int       `gorm:"not null;uniqueIndex:idx_user_year_hour" json:"hour"` // 0~23
+	StudyTime int       `json:"study_time"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// MediaPlayStat 用户每年播放音乐/白噪音的统计
+type MediaPlayStat struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID      uint      `gorm:"not null;uniqueIndex:idx_user_media_year" json:"user_id"`
+	MediaType   string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_user_media_year" json:"media_type"` // music / ambient
+	MediaID     uint      `gorm:"not null;uniqueIndex:idx_user_media_year" json:"media_id"`
+	Year        int       `gorm:"not null;uniqueIndex:idx_user_media_year" json:"year"`
+	PlayCount   int       `json:"play_count"`
+	PlaySeconds int       `json:"play_seconds"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// YearReview 年度回顾快照，Content 为生成时的统计结果（JSON），ShareToken 用于只读分享
+type YearReview struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_user_year" json:"user_id"`
+	Year       int       `gorm:"not null;uniqueIndex:idx_user_year" json:"year"`
+	ShareToken string    `gorm:"type:varchar(32);not null;uniqueIndex" json:"share_token"`
+	Content    string    `gorm:"type:text" json:"content"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StudySession 一次专注学习的起止时间，用于分时段分析和导出日历
+type StudySession struct {
+	ID        uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint      `gorm:"not null;index:idx_user_started" json:"user_id"`
+	SubjectID uint      `json:"subject_id"`
+	TodoID    uint      `json:"todo_id"` // 学习所针对的待办，0表示没有
+	StartedAt time.Time `gorm:"not null;index:idx_user_started" json:"started_at"`
+	EndedAt   time.Time `gorm:"not null" json:"ended_at"`
+	StudyTime int       `json:"study_time"`
+	Tomatoes  int       `json:"tomatoes"`                                // 完成的番茄钟数量
+	Abandoned bool      `gorm:"not null;default:false" json:"abandoned"` // 最后一个番茄钟是否中途放弃
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
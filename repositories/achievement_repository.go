@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AchievementRepository struct {
+	db *gorm.DB
+}
+
+func NewAchievementRepository(db *gorm.DB) *AchievementRepository {
+	return &AchievementRepository{db: db}
+}
+
+// 按 Code 写入成就目录，已存在的成就会更新名称、口径和阈值
+func (r *AchievementRepository) SeedAchievements(achievements []models.Achievement) error {
+	if len(achievements) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "icon", "metric", "threshold", "sort_order", "updated_at"}),
+	}).Create(&achievements).Error
+}
+
+func (r *AchievementRepository) GetAllAchievements() ([]models.Achievement, error) {
+	var achievements []models.Achievement
+	result := r.db.Order("sort_order ASC, id ASC").Find(&achievements)
+	return achievements, result.Error
+}
+
+func (r *AchievementRepository) GetUserAchievements(userID uint) ([]models.UserAchievement, error) {
+	var unlocked []models.UserAchievement
+	result := r.db.Where("user_id = ?", userID).Order("unlocked_at ASC").Find(&unlocked)
+	return unlocked, result.Error
+}
+
+// 解锁成就，已解锁过则返回 false
+func (r *AchievementRepository) UnlockAchievement(userID uint, code string, unlockedAt time.Time) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserAchievement{
+		UserID:          userID,
+		AchievementCode: code,
+		UnlockedAt:      unlockedAt,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// 统计用户已完成的待办数量，回收站中的不计入
+func (r *AchievementRepository) CountCompletedTodos(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Todo{}).Where("user_id = ? AND completed = ?", userID, true).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type AntiCheatRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewAntiCheatRepository(db *gorm.DB, redis *redis.Client) *AntiCheatRepository {
+	return &AntiCheatRepository{
+		db:    db,
+		redis: redis,
+		ctx:   context.Background(),
+	}
+}
+
+// 按分钟计数的提交频率key
+func (r *AntiCheatRepository) GenerateRateKey(userID uint, at time.Time) string {
+	return fmt.Sprintf("user:%d:studydata:rate:%s", userID, at.Format("200601021504"))
+}
+
+// 记录最近一次提交时间的key
+func (r *AntiCheatRepository) GenerateLastSubmissionKey(userID uint) string {
+	return fmt.Sprintf("user:%d:studydata:last_submission", userID)
+}
+
+// 本分钟内的提交次数加一，返回累加后的次数
+func (r *AntiCheatRepository) IncrementSubmissionCount(userID uint, at time.Time) (int64, error) {
+	key := r.GenerateRateKey(userID, at)
+	pipe := r.redis.Pipeline()
+	incr := pipe.Incr(r.ctx, key)
+	pipe.ExpireNX(r.ctx, key, 2*time.Minute)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// 更新最近一次提交时间，只会往后更新，返回更新前的值；没有记录时 found 为 false
+func (r *AntiCheatRepository) SwapLastSubmission(userID uint, at time.Time) (last time.Time, found bool, err error) {
+	key := r.GenerateLastSubmissionKey(userID)
+	previous, err := r.redis.Get(r.ctx, key).Int64()
+	if err != nil && err != redis.Nil {
+		return time.Time{}, false, err
+	}
+	found = err == nil
+	if !found || at.Unix() > previous {
+		if err := r.redis.Set(r.ctx, key, at.Unix(), 24*time.Hour).Err(); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	if !found {
+		return time.Time{}, false, nil
+	}
+	return time.Unix(previous, 0), true, nil
+}
+
+func (r *AntiCheatRepository) CreateFlag(flag *models.StudyDataFlag) error {
+	return r.db.Create(flag).Error
+}
+
+func (r *AntiCheatRepository) GetFlagByID(id uint) (*models.StudyDataFlag, error) {
+	var flag models.StudyDataFlag
+	err := r.db.First(&flag, id).Error
+	return &flag, err
+}
+
+// 分页查询可疑记录，status 为空时查询全部
+func (r *AntiCheatRepository) GetFlags(status string, page, pageSize int) ([]models.StudyDataFlag, int64, error) {
+	var flags []models.StudyDataFlag
+	var total int64
+	query := r.db.Model(&models.StudyDataFlag{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&flags).Error
+	return flags, total, err
+}
+
+// 仅当记录仍是 fromStatus 时才更新状态，返回是否更新成功，防止重复作废
+func (r *AntiCheatRepository) UpdateFlagStatus(id uint, fromStatus, toStatus string, reviewedAt time.Time) (bool, error) {
+	result := r.db.Model(&models.StudyDataFlag{}).
+		Where("id = ? AND status = ?", id, fromStatus).
+		Updates(map[string]interface{}{
+			"status":      toStatus,
+			"reviewed_at": reviewedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
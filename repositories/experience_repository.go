@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ExperienceRepository struct {
+	db *gorm.DB
+}
+
+func NewExperienceRepository(db *gorm.DB) *ExperienceRepository {
+	return &ExperienceRepository{db: db}
+}
+
+// 发放经验：写入流水并累加用户经验，两者在同一事务中完成
+// 若 SourceKey 已存在则视为重复发放，返回 false 且不修改用户经验
+func (r *ExperienceRepository) AwardExperience(log *models.ExperienceLog) (*models.User, bool, error) {
+	var user models.User
+	awarded := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		awarded, err = createExperienceLog(tx, log)
+		if err != nil {
+			return err
+		}
+		return tx.First(&user, log.UserID).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &user, awarded, nil
+}
+
+// 在每日上限内发放经验：锁定用户行后统计该来源当天已发放的总和，
+// 本次最多发放 dailyCap 减去已发放的部分，同一用户的并发发放依次进行，不会超过上限
+func (r *ExperienceRepository) AwardExperienceWithinCap(log *models.ExperienceLog, dailyCap int) (*models.User, bool, error) {
+	var user models.User
+	awarded := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, log.UserID).Error; err != nil {
+			return err
+		}
+		var sum int
+		if err := tx.Model(&models.ExperienceLog{}).
+			Where("user_id = ? AND source = ? AND date = ?", log.UserID, log.Source, log.Date).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&sum).Error; err != nil {
+			return err
+		}
+		log.Amount = min(log.Amount, dailyCap-sum)
+		if log.Amount <= 0 {
+			return nil
+		}
+		var err error
+		if awarded, err = createExperienceLog(tx, log); err != nil || !awarded {
+			return err
+		}
+		return tx.First(&user, log.UserID).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &user, awarded, nil
+}
+
+// 写入流水并累加用户经验，SourceKey 已存在时返回 false
+func createExperienceLog(tx *gorm.DB, log *models.ExperienceLog) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(log)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	err := tx.Model(&models.User{}).Where("id = ?", log.UserID).
+		Update("experience", gorm.Expr("experience + ?", log.Amount)).Error
+	return err == nil, err
+}
+
+func (r *ExperienceRepository) UpdateUserLevel(userID uint, level int) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("level", level).
+		Error
+}
+
+func (r *ExperienceRepository) GetUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	result := r.db.First(&user, userID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &user, nil
+}
+
+// 分页查询经验流水，按时间倒序
+func (r *ExperienceRepository) GetExperienceLogs(userID uint, page, pageSize int) ([]models.ExperienceLog, int64, error) {
+	var logs []models.ExperienceLog
+	var total int64
+	query := r.db.Model(&models.ExperienceLog{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&logs).Error
+	return logs, total, err
+}
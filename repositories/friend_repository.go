@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FriendRepository struct {
+	db *gorm.DB
+}
+
+func NewFriendRepository(db *gorm.DB) *FriendRepository {
+	return &FriendRepository{db: db}
+}
+
+// 添加好友，重复添加不报错
+func (r *FriendRepository) AddFriend(userID, friendID uint) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Friendship{
+		UserID:   userID,
+		FriendID: friendID,
+	}).Error
+}
+
+func (r *FriendRepository) RemoveFriend(userID, friendID uint) error {
+	return r.db.Where("user_id = ? AND friend_id = ?", userID, friendID).Delete(&models.Friendship{}).Error
+}
+
+func (r *FriendRepository) GetFriendIDs(userID uint) ([]uint, error) {
+	var ids []uint
+	result := r.db.Model(&models.Friendship{}).Where("user_id = ?", userID).Pluck("friend_id", &ids)
+	return ids, result.Error
+}
+
+func (r *FriendRepository) GetFriends(userID uint) ([]models.User, error) {
+	var users []models.User
+	result := r.db.Joins("JOIN friendships ON friendships.friend_id = users.id").
+		Where("friendships.user_id = ?", userID).
+		Order("friendships.created_at DESC").
+		Find(&users)
+	return users, result.Error
+}
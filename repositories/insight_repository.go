@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+type InsightRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewInsightRepository(db *gorm.DB, redis *redis.Client) *InsightRepository {
+	return &InsightRepository{
+		db:    db,
+		redis: redis,
+		ctx:   context.Background(),
+	}
+}
+
+// 每个用户每周一份学习周报，weekStart 为当周周一
+func (r *InsightRepository) GenerateInsightKey(userID uint, weekStart time.Time) string {
+	return fmt.Sprintf("user:%d:insight:%s", userID, weekStart.Format("2006-01-02"))
+}
+
+// 按天计数的重新生成次数key
+func (r *InsightRepository) GenerateRegenerateKey(userID uint, day time.Time) string {
+	return fmt.Sprintf("user:%d:insight:regenerate:%s", userID, day.Format("2006-01-02"))
+}
+
+// 读取缓存的周报（JSON），不存在时 found 为 false
+func (r *InsightRepository) GetInsight(userID uint, weekStart time.Time) (content string, found bool, err error) {
+	content, err = r.redis.Get(r.ctx, r.GenerateInsightKey(userID, weekStart)).Result()
+	if err == redis.Nil {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return content, true, nil
+}
+
+func (r *InsightRepository) SaveInsight(userID uint, weekStart time.Time, content string, ttl time.Duration) error {
+	return r.redis.Set(r.ctx, r.GenerateInsightKey(userID, weekStart), content, ttl).Err()
+}
+
+// 当天重新生成次数加一，返回累加后的次数
+func (r *InsightRepository) IncrementRegenerateCount(userID uint, day time.Time) (int64, error) {
+	key := r.GenerateRegenerateKey(userID, day)
+	pipe := r.redis.Pipeline()
+	incr := pipe.Incr(r.ctx, key)
+	pipe.ExpireNX(r.ctx, key, 25*time.Hour)
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// 生成失败时退回本次计数
+func (r *InsightRepository) DecrementRegenerateCount(userID uint, day time.Time) error {
+	return r.redis.Decr(r.ctx, r.GenerateRegenerateKey(userID, day)).Err()
+}
+
+func (r *InsightRepository) GetUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, userID).Error
+	return &user, err
+}
+
+// 最近创建的待办事项
+func (r *InsightRepository) GetRecentTodos(userID uint, limit int) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&todos).Error
+	return todos, err
+}
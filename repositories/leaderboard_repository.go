@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 排行榜周期
+const (
+	LeaderboardDaily   = "daily"
+	LeaderboardWeekly  = "weekly"
+	LeaderboardMonthly = "monthly"
+	LeaderboardAll     = "all"
+)
+
+// 不参与排行榜的用户集合，自增时据此跳过
+const leaderboardOptOutKey = "leaderboard:optout"
+
+type LeaderboardRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewLeaderboardRepository(db *gorm.DB, redis *redis.Client) *LeaderboardRepository {
+	return &LeaderboardRepository{
+		db:    db,
+		redis: redis,
+		ctx:   context.Background(),
+	}
+}
+
+//排行榜使用 ZSET 存储，member 为用户ID，score 为学习时长（分钟）
+//key：leaderboard:daily:{YYYY-MM-DD}	leaderboard:weekly:{YYYY-Www}
+//     leaderboard:monthly:{YYYY-MM}	leaderboard:all
+
+// 生成排行榜key
+func (r *LeaderboardRepository) GenerateLeaderboardKey(period string, date time.Time) string {
+	switch period {
+	case LeaderboardDaily:
+		return "leaderboard:daily:" + date.Format("2006-01-02")
+	case LeaderboardWeekly:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("leaderboard:weekly:%d-W%02d", year, week)
+	case LeaderboardMonthly:
+		return "leaderboard:monthly:" + date.Format("2006-01")
+	default:
+		return "leaderboard:all"
+	}
+}
+
+// 各周期排行榜的过期时间，留出余量便于跨周期查看
+func leaderboardTTL(period string) time.Duration {
+	switch period {
+	case LeaderboardDaily:
+		return 48 * time.Hour
+	case LeaderboardWeekly:
+		return 14 * 24 * time.Hour
+	case LeaderboardMonthly:
+		return 62 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+var leaderboardPeriods = []string{LeaderboardDaily, LeaderboardWeekly, LeaderboardMonthly, LeaderboardAll}
+
+// 为用户在所有周期排行榜上增加学习时长，不参与排行榜的用户直接跳过
+func (r *LeaderboardRepository) IncrementStudyTime(userID uint, date time.Time, studyTime int) error {
+	member := strconv.FormatUint(uint64(userID), 10)
+	optOut, err := r.redis.SIsMember(r.ctx, leaderboardOptOutKey, member).Result()
+	if err != nil {
+		return err
+	}
+	if optOut {
+		return nil
+	}
+
+	pipe := r.redis.Pipeline()
+	for _, period := range leaderboardPeriods {
+		key := r.GenerateLeaderboardKey(period, date)
+		pipe.ZIncrBy(r.ctx, key, float64(studyTime), member)
+		if ttl := leaderboardTTL(period); ttl > 0 {
+			pipe.ExpireNX(r.ctx, key, ttl)
+		}
+	}
+	_, err = pipe.Exec(r.ctx)
+	return err
+}
+
+// 按名次区间查询，start/stop 从0开始，分数从高到低
+func (r *LeaderboardRepository) GetRange(period string, date time.Time, start, stop int64) ([]redis.Z, error) {
+	key := r.GenerateLeaderboardKey(period, date)
+	return r.redis.ZRevRangeWithScores(r.ctx, key, start, stop).Result()
+}
+
+// 查询用户名次（从0开始），不在榜上时 found 为 false
+func (r *LeaderboardRepository) GetRank(period string, date time.Time, userID uint) (rank int64, found bool, err error) {
+	key := r.GenerateLeaderboardKey(period, date)
+	rank, err = r.redis.ZRevRank(r.ctx, key, strconv.FormatUint(uint64(userID), 10)).Result()
+	if err == redis.Nil {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return rank, true, nil
+}
+
+// 批量查询用户分数，不在榜上的用户分数为0
+func (r *LeaderboardRepository) GetScores(period string, date time.Time, userIDs []uint) ([]float64, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+	members := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	key := r.GenerateLeaderboardKey(period, date)
+	return r.redis.ZMScore(r.ctx, key, members...).Result()
+}
+
+// 从排行榜中移除用户（例如用户已注销）
+func (r *LeaderboardRepository) RemoveUser(period string, date time.Time, userID uint) error {
+	key := r.GenerateLeaderboardKey(period, date)
+	return r.redis.ZRem(r.ctx, key, strconv.FormatUint(uint64(userID), 10)).Err()
+}
+
+// 把用户从当前各周期排行榜和不参与排行榜的集合中移除，用于注销账号
+func (r *LeaderboardRepository) RemoveUserFromAll(userID uint, date time.Time) error {
+	member := strconv.FormatUint(uint64(userID), 10)
+	pipe := r.redis.Pipeline()
+	pipe.SRem(r.ctx, leaderboardOptOutKey, member)
+	for _, period := range leaderboardPeriods {
+		pipe.ZRem(r.ctx, r.GenerateLeaderboardKey(period, date), member)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+func (r *LeaderboardRepository) GetUsersByIDs(userIDs []uint) ([]models.User, error) {
+	var users []models.User
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	result := r.db.Where("id IN ?", userIDs).Find(&users)
+	return users, result.Error
+}
+
+// 更新排行榜隐私设置，同时维护 redis 中的跳过集合
+// 退出时从当前各周期榜单移除，重新加入时按 mysql 数据补回当前周期分数
+func (r *LeaderboardRepository) SetOptOut(userID uint, optOut bool, date time.Time) error {
+	if err := r.db.Model(&models.User{}).Where("id = ?", userID).Update("leaderboard_opt_out", optOut).Error; err != nil {
+		return err
+	}
+
+	member := strconv.FormatUint(uint64(userID), 10)
+	if optOut {
+		pipe := r.redis.Pipeline()
+		pipe.SAdd(r.ctx, leaderboardOptOutKey, member)
+		for _, period := range leaderboardPeriods {
+			pipe.ZRem(r.ctx, r.GenerateLeaderboardKey(period, date), member)
+		}
+		_, err := pipe.Exec(r.ctx)
+		return err
+	}
+
+	if err := r.redis.SRem(r.ctx, leaderboardOptOutKey, member).Err(); err != nil {
+		return err
+	}
+	pipe := r.redis.Pipeline()
+	for _, period := range leaderboardPeriods {
+		scores, err := r.loadScores(period, date, userID)
+		if err != nil {
+			return err
+		}
+		key := r.GenerateLeaderboardKey(period, date)
+		if len(scores) == 0 {
+			continue
+		}
+		pipe.ZAdd(r.ctx, key, scores...)
+		if ttl := leaderboardTTL(period); ttl > 0 {
+			pipe.ExpireNX(r.ctx, key, ttl)
+		}
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// 根据 mysql 数据重建当前各周期排行榜
+// 先写入临时key再 RENAME，重建过程中线上查询不受影响
+func (r *LeaderboardRepository) Rebuild(date time.Time) error {
+	var optOutIDs []uint
+	if err := r.db.Model(&models.User{}).Where("leaderboard_opt_out = ?", true).Pluck("id", &optOutIDs).Error; err != nil {
+		return err
+	}
+	members := make([]interface{}, 0, len(optOutIDs))
+	for _, id := range optOutIDs {
+		members = append(members, strconv.FormatUint(uint64(id), 10))
+	}
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, leaderboardOptOutKey)
+	if len(members) > 0 {
+		pipe.SAdd(r.ctx, leaderboardOptOutKey, members...)
+	}
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return err
+	}
+
+	for _, period := range leaderboardPeriods {
+		scores, err := r.loadScores(period, date, 0)
+		if err != nil {
+			return fmt.Errorf("加载%s排行榜数据失败: %w", period, err)
+		}
+		key := r.GenerateLeaderboardKey(period, date)
+		tmpKey := key + ":rebuild"
+
+		pipe := r.redis.TxPipeline()
+		pipe.Del(r.ctx, tmpKey)
+		if len(scores) == 0 {
+			pipe.Del(r.ctx, key)
+		} else {
+			pipe.ZAdd(r.ctx, tmpKey, scores...)
+			if ttl := leaderboardTTL(period); ttl > 0 {
+				pipe.Expire(r.ctx, tmpKey, ttl)
+			}
+			pipe.Rename(r.ctx, tmpKey, key)
+		}
+		if _, err := pipe.Exec(r.ctx); err != nil {
+			return fmt.Errorf("写入%s排行榜失败: %w", period, err)
+		}
+	}
+	return nil
+}
+
+// 从 mysql 汇总某周期的学习时长，userID 为0时加载全部参与排行的用户
+func (r *LeaderboardRepository) loadScores(period string, date time.Time, userID uint) ([]redis.Z, error) {
+	type row struct {
+		UserID    uint
+		StudyTime int
+	}
+	var rows []row
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+
+	var query *gorm.DB
+	var table string
+	switch period {
+	case LeaderboardDaily:
+		table = "daily_study_data"
+		query = r.db.Model(&models.DailyStudyData{}).
+			Select("daily_study_data.user_id, daily_study_data.study_time").
+			Where("daily_study_data.date = ?", day)
+	case LeaderboardWeekly:
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		monday := day.AddDate(0, 0, -weekday+1)
+		table = "daily_study_data"
+		query = r.db.Model(&models.DailyStudyData{}).
+			Select("daily_study_data.user_id, SUM(daily_study_data.study_time) AS study_time").
+			Where("daily_study_data.date BETWEEN ? AND ?", monday, monday.AddDate(0, 0, 6)).
+			Group("daily_study_data.user_id")
+	case LeaderboardMonthly:
+		table = "monthly_study_data"
+		query = r.db.Model(&models.MonthlyStudyData{}).
+			Select("monthly_study_data.user_id, monthly_study_data.study_time").
+			Where("monthly_study_data.month = ?", time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location()))
+	default:
+		table = "total_study_data"
+		query = r.db.Model(&models.TotalStudyData{}).
+			Select("total_study_data.user_id, total_study_data.study_time")
+	}
+
+	query = query.Joins(fmt.Sprintf("JOIN users ON users.id = %s.user_id AND users.leaderboard_opt_out = ?", table), false)
+	if userID != 0 {
+		query = query.Where(table+".user_id = ?", userID)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	scores := make([]redis.Z, 0, len(rows))
+	for _, row := range rows {
+		if row.StudyTime <= 0 {
+			continue
+		}
+		scores = append(scores, redis.Z{
+			Score:  float64(row.StudyTime),
+			Member: strconv.FormatUint(uint64(row.UserID), 10),
+		})
+	}
+	return scores, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NoteRepository struct {
+	db *gorm.DB
+}
+
+func NewNoteRepository(db *gorm.DB) *NoteRepository {
+	return &NoteRepository{db: db}
+}
+
+func (r *NoteRepository) CreateNote(note *models.Note) error {
+	return r.db.Create(note).Error
+}
+
+func (r *NoteRepository) GetNoteByID(id uint) (*models.Note, error) {
+	var note models.Note
+	err := r.db.First(&note, id).Error
+	return &note, err
+}
+
+// 分页查询备忘录，置顶的在前，其余按日期倒序；start、end 不为空时按日期筛选（包含两端）
+func (r *NoteRepository) GetNotes(userID uint, start, end *time.Time, page, pageSize int) ([]models.Note, int64, error) {
+	query := r.db.Model(&models.Note{}).Where("user_id = ?", userID)
+	if start != nil {
+		query = query.Where("date >= ?", *start)
+	}
+	if end != nil {
+		query = query.Where("date <= ?", *end)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var notes []models.Note
+	err := query.Order("pinned DESC, date DESC, updated_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&notes).Error
+	return notes, total, err
+}
+
+func (r *NoteRepository) UpdateNote(note *models.Note) error {
+	return r.db.Save(note).Error
+}
+
+// 软删除备忘录
+func (r *NoteRepository) DeleteNote(id uint) error {
+	return r.db.Delete(&models.Note{}, id).Error
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+func (r *NotificationRepository) CreateTodoReminder(reminder *models.TodoReminder) error {
+	return r.db.Create(reminder).Error
+}
+
+func (r *NotificationRepository) GetTodoReminderByID(id uint) (*models.TodoReminder, error) {
+	var reminder models.TodoReminder
+	err := r.db.First(&reminder, id).Error
+	return &reminder, err
+}
+
+func (r *NotificationRepository) GetTodoReminders(todoID uint) ([]models.TodoReminder, error) {
+	var reminders []models.TodoReminder
+	err := r.db.Where("todo_id = ?", todoID).Order("offset_minutes DESC, id ASC").Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *NotificationRepository) DeleteTodoReminder(id uint) error {
+	return r.db.Delete(&models.TodoReminder{}, id).Error
+}
+
+func (r *NotificationRepository) UpdateTodoReminder(reminder *models.TodoReminder) error {
+	return r.db.Save(reminder).Error
+}
+
+// 重复待办上一次设置的提前提醒分钟数，before 为这一次的时间
+func (r *NotificationRepository) GetRecurrenceReminderOffsets(recurrenceID uint, before time.Time) ([]int, error) {
+	var previous models.Todo
+	err := r.db.Where("recurrence_id = ? AND occurrence_at < ?", recurrenceID, before).
+		Order("occurrence_at DESC").First(&previous).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var offsets []int
+	err = r.db.Model(&models.TodoReminder{}).Where("todo_id = ?", previous.ID).
+		Order("offset_minutes DESC").Pluck("offset_minutes", &offsets).Error
+	return offsets, err
+}
+
+// 到期的提醒，按提醒时间从早到晚
+func (r *NotificationRepository) GetDueReminders(status string, now time.Time, limit int) ([]models.TodoReminder, error) {
+	var reminders []models.TodoReminder
+	err := r.db.Where("status = ? AND remind_at <= ?", status, now).
+		Order("remind_at ASC, id ASC").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+// 把到期的提醒从 fromStatus 改为 toStatus，只有提醒时间仍为 remindAt 时才会更新
+// 条件更新保证多个实例只有一个能处理同一条提醒
+func (r *NotificationRepository) ClaimReminder(id uint, remindAt time.Time, fromStatus, toStatus string, sentAt *time.Time) (bool, error) {
+	result := r.db.Model(&models.TodoReminder{}).
+		Where("id = ? AND status = ? AND remind_at = ?", id, fromStatus, remindAt).
+		Updates(map[string]interface{}{"status": toStatus, "sent_at": sentAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 把到期的待发送提醒改为发送中并记录开始发送的时间
+func (r *NotificationRepository) ClaimReminderForSending(id uint, remindAt, now time.Time) (bool, error) {
+	result := r.db.Model(&models.TodoReminder{}).
+		Where("id = ? AND status = ? AND remind_at = ?", id, "pending", remindAt).
+		Updates(map[string]interface{}{"status": "sending", "claimed_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+// 把 before 之前开始发送、仍未完成的提醒恢复为待发送，返回恢复的数量
+func (r *NotificationRepository) ReleaseStaleReminders(before time.Time) (int64, error) {
+	result := r.db.Model(&models.TodoReminder{}).
+		Where("status = ? AND claimed_at < ?", "sending", before).
+		Updates(map[string]interface{}{"status": "pending", "claimed_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+// 把提醒推迟到 until，只有提醒时间仍为 remindAt 时才会更新
+func (r *NotificationRepository) DeferReminder(id uint, remindAt, until time.Time) (bool, error) {
+	result := r.db.Model(&models.TodoReminder{}).
+		Where("id = ? AND remind_at = ?", id, remindAt).
+		Update("remind_at", until)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *NotificationRepository) CreateNotification(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+func (r *NotificationRepository) GetNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *NotificationRepository) CountUnreadNotifications(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false).Count(&count).Error
+	return count, err
+}
+
+// 把通知标记为已读，id 为0时标记该用户所有未读通知，返回标记的数量
+func (r *NotificationRepository) MarkNotificationsRead(userID, id uint, at time.Time) (int64, error) {
+	query := r.db.Model(&models.Notification{}).Where("user_id = ? AND is_read = ?", userID, false)
+	if id != 0 {
+		query = query.Where("id = ?", id)
+	}
+	result := query.Updates(map[string]interface{}{"is_read": true, "read_at": at})
+	return result.RowsAffected, result.Error
+}
+
+// 用户的通知设置，没有设置时返回nil
+func (r *NotificationRepository) GetNotificationSetting(userID uint) (*models.NotificationSetting, error) {
+	var setting models.NotificationSetting
+	err := r.db.Where("user_id = ?", userID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (r *NotificationRepository) SaveNotificationSetting(setting *models.NotificationSetting) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"email_enabled", "push_enabled", "quiet_start", "quiet_end", "updated_at"}),
+	}).Create(setting).Error
+}
+
+func (r *NotificationRepository) GetUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, userID).Error
+	return &user, err
+}
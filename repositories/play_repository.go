@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PlayRepository struct {
+	db *gorm.DB
+}
+
+func NewPlayRepository(db *gorm.DB) *PlayRepository {
+	return &PlayRepository{db: db}
+}
+
+// 累加一次播放
+func (r *PlayRepository) RecordPlay(userID uint, mediaType string, mediaID uint, year, seconds int) error {
+	stat := models.MediaPlayStat{
+		UserID:      userID,
+		MediaType:   mediaType,
+		MediaID:     mediaID,
+		Year:        year,
+		PlayCount:   1,
+		PlaySeconds: seconds,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "media_type"}, {Name: "media_id"}, {Name: "year"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"play_count":   gorm.Expr("play_count + 1"),
+			"play_seconds": gorm.Expr("play_seconds + ?", seconds),
+			"updated_at":   time.Now(),
+		}),
+	}).Create(&stat).Error
+}
+
+// 某年播放最多的音乐/白噪音，按播放时长、次数排序
+func (r *PlayRepository) GetTopPlays(userID uint, mediaType string, year, limit int) ([]models.MediaPlayStat, error) {
+	var stats []models.MediaPlayStat
+	err := r.db.Where("user_id = ? AND media_type = ? AND year = ?", userID, mediaType, year).
+		Order("play_seconds DESC, play_count DESC").
+		Limit(limit).
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *PlayRepository) GetMusicByID(id uint) (*models.Music, error) {
+	var music models.Music
+	err := r.db.First(&music, id).Error
+	return &music, err
+}
+
+func (r *PlayRepository) GetAmbientSoundByID(id uint) (*models.AmbientSound, error) {
+	var sound models.AmbientSound
+	err := r.db.First(&sound, id).Error
+	return &sound, err
+}
+
+func (r *PlayRepository) GetMusicByIDs(ids []uint) ([]models.Music, error) {
+	var musics []models.Music
+	if len(ids) == 0 {
+		return musics, nil
+	}
+	err := r.db.Where("id IN ?", ids).Find(&musics).Error
+	return musics, err
+}
+
+// 包括已删除的白噪音，用于历史统计的展示
+func (r *PlayRepository) GetAmbientSoundsByIDs(ids []uint) ([]models.AmbientSound, error) {
+	var sounds []models.AmbientSound
+	if len(ids) == 0 {
+		return sounds, nil
+	}
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&sounds).Error
+	return sounds, err
+}
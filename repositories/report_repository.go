@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReportRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewReportRepository(db *gorm.DB, redis *redis.Client) *ReportRepository {
+	return &ReportRepository{
+		db:    db,
+		redis: redis,
+		ctx:   context.Background(),
+	}
+}
+
+// 保存报告，同一用户同一周期重复生成时覆盖
+func (r *ReportRepository) SaveReport(report *models.StudyReport) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "type"}, {Name: "period_start"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"period_end", "study_time", "tomatoes", "active_days", "daily_average", "best_day", "best_day_study_time",
+			"previous_study_time", "daily_goal", "goal_days", "streak", "todo_breakdown", "updated_at",
+		}),
+	}).Create(report).Error
+}
+
+// 分页查询报告历史，reportType 为空时查询全部，按周期倒序
+func (r *ReportRepository) GetReports(userID uint, reportType string, page, pageSize int) ([]models.StudyReport, int64, error) {
+	var reports []models.StudyReport
+	var total int64
+	query := r.db.Model(&models.StudyReport{}).Where("user_id = ?", userID)
+	if reportType != "" {
+		query = query.Where("type = ?", reportType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("period_start DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&reports).Error
+	return reports, total, err
+}
+
+// 某段时间内有学习记录的用户
+func (r *ReportRepository) GetActiveUserIDs(startDate, endDate time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&models.DailyStudyData{}).
+		Where("date BETWEEN ? AND ? AND study_time > 0", startDate, endDate).
+		Distinct().
+		Order("user_id ASC").
+		Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// 某段时间内学习时间最多的待办，已删除的待办不统计
+func (r *ReportRepository) GetTodoStudySummary(userID uint, startDate, endDate time.Time, limit int) ([]models.TodoStudySummary, error) {
+	var summaries []models.TodoStudySummary
+	err := r.db.Table("todo_daily_study_data").
+		Select("todo_daily_study_data.todo_id, todos.event, todos.estimated_tomatoes, SUM(todo_daily_study_data.study_time) AS study_time, SUM(todo_daily_study_data.tomatoes) AS tomatoes").
+		Joins("JOIN todos ON todos.id = todo_daily_study_data.todo_id AND todos.deleted_at IS NULL").
+		Where("todo_daily_study_data.user_id = ? AND todo_daily_study_data.date BETWEEN ? AND ?", userID, startDate, endDate).
+		Group("todo_daily_study_data.todo_id, todos.event, todos.estimated_tomatoes").
+		Order("study_time DESC").
+		Limit(limit).
+		Scan(&summaries).Error
+	return summaries, err
+}
+
+func (r *ReportRepository) GetUserByID(userID uint) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, userID).Error
+	return &user, err
+}
+
+// 生成任务标记，保证同一周期的报告只批量生成一次（多实例部署时也只有一个实例执行）
+func (r *ReportRepository) GenerateReportRunKey(reportType string, periodStart time.Time) string {
+	return fmt.Sprintf("reports:run:%s:%s", reportType, periodStart.Format("2006-01-02"))
+}
+
+func (r *ReportRepository) ClaimReportRun(reportType string, periodStart time.Time) (bool, error) {
+	return r.redis.SetNX(r.ctx, r.GenerateReportRunKey(reportType, periodStart), time.Now().Unix(), 40*24*time.Hour).Result()
+}
+
+// 生成失败时释放标记，下次检查时重试
+func (r *ReportRepository) ReleaseReportRun(reportType string, periodStart time.Time) error {
+	return r.redis.Del(r.ctx, r.GenerateReportRunKey(reportType, periodStart)).Err()
+}
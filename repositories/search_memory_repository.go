@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MemorySearchRepository 在内存中搜索备忘录和待办，不依赖MySQL全文索引，用于测试
+// 关键词不区分大小写，按出现次数计算相关度
+type MemorySearchRepository struct {
+	mu    sync.RWMutex
+	notes map[uint]models.Note
+	todos map[uint]models.Todo
+}
+
+func NewMemorySearchRepository() *MemorySearchRepository {
+	return &MemorySearchRepository{
+		notes: make(map[uint]models.Note),
+		todos: make(map[uint]models.Todo),
+	}
+}
+
+// 添加或更新备忘录
+func (r *MemorySearchRepository) SaveNote(note models.Note) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notes[note.ID] = note
+}
+
+// 添加或更新待办
+func (r *MemorySearchRepository) SaveTodo(todo models.Todo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.todos[todo.ID] = todo
+}
+
+func (r *MemorySearchRepository) Search(userID uint, filter models.SearchFilter, page, pageSize int) ([]models.SearchHit, int64, error) {
+	r.mu.RLock()
+	hits := make([]models.SearchHit, 0)
+	if searchTypeEnabled(filter.Types, searchTypeNote) {
+		for _, note := range r.notes {
+			if note.UserID != userID || note.DeletedAt.Valid {
+				continue
+			}
+			if score, ok := memorySearchScore(note.Title, note.Content, filter.Keywords); ok {
+				hits = append(hits, models.SearchHit{Type: searchTypeNote, ID: note.ID, Title: note.Title, Content: note.Content, Score: score, UpdatedAt: note.UpdatedAt})
+			}
+		}
+	}
+	if searchTypeEnabled(filter.Types, searchTypeTodo) {
+		for _, todo := range r.todos {
+			if todo.UserID != userID || todo.DeletedAt.Valid {
+				continue
+			}
+			if score, ok := memorySearchScore(todo.Event, todo.Description, filter.Keywords); ok {
+				hits = append(hits, models.SearchHit{Type: searchTypeTodo, ID: todo.ID, Title: todo.Event, Content: todo.Description, Score: score, UpdatedAt: todo.UpdatedAt})
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	// 与MySQL实现的排序一致
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if !hits[i].UpdatedAt.Equal(hits[j].UpdatedAt) {
+			return hits[i].UpdatedAt.After(hits[j].UpdatedAt)
+		}
+		return hits[i].ID > hits[j].ID
+	})
+	total := int64(len(hits))
+	start := min((page-1)*pageSize, len(hits))
+	end := min(start+pageSize, len(hits))
+	return hits[start:end], total, nil
+}
+
+// 所有关键词都出现时匹配，相关度为关键词出现的总次数
+func memorySearchScore(title, content string, keywords []string) (float64, bool) {
+	text := strings.ToLower(title + "\n" + content)
+	score := 0
+	for _, keyword := range keywords {
+		count := strings.Count(text, strings.ToLower(keyword))
+		if count == 0 {
+			return 0, false
+		}
+		score += count
+	}
+	return float64(score), true
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// 搜索结果的类型
+const (
+	searchTypeNote = "note"
+	searchTypeTodo = "todo"
+)
+
+// ngram分词器默认 ngram_token_size 为2，更短的关键词用 LIKE 匹配
+const ngramTokenSize = 2
+
+// 全文索引名，与 config.InitDatabase 中创建的一致
+const (
+	noteFulltextIndex = "idx_note_fulltext"
+	todoFulltextIndex = "idx_todo_fulltext"
+)
+
+// SearchRepository 基于MySQL全文索引搜索备忘录和待办
+type SearchRepository struct {
+	db       *gorm.DB
+	fulltext map[string]bool // 各类型的全文索引是否存在，不存在时该类型只用 LIKE 匹配
+}
+
+func NewSearchRepository(db *gorm.DB) *SearchRepository {
+	return &SearchRepository{
+		db: db,
+		fulltext: map[string]bool{
+			searchTypeNote: db.Migrator().HasIndex(&models.Note{}, noteFulltextIndex),
+			searchTypeTodo: db.Migrator().HasIndex(&models.Todo{}, todoFulltextIndex),
+		},
+	}
+}
+
+// 搜索用户的备忘录和待办，按相关度倒序分页，回收站中的待办不返回
+func (r *SearchRepository) Search(userID uint, filter models.SearchFilter, page, pageSize int) ([]models.SearchHit, int64, error) {
+	subqueries := make([]interface{}, 0, 2)
+	if searchTypeEnabled(filter.Types, searchTypeNote) {
+		subqueries = append(subqueries, r.searchQuery(&models.Note{}, searchTypeNote, "title", "content", userID, filter.Keywords))
+	}
+	if searchTypeEnabled(filter.Types, searchTypeTodo) {
+		subqueries = append(subqueries, r.searchQuery(&models.Todo{}, searchTypeTodo, "event", "description", userID, filter.Keywords))
+	}
+	if len(subqueries) == 0 {
+		return []models.SearchHit{}, 0, nil
+	}
+	union := strings.TrimSuffix(strings.Repeat("? UNION ALL ", len(subqueries)), " UNION ALL ")
+
+	var total int64
+	if err := r.db.Raw("SELECT COUNT(*) FROM ("+union+") AS hits", subqueries...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var hits []models.SearchHit
+	args := append(subqueries, pageSize, (page-1)*pageSize)
+	err := r.db.Raw("SELECT * FROM ("+union+") AS hits ORDER BY score DESC, updated_at DESC, id DESC LIMIT ? OFFSET ?", args...).
+		Scan(&hits).Error
+	return hits, total, err
+}
+
+// 单张表的搜索子查询，没有需要全文检索的关键词时只用 LIKE 匹配，相关度都为0
+func (r *SearchRepository) searchQuery(model interface{}, searchType, titleColumn, contentColumn string, userID uint, keywords []string) *gorm.DB {
+	against, likes := splitSearchKeywords(keywords, r.fulltext[searchType])
+	match := "MATCH(" + titleColumn + ", " + contentColumn + ") AGAINST (? IN BOOLEAN MODE)"
+	query := r.db.Model(model).Where("user_id = ?", userID)
+	if against != "" {
+		query = query.Select("? AS type, id, "+titleColumn+" AS title, "+contentColumn+" AS content, "+match+" AS score, updated_at", searchType, against).
+			Where(match, against)
+	} else {
+		query = query.Select("? AS type, id, "+titleColumn+" AS title, "+contentColumn+" AS content, 0 AS score, updated_at", searchType)
+	}
+	for _, like := range likes {
+		query = query.Where(titleColumn+" LIKE ? OR "+contentColumn+" LIKE ?", like, like)
+	}
+	return query
+}
+
+// 把关键词拆分为全文检索的布尔表达式和 LIKE 模式，每个关键词都必须出现
+// 没有全文索引时全部用 LIKE 匹配
+func splitSearchKeywords(keywords []string, fulltext bool) (string, []string) {
+	terms := make([]string, 0, len(keywords))
+	likes := make([]string, 0)
+	for _, keyword := range keywords {
+		if !fulltext || utf8.RuneCountInString(keyword) < ngramTokenSize {
+			likes = append(likes, "%"+escapeLike(keyword)+"%")
+			continue
+		}
+		// 关键词作为短语匹配，双引号内的其他运算符不生效
+		terms = append(terms, `+"`+strings.ReplaceAll(keyword, `"`, "")+`"`)
+	}
+	return strings.Join(terms, " "), likes
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func searchTypeEnabled(types []string, searchType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == searchType {
+			return true
+		}
+	}
+	return false
+}
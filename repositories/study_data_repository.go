@@ -3,7 +3,9 @@ package repository
 import (
 	"2026-FM247-BackEnd/models"
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -40,144 +42,387 @@ func (r *StudyDataRepository) GenerateMonthlyKey(userID uint, date time.Time) st
 //数据结构： key：user:{userID}:studydata:date:{YYYY-MM-DD}
 // 			study_time：学习时长 单位分钟		tomatoes：番茄钟数量
 
-// 增加每日和每月学习时长
-func (r *StudyDataRepository) IncrementDailyStudyTime(userID uint, date time.Time, studyTime int) error {
-	dailykey := r.GenerateDailyKey(userID, date)
-	monthlykey := r.GenerateMonthlyKey(userID, date)
+// 写入采用 write-behind：增量先原子地写入redis并登记到脏集合，由后台 flusher 批量落库
+// 脏集合成员格式 {userID}:{YYYY-MM-DD}；总数据的增量暂存在 pending hash 中，落库后清空
+const studyDataDirtyKey = "studydata:dirty"
 
-	// 策略：操作前先检查Key是否存在，若不存在尝试从DB回捞数据进行预热
-	if r.redis.Exists(r.ctx, dailykey).Val() == 0 {
-		r.GetDailyStudyData(userID, date)
+const (
+	dailyKeyTTL   = 26 * time.Hour
+	monthlyKeyTTL = 35 * 24 * time.Hour
+	// 提交遇到正在修改历史数据时最多等待的时间
+	studyDataLockWait = 3 * time.Second
+)
+
+// KEYS: 每日hash、每月hash、总数据增量hash、总数据缓存、脏集合、修改锁
+// ARGV: 学习时长、番茄钟、每日过期秒数、每月过期秒数、脏集合成员、每日上限
+// 正在修改历史数据时返回-3，由调用方等待后重试
+// 每日/每月key不存在时返回-1，由调用方预热后重试，避免从0开始累加覆盖mysql中的数据
+// 超过每日上限时返回-2，不做任何修改
+var incrementStudyDataScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[6]) == 1 then
+	return -3
+end
+if redis.call('EXISTS', KEYS[1]) == 0 or redis.call('EXISTS', KEYS[2]) == 0 then
+	return -1
+end
+local dayStudyTime = tonumber(redis.call('HGET', KEYS[1], 'study_time') or '0') + tonumber(ARGV[1])
+if dayStudyTime > tonumber(ARGV[6]) then
+	return -2
+end
+redis.call('HINCRBY', KEYS[1], 'study_time', ARGV[1])
+redis.call('HINCRBY', KEYS[1], 'tomatoes', ARGV[2])
+redis.call('HINCRBY', KEYS[2], 'study_time', ARGV[1])
+redis.call('HINCRBY', KEYS[2], 'tomatoes', ARGV[2])
+redis.call('EXPIRE', KEYS[1], ARGV[3])
+redis.call('EXPIRE', KEYS[2], ARGV[4])
+redis.call('HINCRBY', KEYS[3], 'study_time', ARGV[1])
+redis.call('HINCRBY', KEYS[3], 'tomatoes', ARGV[2])
+if redis.call('EXISTS', KEYS[4]) == 1 then
+	redis.call('HINCRBY', KEYS[4], 'study_time', ARGV[1])
+	redis.call('HINCRBY', KEYS[4], 'tomatoes', ARGV[2])
+end
+redis.call('SADD', KEYS[5], ARGV[5])
+return dayStudyTime
+`)
+
+// 取出并清空总数据增量
+var popPendingTotalScript = redis.NewScript(`
+local values = redis.call('HMGET', KEYS[1], 'study_time', 'tomatoes')
+redis.call('DEL', KEYS[1])
+return values
+`)
+
+// 生成redis的总数据缓存key
+func (r *StudyDataRepository) GenerateTotalKey(userID uint) string {
+	return fmt.Sprintf("user:%d:studydata:total", userID)
+}
+
+// 生成redis的总数据待落库增量key
+func (r *StudyDataRepository) GeneratePendingTotalKey(userID uint) string {
+	return fmt.Sprintf("user:%d:studydata:total:pending", userID)
+}
+
+// 原子地增加每日、每月、总学习数据，并标记为待落库
+// 增加后当天的学习时长超过 maxDayStudyTime 时不做任何修改，返回 false
+// 返回增加后当天的学习时长
+func (r *StudyDataRepository) IncrementStudyData(userID uint, date time.Time, studyTime int, tomatoes int, maxDayStudyTime int) (int, bool, error) {
+	keys := []string{
+		r.GenerateDailyKey(userID, date),
+		r.GenerateMonthlyKey(userID, date),
+		r.GeneratePendingTotalKey(userID),
+		r.GenerateTotalKey(userID),
+		studyDataDirtyKey,
+		r.GenerateStudyDataLockKey(userID),
 	}
-	if r.redis.Exists(r.ctx, monthlykey).Val() == 0 {
-		r.GetMonthlyStudyData(userID, date)
+	member := fmt.Sprintf("%d:%s", userID, date.Format("2006-01-02"))
+
+	// 预热和脚本之间key可能刚好过期，重试一次；正在修改历史数据时等待修改完成
+	deadline := time.Now().Add(studyDataLockWait)
+	for attempt := 0; attempt < 2; {
+		if err := r.warmStudyDataKeys(userID, date); err != nil {
+			return 0, false, err
+		}
+		dayStudyTime, err := incrementStudyDataScript.Run(r.ctx, r.redis, keys,
+			studyTime, tomatoes, int(dailyKeyTTL.Seconds()), int(monthlyKeyTTL.Seconds()), member, maxDayStudyTime).Int()
+		if err != nil {
+			return 0, false, err
+		}
+		switch {
+		case dayStudyTime >= 0:
+			return dayStudyTime, true, nil
+		case dayStudyTime == -2:
+			return 0, false, nil
+		case dayStudyTime == -3:
+			if time.Now().After(deadline) {
+				return 0, false, fmt.Errorf("学习数据正在修改，请稍后再试")
+			}
+			time.Sleep(100 * time.Millisecond)
+		default:
+			attempt++
+		}
 	}
+	return 0, false, fmt.Errorf("学习数据缓存预热失败")
+}
 
-	pipe := r.redis.Pipeline()
-	pipe.HIncrBy(r.ctx, dailykey, "study_time", int64(studyTime))
-	pipe.HIncrBy(r.ctx, monthlykey, "study_time", int64(studyTime))
+// 生成redis的学习数据修改锁key
+func (r *StudyDataRepository) GenerateStudyDataLockKey(userID uint) string {
+	return fmt.Sprintf("user:%d:studydata:lock", userID)
+}
 
-	//设置并只设置一次过期时间(NX)
-	pipe.ExpireNX(r.ctx, dailykey, 26*time.Hour)
-	pipe.ExpireNX(r.ctx, monthlykey, 35*24*time.Hour)
+// 修改历史数据前锁住该用户的学习数据，锁住期间新的提交会等待
+func (r *StudyDataRepository) LockStudyData(userID uint, ttl time.Duration) (bool, error) {
+	return r.redis.SetNX(r.ctx, r.GenerateStudyDataLockKey(userID), time.Now().Unix(), ttl).Result()
+}
 
-	_, err := pipe.Exec(r.ctx)
-	return err
+func (r *StudyDataRepository) UnlockStudyData(userID uint) error {
+	return r.redis.Del(r.ctx, r.GenerateStudyDataLockKey(userID)).Err()
 }
 
-// 增加每日和每月番茄钟次数
-func (r *StudyDataRepository) IncrementDailyTomatoes(userID uint, date time.Time, tomatoes int) error {
+// 策略：操作前先检查Key是否存在，若不存在尝试从DB回捞数据进行预热，DB中也没有则初始化为0
+func (r *StudyDataRepository) warmStudyDataKeys(userID uint, date time.Time) error {
 	dailykey := r.GenerateDailyKey(userID, date)
-	monthlykey := r.GenerateMonthlyKey(userID, date)
-
-	// 关键修复：同上，防止番茄钟数据重置覆盖
 	if r.redis.Exists(r.ctx, dailykey).Val() == 0 {
-		r.GetDailyStudyData(userID, date)
+		_, err, notFound := r.GetDailyStudyData(userID, date)
+		if notFound {
+			err = r.initStudyDataKey(dailykey, dailyKeyTTL)
+		}
+		if err != nil {
+			return err
+		}
 	}
+	monthlykey := r.GenerateMonthlyKey(userID, date)
 	if r.redis.Exists(r.ctx, monthlykey).Val() == 0 {
-		r.GetMonthlyStudyData(userID, date)
+		_, err, notFound := r.GetMonthlyStudyData(userID, date)
+		if notFound {
+			err = r.initStudyDataKey(monthlykey, monthlyKeyTTL)
+		}
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+// 用 HSETNX 初始化，不会覆盖并发写入的数据
+func (r *StudyDataRepository) initStudyDataKey(key string, ttl time.Duration) error {
 	pipe := r.redis.Pipeline()
-	pipe.HIncrBy(r.ctx, dailykey, "tomatoes", int64(tomatoes))
-	pipe.HIncrBy(r.ctx, monthlykey, "tomatoes", int64(tomatoes))
-
-	//其实可以不写来着，以防万一
-	pipe.ExpireNX(r.ctx, dailykey, 26*time.Hour)
-	pipe.ExpireNX(r.ctx, monthlykey, 35*24*time.Hour)
-
+	pipe.HSetNX(r.ctx, key, "study_time", 0)
+	pipe.HSetNX(r.ctx, key, "tomatoes", 0)
+	pipe.Expire(r.ctx, key, ttl)
 	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// 待落库的条目数，包括分时段数据
+func (r *StudyDataRepository) CountDirtyStudyData() (int64, error) {
+	count, err := r.redis.SCard(r.ctx, studyDataDirtyKey).Result()
 	if err != nil {
-		return err
+		return 0, err
 	}
+	hourly, err := r.redis.SCard(r.ctx, hourlyStudyDataDirtyKey).Result()
+	return count + hourly, err
+}
 
-	return nil
+// 每日数据和分时段数据各自从脏集合取出最多 batchSize 条落库，一方失败不影响另一方
+// 返回两个脏集合中本次落库的条目总数，小于 batchSize 时两个脏集合都已处理完
+func (r *StudyDataRepository) FlushDirtyStudyData(batchSize int) (int, error) {
+	dailyCount, dailyErr := r.flushDirtyDailyStudyData(batchSize)
+	hourlyCount, hourlyErr := r.flushDirtyHourlyStudyData(batchSize)
+	return dailyCount + hourlyCount, errors.Join(dailyErr, hourlyErr)
 }
 
-// 同步数据到mysql
-func (r *StudyDataRepository) SyncDailyDataToMySQL(userID uint, date time.Time, addStudyTime int, addTomatoes int) error {
-	dailykey := r.GenerateDailyKey(userID, date)
-	monthlykey := r.GenerateMonthlyKey(userID, date)
+// 从脏集合取出最多 batchSize 条，把每日、每月数据以redis为准写入mysql，总数据按增量累加
+// 落库失败时把取出的条目和增量放回redis，等待下次重试；返回本次落库的条目数
+func (r *StudyDataRepository) flushDirtyDailyStudyData(batchSize int) (int, error) {
+	members, err := r.redis.SPopN(r.ctx, studyDataDirtyKey, int64(batchSize)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
 
-	daydata, err := r.redis.HGetAll(r.ctx, dailykey).Result()
+	dailyList, monthlyList, userIDs, err := r.loadDirtyStudyData(members)
 	if err != nil {
-		return err
+		r.redis.SAdd(r.ctx, studyDataDirtyKey, members)
+		return 0, err
 	}
-	if len(daydata) == 0 {
-		return nil
+	totals, err := r.popPendingTotals(userIDs)
+	if err != nil {
+		r.requeueDirtyStudyData(members, totals)
+		return 0, err
 	}
-	monthdata, err := r.redis.HGetAll(r.ctx, monthlykey).Result()
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		//更新每日数据
+		if len(dailyList) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+				DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+			}).Create(&dailyList).Error
+			if err != nil {
+				return err
+			}
+		}
+		//更新每月数据
+		if len(monthlyList) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "month"}},
+				DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+			}).Create(&monthlyList).Error
+			if err != nil {
+				return err
+			}
+		}
+		//更新总数据
+		for _, total := range totals {
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "user_id"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"study_time": gorm.Expr("study_time + ?", total.StudyTime),
+					"tomatoes":   gorm.Expr("tomatoes + ?", total.Tomatoes),
+					"updated_at": time.Now(),
+				}),
+			}).Create(&total).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return err
+		r.requeueDirtyStudyData(members, totals)
+		return 0, err
 	}
 
-	//每日的日期格式xxxx-xx-xx-00-00-00
-	daystudytime, _ := strconv.Atoi(daydata["study_time"])
-	daytomatoes, _ := strconv.Atoi(daydata["tomatoes"])
-	dailyData := models.DailyStudyData{
-		UserID:    userID,
-		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
-		StudyTime: daystudytime,
-		Tomatoes:  daytomatoes,
+	// 事务提交成功后，删除总数据缓存以保证下次读取最新数据
+	for _, total := range totals {
+		r.redis.Del(r.ctx, r.GenerateTotalKey(total.UserID))
 	}
-	//每月的日期格式xxxx-xx-01-00-00-00
-	monthstudytime, _ := strconv.Atoi(monthdata["study_time"])
-	monthtomatoes, _ := strconv.Atoi(monthdata["tomatoes"])
-	monthlyData := models.MonthlyStudyData{
-		UserID:    userID,
-		Month:     time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location()),
-		StudyTime: monthstudytime,
-		Tomatoes:  monthtomatoes,
+	return len(members), nil
+}
+
+// 读取脏集合成员对应的每日、每月数据，同一个月只保留一条；已注销用户的数据直接丢弃
+func (r *StudyDataRepository) loadDirtyStudyData(members []string) ([]models.DailyStudyData, []models.MonthlyStudyData, []uint, error) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	type dirtyEntry struct {
+		userID uint
+		date   time.Time
+	}
+	entries := make([]dirtyEntry, 0, len(members))
+	candidateIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		var userID uint
+		var day string
+		if _, err := fmt.Sscanf(member, "%d:%s", &userID, &day); err != nil {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", day, loc)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, dirtyEntry{userID: userID, date: date})
+		candidateIDs = append(candidateIDs, userID)
 	}
 
-	totalData := models.TotalStudyData{
-		UserID:    userID,
-		StudyTime: addStudyTime,
-		Tomatoes:  addTomatoes,
+	var existingIDs []uint
+	if len(candidateIDs) > 0 {
+		if err := r.db.Model(&models.User{}).Where("id IN ?", candidateIDs).Pluck("id", &existingIDs).Error; err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
 	}
 
-	tx := r.db.Begin()
-	//更新每日数据
-	err = tx.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
-		DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
-	}).Create(&dailyData).Error
-	if err != nil {
-		tx.Rollback()
-		return err
+	pipe := r.redis.Pipeline()
+	dailyCmds := make([]*redis.MapStringStringCmd, len(entries))
+	monthlyCmds := make([]*redis.MapStringStringCmd, len(entries))
+	for i, entry := range entries {
+		dailyCmds[i] = pipe.HGetAll(r.ctx, r.GenerateDailyKey(entry.userID, entry.date))
+		monthlyCmds[i] = pipe.HGetAll(r.ctx, r.GenerateMonthlyKey(entry.userID, entry.date))
 	}
-	//更新每月数据
-	err = tx.Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "user_id"}, {Name: "month"}},
-		DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
-	}).Create(&monthlyData).Error
-	if err != nil {
-		tx.Rollback()
-		return err
+	if _, err := pipe.Exec(r.ctx); err != nil {
+		return nil, nil, nil, err
 	}
-	//更新总数据
-	err = tx.Clauses(clause.OnConflict{
-		Columns: []clause.Column{{Name: "user_id"}},
-		DoUpdates: clause.Assignments(map[string]interface{}{
-			"study_time": gorm.Expr("study_time + ?", addStudyTime),
-			"tomatoes":   gorm.Expr("tomatoes + ?", addTomatoes),
-			"updated_at": time.Now(),
-		}),
-	}).Create(&totalData).Error
-	if err != nil {
-		tx.Rollback()
-		return err
+
+	dailyList := make([]models.DailyStudyData, 0, len(entries))
+	monthlyList := make([]models.MonthlyStudyData, 0, len(entries))
+	seenMonths := make(map[string]bool)
+	seenUsers := make(map[uint]bool)
+	userIDs := make([]uint, 0)
+	for i, entry := range entries {
+		if !existing[entry.userID] {
+			continue
+		}
+		if !seenUsers[entry.userID] {
+			seenUsers[entry.userID] = true
+			userIDs = append(userIDs, entry.userID)
+		}
+		//每日的日期格式xxxx-xx-xx-00-00-00
+		if daydata := dailyCmds[i].Val(); len(daydata) > 0 {
+			studyTime, _ := strconv.Atoi(daydata["study_time"])
+			tomatoes, _ := strconv.Atoi(daydata["tomatoes"])
+			dailyList = append(dailyList, models.DailyStudyData{
+				UserID:    entry.userID,
+				Date:      entry.date,
+				StudyTime: studyTime,
+				Tomatoes:  tomatoes,
+			})
+		}
+		//每月的日期格式xxxx-xx-01-00-00-00
+		monthKey := r.GenerateMonthlyKey(entry.userID, entry.date)
+		if monthdata := monthlyCmds[i].Val(); len(monthdata) > 0 && !seenMonths[monthKey] {
+			seenMonths[monthKey] = true
+			studyTime, _ := strconv.Atoi(monthdata["study_time"])
+			tomatoes, _ := strconv.Atoi(monthdata["tomatoes"])
+			monthlyList = append(monthlyList, models.MonthlyStudyData{
+				UserID:    entry.userID,
+				Month:     time.Date(entry.date.Year(), entry.date.Month(), 1, 0, 0, 0, 0, entry.date.Location()),
+				StudyTime: studyTime,
+				Tomatoes:  tomatoes,
+			})
+		}
 	}
+	return dailyList, monthlyList, userIDs, nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		return err
+// 取出各用户总数据的待落库增量
+func (r *StudyDataRepository) popPendingTotals(userIDs []uint) ([]models.TotalStudyData, error) {
+	totals := make([]models.TotalStudyData, 0, len(userIDs))
+	for _, userID := range userIDs {
+		values, err := popPendingTotalScript.Run(r.ctx, r.redis, []string{r.GeneratePendingTotalKey(userID)}).Slice()
+		if err != nil {
+			return totals, err
+		}
+		studyTime, tomatoes := parseRedisInt(values[0]), parseRedisInt(values[1])
+		if studyTime == 0 && tomatoes == 0 {
+			continue
+		}
+		totals = append(totals, models.TotalStudyData{
+			UserID:    userID,
+			StudyTime: studyTime,
+			Tomatoes:  tomatoes,
+		})
 	}
+	return totals, nil
+}
 
-	// 事务提交成功后，删除总数据缓存以保证下次读取最新数据
-	totalkey := fmt.Sprintf("user:%d:studydata:total", userID)
-	r.redis.Del(r.ctx, totalkey)
+// 落库失败时把条目和总数据增量放回redis
+func (r *StudyDataRepository) requeueDirtyStudyData(members []string, totals []models.TotalStudyData) {
+	pipe := r.redis.Pipeline()
+	pipe.SAdd(r.ctx, studyDataDirtyKey, members)
+	for _, total := range totals {
+		pendingkey := r.GeneratePendingTotalKey(total.UserID)
+		pipe.HIncrBy(r.ctx, pendingkey, "study_time", int64(total.StudyTime))
+		pipe.HIncrBy(r.ctx, pendingkey, "tomatoes", int64(total.Tomatoes))
+	}
+	pipe.Exec(r.ctx)
+}
 
-	return nil
+func parseRedisInt(value interface{}) int {
+	str, ok := value.(string)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(str)
+	return n
+}
+
+// 生成提交去重key
+func (r *StudyDataRepository) GenerateSubmissionKey(userID uint, idempotencyKey string) string {
+	return fmt.Sprintf("user:%d:studydata:submission:%s", userID, idempotencyKey)
+}
+
+// 占用幂等key，SETNX成功表示首次提交；key已存在表示重复提交，返回false
+func (r *StudyDataRepository) ClaimSubmission(userID uint, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return r.redis.SetNX(r.ctx, r.GenerateSubmissionKey(userID, idempotencyKey), time.Now().Unix(), ttl).Result()
+}
+
+// 提交处理失败时释放幂等key，允许客户端重试
+func (r *StudyDataRepository) ReleaseSubmission(userID uint, idempotencyKey string) error {
+	return r.redis.Del(r.ctx, r.GenerateSubmissionKey(userID, idempotencyKey)).Err()
 }
 
 //以下是查询数据以及总结报告的数据汇总
@@ -206,9 +451,9 @@ func (r *StudyDataRepository) GetDailyStudyData(userID uint, date time.Time) (*m
 		}
 		//同步至redis
 		pipe := r.redis.Pipeline()
-		pipe.HSet(r.ctx, dailykey, "study_time", dailyData.StudyTime)
-		pipe.HSet(r.ctx, dailykey, "tomatoes", dailyData.Tomatoes)
-		pipe.Expire(r.ctx, dailykey, 26*time.Hour)
+		pipe.HSetNX(r.ctx, dailykey, "study_time", dailyData.StudyTime)
+		pipe.HSetNX(r.ctx, dailykey, "tomatoes", dailyData.Tomatoes)
+		pipe.Expire(r.ctx, dailykey, dailyKeyTTL)
 		_, err = pipe.Exec(r.ctx)
 		if err != nil {
 			return nil, err, false
@@ -248,9 +493,9 @@ func (r *StudyDataRepository) GetMonthlyStudyData(userID uint, date time.Time) (
 		}
 		//同步至redis
 		pipe := r.redis.Pipeline()
-		pipe.HSet(r.ctx, monthlykey, "study_time", monthlyData.StudyTime)
-		pipe.HSet(r.ctx, monthlykey, "tomatoes", monthlyData.Tomatoes)
-		pipe.Expire(r.ctx, monthlykey, 35*24*time.Hour)
+		pipe.HSetNX(r.ctx, monthlykey, "study_time", monthlyData.StudyTime)
+		pipe.HSetNX(r.ctx, monthlykey, "tomatoes", monthlyData.Tomatoes)
+		pipe.Expire(r.ctx, monthlykey, monthlyKeyTTL)
 		_, err = pipe.Exec(r.ctx)
 		if err != nil {
 			return nil, err, false
@@ -269,9 +514,9 @@ func (r *StudyDataRepository) GetMonthlyStudyData(userID uint, date time.Time) (
 }
 
 // 查询总学习数据
-// 总数据仅存于mysql中, 但为了性能优化, 查询后会缓存至redis, 设置过期时间24小时
+// 总数据存于mysql中，加上redis中尚未落库的增量; 为了性能优化, 查询后会缓存至redis, 设置过期时间24小时
 func (r *StudyDataRepository) GetTotalStudyData(userID uint) (*models.TotalStudyData, error, bool) {
-	totalkey := fmt.Sprintf("user:%d:studydata:total", userID)
+	totalkey := r.GenerateTotalKey(userID)
 	data, err := r.redis.HGetAll(r.ctx, totalkey).Result()
 	if err != nil {
 		return nil, err, false
@@ -280,12 +525,21 @@ func (r *StudyDataRepository) GetTotalStudyData(userID uint) (*models.TotalStudy
 		//查询mysql
 		var totalData models.TotalStudyData
 		result := r.db.Where("user_id = ?", userID).First(&totalData)
-		if result.Error == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("还未开始记录学习数据"), true
-		}
-		if result.Error != nil {
+		if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
 			return nil, result.Error, false
 		}
+		pending, err := r.redis.HGetAll(r.ctx, r.GeneratePendingTotalKey(userID)).Result()
+		if err != nil {
+			return nil, err, false
+		}
+		if result.Error == gorm.ErrRecordNotFound && len(pending) == 0 {
+			return nil, fmt.Errorf("还未开始记录学习数据"), true
+		}
+		pendingStudyTime, _ := strconv.Atoi(pending["study_time"])
+		pendingTomatoes, _ := strconv.Atoi(pending["tomatoes"])
+		totalData.UserID = userID
+		totalData.StudyTime += pendingStudyTime
+		totalData.Tomatoes += pendingTomatoes
 		//同步至redis
 		pipe := r.redis.Pipeline()
 		pipe.HSet(r.ctx, totalkey, "study_time", totalData.StudyTime)
@@ -308,7 +562,7 @@ func (r *StudyDataRepository) GetTotalStudyData(userID uint) (*models.TotalStudy
 }
 
 // 查询某段时间内的学习数据，进而生成总结报告同时返回具体每日数据，但计算总和交给上层调用者
-// 一次数据库范围查询 + Redis获取今日最新数据修正
+// 一次数据库范围查询 + Redis获取未落库的最新数据修正
 func (r *StudyDataRepository) GetStudyDataSummary(userID uint, startDate, endDate time.Time) ([]models.DailyStudyData, error) {
 	//一次性从MySQL中获取该时间段内的所有每日数据
 	var dailyDataList []models.DailyStudyData
@@ -324,38 +578,526 @@ func (r *StudyDataRepository) GetStudyDataSummary(userID uint, startDate, endDat
 		dataMap[dailyDataList[i].Date.Format("2006-01-02")] = &dailyDataList[i]
 	}
 
-	// 修正"今天"的数据 (MySQL中的今天可能不是最新的，以Redis为准)
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// 修正尚未落库的数据 (MySQL中的数据可能不是最新的，以Redis为准)
+	days := make([]time.Time, 0)
+	members := make([]interface{}, 0)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+		members = append(members, fmt.Sprintf("%d:%s", userID, day.Format("2006-01-02")))
+	}
+	if len(members) == 0 {
+		return dailyDataList, nil
+	}
+	dirty, err := r.redis.SMIsMember(r.ctx, studyDataDirtyKey, members...).Result()
+	if err != nil {
+		return dailyDataList, nil
+	}
 
-	// 如果查询范围包含"今天"
-	if (today.Equal(startDate) || today.After(startDate)) && (today.Equal(endDate) || today.Before(endDate)) {
-		dailyKey := r.GenerateDailyKey(userID, today)
+	// 追加的记录先单独存放，避免切片扩容后 dataMap 中的指针失效
+	appended := make([]models.DailyStudyData, 0)
+	for i, day := range days {
+		if !dirty[i] {
+			continue
+		}
+		dailyKey := r.GenerateDailyKey(userID, day)
 		redisData, err := r.redis.HGetAll(r.ctx, dailyKey).Result()
+		if err != nil || len(redisData) == 0 {
+			continue
+		}
+		// 解析Redis最新数据
+		realTimeStudy, _ := strconv.Atoi(redisData["study_time"])
+		realTimeTomato, _ := strconv.Atoi(redisData["tomatoes"])
 
-		if err == nil && len(redisData) > 0 {
-			// 解析Redis最新数据
-			realTimeStudy, _ := strconv.Atoi(redisData["study_time"])
-			realTimeTomato, _ := strconv.Atoi(redisData["tomatoes"])
-			todayStr := today.Format("2006-01-02")
-
-			record, exists := dataMap[todayStr]
-			if exists {
-				// 情况A: 数据库有记录，但Redis是最新的 -> 覆盖
-				record.StudyTime = realTimeStudy
-				record.Tomatoes = realTimeTomato
-			} else {
-				// 情况B: 数据库没记录(今天第一次还没同步)，但Redis有 -> 追加
-				todayRecord := models.DailyStudyData{
-					UserID:    userID,
-					Date:      today,
-					StudyTime: realTimeStudy,
-					Tomatoes:  realTimeTomato,
-				}
-				dailyDataList = append(dailyDataList, todayRecord)
-			}
+		record, exists := dataMap[day.Format("2006-01-02")]
+		if exists {
+			// 情况A: 数据库有记录，但Redis是最新的 -> 覆盖
+			record.StudyTime = realTimeStudy
+			record.Tomatoes = realTimeTomato
+		} else {
+			// 情况B: 数据库没记录(还没落库)，但Redis有 -> 追加
+			appended = append(appended, models.DailyStudyData{
+				UserID:    userID,
+				Date:      day,
+				StudyTime: realTimeStudy,
+				Tomatoes:  realTimeTomato,
+			})
 		}
 	}
+	dailyDataList = append(dailyDataList, appended...)
+	sort.SliceStable(dailyDataList, func(i, j int) bool {
+		return dailyDataList[i].Date.Before(dailyDataList[j].Date)
+	})
 
 	return dailyDataList, nil
 }
+
+//以下是对账相关的查询与修复
+
+// 有学习数据的用户
+func (r *StudyDataRepository) GetStudyDataUserIDs() ([]uint, error) {
+	seen := make(map[uint]bool)
+	ids := make([]uint, 0)
+	for _, model := range []interface{}{&models.DailyStudyData{}, &models.MonthlyStudyData{}, &models.TotalStudyData{}} {
+		var userIDs []uint
+		if err := r.db.Model(model).Distinct().Pluck("user_id", &userIDs).Error; err != nil {
+			return nil, err
+		}
+		for _, id := range userIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (r *StudyDataRepository) GetAllDailyStudyData(userID uint) ([]models.DailyStudyData, error) {
+	var dataList []models.DailyStudyData
+	err := r.db.Where("user_id = ?", userID).Order("date ASC").Find(&dataList).Error
+	return dataList, err
+}
+
+func (r *StudyDataRepository) GetAllMonthlyStudyData(userID uint) ([]models.MonthlyStudyData, error) {
+	var dataList []models.MonthlyStudyData
+	err := r.db.Where("user_id = ?", userID).Order("month ASC").Find(&dataList).Error
+	return dataList, err
+}
+
+// 直接读取mysql中的总数据，不存在时返回nil
+func (r *StudyDataRepository) GetTotalStudyDataRow(userID uint) (*models.TotalStudyData, error) {
+	var totalData models.TotalStudyData
+	err := r.db.Where("user_id = ?", userID).First(&totalData).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &totalData, nil
+}
+
+// 只读取redis中的每月缓存，不回源，不存在时返回nil
+func (r *StudyDataRepository) PeekMonthlyCache(userID uint, month time.Time) (*models.MonthlyStudyData, error) {
+	data, err := r.redis.HGetAll(r.ctx, r.GenerateMonthlyKey(userID, month)).Result()
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	studyTime, _ := strconv.Atoi(data["study_time"])
+	tomatoes, _ := strconv.Atoi(data["tomatoes"])
+	return &models.MonthlyStudyData{
+		UserID:    userID,
+		Month:     month,
+		StudyTime: studyTime,
+		Tomatoes:  tomatoes,
+	}, nil
+}
+
+// 只读取redis中的总数据缓存，不回源，不存在时返回nil
+func (r *StudyDataRepository) PeekTotalCache(userID uint) (*models.TotalStudyData, error) {
+	data, err := r.redis.HGetAll(r.ctx, r.GenerateTotalKey(userID)).Result()
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	studyTime, _ := strconv.Atoi(data["study_time"])
+	tomatoes, _ := strconv.Atoi(data["tomatoes"])
+	return &models.TotalStudyData{
+		UserID:    userID,
+		StudyTime: studyTime,
+		Tomatoes:  tomatoes,
+	}, nil
+}
+
+// 用每日数据重新计算出的结果覆盖每月、总数据，并删除相关缓存，下次读取时从mysql回源
+func (r *StudyDataRepository) RepairStudyData(userID uint, monthlyList []models.MonthlyStudyData, total models.TotalStudyData) error {
+	months := make([]time.Time, 0, len(monthlyList))
+	for _, monthly := range monthlyList {
+		months = append(months, monthly.Month)
+	}
+	var staleMonths []time.Time
+	if err := r.db.Model(&models.MonthlyStudyData{}).Where("user_id = ?", userID).Pluck("month", &staleMonths).Error; err != nil {
+		return err
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 没有每日数据支撑的月份直接删除，硬删除以免软删除的记录占用唯一索引
+		query := tx.Unscoped().Where("user_id = ?", userID)
+		if len(months) > 0 {
+			query = query.Where("month NOT IN ?", months)
+		}
+		if err := query.Delete(&models.MonthlyStudyData{}).Error; err != nil {
+			return err
+		}
+		if len(monthlyList) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "month"}},
+				DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+			}).Create(&monthlyList).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+		}).Create(&total).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{r.GenerateTotalKey(userID)}
+	for _, month := range append(months, staleMonths...) {
+		keys = append(keys, r.GenerateMonthlyKey(userID, month))
+	}
+	return r.redis.Del(r.ctx, keys...).Err()
+}
+
+//以下是学习数据修改
+
+// 修改某天的学习数据，add 为 true 时在原值基础上增加，否则改为指定值
+// 当月数据由每日数据重新汇总，总数据按差值调整，并记录本次修改
+// 调用前需先把redis中未落库的数据写入mysql，提交后删除相关缓存
+func (r *StudyDataRepository) CorrectDailyStudyData(userID uint, date time.Time, studyTime, tomatoes int, add bool, reason string, maxStudyTime, maxTomatoes int) (*models.StudyDataCorrection, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	month := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	correction := &models.StudyDataCorrection{
+		UserID:       userID,
+		Date:         day,
+		NewStudyTime: studyTime,
+		NewTomatoes:  tomatoes,
+		Reason:       reason,
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var old models.DailyStudyData
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND date = ?", userID, day).
+			First(&old).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		correction.OldStudyTime = old.StudyTime
+		correction.OldTomatoes = old.Tomatoes
+		if add {
+			// 扣除时不会低于0
+			correction.NewStudyTime = max(correction.NewStudyTime+old.StudyTime, 0)
+			correction.NewTomatoes = max(correction.NewTomatoes+old.Tomatoes, 0)
+		}
+		// 修改后当天的数据不能超过每日上限，上限不大于0时不限制
+		if maxStudyTime > 0 && correction.NewStudyTime > maxStudyTime {
+			return fmt.Errorf("每日学习时长不能超过%d分钟", maxStudyTime)
+		}
+		if maxTomatoes > 0 && correction.NewTomatoes > maxTomatoes {
+			return fmt.Errorf("每日番茄钟数量不能超过%d个", maxTomatoes)
+		}
+
+		//更新每日数据
+		dailyData := models.DailyStudyData{
+			UserID:    userID,
+			Date:      day,
+			StudyTime: correction.NewStudyTime,
+			Tomatoes:  correction.NewTomatoes,
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+		}).Create(&dailyData).Error
+		if err != nil {
+			return err
+		}
+
+		//重新汇总每月数据
+		monthlyData := models.MonthlyStudyData{UserID: userID, Month: month}
+		err = tx.Model(&models.DailyStudyData{}).
+			Select("COALESCE(SUM(study_time), 0) AS study_time, COALESCE(SUM(tomatoes), 0) AS tomatoes").
+			Where("user_id = ? AND date BETWEEN ? AND ?", userID, month, month.AddDate(0, 1, -1)).
+			Scan(&monthlyData).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "month"}},
+			DoUpdates: clause.AssignmentColumns([]string{"study_time", "tomatoes", "updated_at"}),
+		}).Create(&monthlyData).Error
+		if err != nil {
+			return err
+		}
+
+		//按差值调整总数据
+		addStudyTime := correction.NewStudyTime - correction.OldStudyTime
+		addTomatoes := correction.NewTomatoes - correction.OldTomatoes
+		totalData := models.TotalStudyData{
+			UserID:    userID,
+			StudyTime: addStudyTime,
+			Tomatoes:  addTomatoes,
+		}
+		err = tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"study_time": gorm.Expr("study_time + ?", addStudyTime),
+				"tomatoes":   gorm.Expr("tomatoes + ?", addTomatoes),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&totalData).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(correction).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 删除缓存，下次读取时从mysql回源
+	r.redis.Del(r.ctx, r.GenerateDailyKey(userID, day), r.GenerateMonthlyKey(userID, day), r.GenerateTotalKey(userID),
+		r.GenerateHeatmapKey(userID, day.Year()))
+	return correction, nil
+}
+
+// 分页查询学习数据修改记录，按时间倒序
+func (r *StudyDataRepository) GetStudyDataCorrections(userID uint, page, pageSize int) ([]models.StudyDataCorrection, int64, error) {
+	var corrections []models.StudyDataCorrection
+	var total int64
+	query := r.db.Model(&models.StudyDataCorrection{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&corrections).Error
+	return corrections, total, err
+}
+
+//以下是热力图缓存
+
+// 生成热力图缓存key，按用户和年份缓存，不包含今天的数据
+func (r *StudyDataRepository) GenerateHeatmapKey(userID uint, year int) string {
+	return fmt.Sprintf("user:%d:studydata:heatmap:%d", userID, year)
+}
+
+// 读取热力图缓存，key 为日期 YYYY-MM-DD，value 为学习时长；未缓存时 found 为 false
+func (r *StudyDataRepository) GetHeatmapCache(userID uint, year int) (map[string]int, bool, error) {
+	data, err := r.redis.HGetAll(r.ctx, r.GenerateHeatmapKey(userID, year)).Result()
+	if err != nil || len(data) == 0 {
+		return nil, false, err
+	}
+	days := make(map[string]int, len(data))
+	for day, value := range data {
+		// 占位字段，保证没有学习数据的年份也能被缓存
+		if day == "_" {
+			continue
+		}
+		days[day], _ = strconv.Atoi(value)
+	}
+	return days, true, nil
+}
+
+func (r *StudyDataRepository) SetHeatmapCache(userID uint, year int, days map[string]int, ttl time.Duration) error {
+	key := r.GenerateHeatmapKey(userID, year)
+	values := make(map[string]interface{}, len(days)+1)
+	values["_"] = 0
+	for day, studyTime := range days {
+		values[day] = studyTime
+	}
+	pipe := r.redis.TxPipeline()
+	pipe.Del(r.ctx, key)
+	pipe.HSet(r.ctx, key, values)
+	pipe.Expire(r.ctx, key, ttl)
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+// 历史数据变化后删除对应年份的热力图缓存
+func (r *StudyDataRepository) InvalidateHeatmapCache(userID uint, year int) error {
+	return r.redis.Del(r.ctx, r.GenerateHeatmapKey(userID, year)).Err()
+}
+
+//以下是按小时统计的学习时长
+
+// 按小时的学习时长同样采用 write-behind：增量写入 pending hash 并登记到脏集合，由 flusher 累加落库
+// 脏集合成员格式 {userID}:{year}；pending hash 的 field 为小时 0~23
+const hourlyStudyDataDirtyKey = "studydata:hourly:dirty"
+
+// KEYS: 分时段增量hash、脏集合
+// ARGV: 小时、学习时长、脏集合成员
+var incrementHourlyStudyDataScript = redis.NewScript(`
+redis.call('HINCRBY', KEYS[1], ARGV[1], ARGV[2])
+redis.call('SADD', KEYS[2], ARGV[3])
+return 1
+`)
+
+// 取出并清空分时段增量
+var popPendingHourlyScript = redis.NewScript(`
+local values = redis.call('HGETALL', KEYS[1])
+redis.call('DEL', KEYS[1])
+return values
+`)
+
+// 生成redis的分时段待落库增量key
+func (r *StudyDataRepository) GeneratePendingHourlyKey(userID uint, year int) string {
+	return fmt.Sprintf("user:%d:studydata:hourly:%d:pending", userID, year)
+}
+
+// 累加某年某个小时的学习时长，先写入redis，由 flusher 落库
+func (r *StudyDataRepository) IncrementHourlyStudyData(userID uint, year, hour, studyTime int) error {
+	keys := []string{r.GeneratePendingHourlyKey(userID, year), hourlyStudyDataDirtyKey}
+	member := fmt.Sprintf("%d:%d", userID, year)
+	return incrementHourlyStudyDataScript.Run(r.ctx, r.redis, keys, hour, studyTime, member).Err()
+}
+
+// 从分时段脏集合取出最多 batchSize 条，把增量累加到mysql；失败时放回redis，返回本次处理的条目数
+func (r *StudyDataRepository) flushDirtyHourlyStudyData(batchSize int) (int, error) {
+	members, err := r.redis.SPopN(r.ctx, hourlyStudyDataDirtyKey, int64(batchSize)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+
+	dataList, err := r.popPendingHourly(members)
+	if err != nil {
+		r.requeueDirtyHourlyStudyData(members, dataList)
+		return 0, err
+	}
+	// 已注销用户的数据直接丢弃
+	userIDs := make([]uint, 0, len(dataList))
+	for _, data := range dataList {
+		userIDs = append(userIDs, data.UserID)
+	}
+	var existingIDs []uint
+	if len(userIDs) > 0 {
+		if err := r.db.Model(&models.User{}).Where("id IN ?", userIDs).Pluck("id", &existingIDs).Error; err != nil {
+			r.requeueDirtyHourlyStudyData(members, dataList)
+			return 0, err
+		}
+	}
+	existing := make(map[uint]bool, len(existingIDs))
+	for _, id := range existingIDs {
+		existing[id] = true
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		for _, data := range dataList {
+			if !existing[data.UserID] {
+				continue
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "user_id"}, {Name: "year"}, {Name: "hour"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{
+					"study_time": gorm.Expr("study_time + ?", data.StudyTime),
+					"updated_at": time.Now(),
+				}),
+			}).Create(&data).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		r.requeueDirtyHourlyStudyData(members, dataList)
+		return 0, err
+	}
+	return len(members), nil
+}
+
+// 取出脏集合成员对应的分时段增量
+func (r *StudyDataRepository) popPendingHourly(members []string) ([]models.HourlyStudyData, error) {
+	dataList := make([]models.HourlyStudyData, 0)
+	for _, member := range members {
+		var userID uint
+		var year int
+		if _, err := fmt.Sscanf(member, "%d:%d", &userID, &year); err != nil {
+			continue
+		}
+		values, err := popPendingHourlyScript.Run(r.ctx, r.redis, []string{r.GeneratePendingHourlyKey(userID, year)}).Slice()
+		if err != nil {
+			return dataList, err
+		}
+		for i := 0; i+1 < len(values); i += 2 {
+			hour, studyTime := parseRedisInt(values[i]), parseRedisInt(values[i+1])
+			if studyTime == 0 {
+				continue
+			}
+			dataList = append(dataList, models.HourlyStudyData{
+				UserID:    userID,
+				Year:      year,
+				Hour:      hour,
+				StudyTime: studyTime,
+			})
+		}
+	}
+	return dataList, nil
+}
+
+// 落库失败时把条目和分时段增量放回redis
+func (r *StudyDataRepository) requeueDirtyHourlyStudyData(members []string, dataList []models.HourlyStudyData) {
+	pipe := r.redis.Pipeline()
+	pipe.SAdd(r.ctx, hourlyStudyDataDirtyKey, members)
+	for _, data := range dataList {
+		pipe.HIncrBy(r.ctx, r.GeneratePendingHourlyKey(data.UserID, data.Year), strconv.Itoa(data.Hour), int64(data.StudyTime))
+	}
+	pipe.Exec(r.ctx)
+}
+
+// 查询某年按小时的学习时长，叠加redis中尚未落库的增量
+func (r *StudyDataRepository) GetHourlyStudyData(userID uint, year int) ([]models.HourlyStudyData, error) {
+	var dataList []models.HourlyStudyData
+	err := r.db.Where("user_id = ? AND year = ?", userID, year).Order("hour ASC").Find(&dataList).Error
+	if err != nil {
+		return nil, err
+	}
+	pending, err := r.redis.HGetAll(r.ctx, r.GeneratePendingHourlyKey(userID, year)).Result()
+	if err != nil || len(pending) == 0 {
+		return dataList, nil
+	}
+	for field, value := range pending {
+		hour, _ := strconv.Atoi(field)
+		studyTime, _ := strconv.Atoi(value)
+		found := false
+		for i := range dataList {
+			if dataList[i].Hour == hour {
+				dataList[i].StudyTime += studyTime
+				found = true
+				break
+			}
+		}
+		if !found && studyTime != 0 {
+			dataList = append(dataList, models.HourlyStudyData{UserID: userID, Year: year, Hour: hour, StudyTime: studyTime})
+		}
+	}
+	sort.Slice(dataList, func(i, j int) bool { return dataList[i].Hour < dataList[j].Hour })
+	return dataList, nil
+}
+
+// 以下是逐次学习记录相关
+
+func (r *StudyDataRepository) CreateStudySession(session *models.StudySession) error {
+	return r.db.Create(session).Error
+}
+
+// 删除一条结束于 endedAt、时长为 studyTime 的学习记录，供作废提交使用
+func (r *StudyDataRepository) DeleteStudySession(userID uint, endedAt time.Time, studyTime int) error {
+	var session models.StudySession
+	err := r.db.Where("user_id = ? AND ended_at = ? AND study_time = ?", userID, endedAt, studyTime).First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		// 没有起止时间的提交不会保存学习记录
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return r.db.Delete(&session).Error
+}
+
+// 查询开始时间在 [start, end) 内的学习记录
+func (r *StudyDataRepository) GetStudySessions(userID uint, start, end time.Time) ([]models.StudySession, error) {
+	var sessions []models.StudySession
+	err := r.db.Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, start, end).Order("started_at ASC").Find(&sessions).Error
+	return sessions, err
+}
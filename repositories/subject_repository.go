@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubjectRepository struct {
+	db *gorm.DB
+}
+
+func NewSubjectRepository(db *gorm.DB) *SubjectRepository {
+	return &SubjectRepository{db: db}
+}
+
+func (r *SubjectRepository) CreateSubject(subject *models.Subject) error {
+	return r.db.Create(subject).Error
+}
+
+func (r *SubjectRepository) GetSubjectByID(id uint) (*models.Subject, error) {
+	var subject models.Subject
+	err := r.db.First(&subject, id).Error
+	return &subject, err
+}
+
+// 同一用户下科目名称不能重复
+func (r *SubjectRepository) GetSubjectByName(userID uint, name string) (*models.Subject, error) {
+	var subject models.Subject
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&subject).Error
+	return &subject, err
+}
+
+func (r *SubjectRepository) GetSubjectsByUserID(userID uint) ([]models.Subject, error) {
+	var subjects []models.Subject
+	err := r.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&subjects).Error
+	return subjects, err
+}
+
+// 包括已删除的科目，用于历史数据的展示
+func (r *SubjectRepository) GetSubjectsByIDs(ids []uint) ([]models.Subject, error) {
+	var subjects []models.Subject
+	if len(ids) == 0 {
+		return subjects, nil
+	}
+	err := r.db.Unscoped().Where("id IN ?", ids).Find(&subjects).Error
+	return subjects, err
+}
+
+func (r *SubjectRepository) UpdateSubject(subject *models.Subject) error {
+	return r.db.Save(subject).Error
+}
+
+// 软删除科目，历史学习数据保留
+func (r *SubjectRepository) DeleteSubject(id uint) error {
+	var subject models.Subject
+	if err := r.db.First(&subject, id).Error; err != nil {
+		return fmt.Errorf("科目不存在")
+	}
+	return r.db.Delete(&models.Subject{}, id).Error
+}
+
+// 累加科目每日学习数据
+func (r *SubjectRepository) IncrementSubjectStudyData(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error {
+	data := models.SubjectDailyStudyData{
+		UserID:    userID,
+		SubjectID: subjectID,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		StudyTime: studyTime,
+		Tomatoes:  tomatoes,
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "subject_id"}, {Name: "date"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"study_time": gorm.Expr("study_time + ?", studyTime),
+			"tomatoes":   gorm.Expr("tomatoes + ?", tomatoes),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&data).Error
+}
+
+// 扣除科目某天的学习数据，不会扣成负数
+func (r *SubjectRepository) DecrementSubjectStudyData(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.db.Model(&models.SubjectDailyStudyData{}).
+		Where("user_id = ? AND subject_id = ? AND date = ?", userID, subjectID, day).
+		Updates(map[string]interface{}{
+			"study_time": gorm.Expr("GREATEST(study_time - ?, 0)", studyTime),
+			"tomatoes":   gorm.Expr("GREATEST(tomatoes - ?, 0)", tomatoes),
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// 查询某段时间内每个科目每天的学习数据，按日期升序
+func (r *SubjectRepository) GetSubjectStudyData(userID uint, startDate, endDate time.Time) ([]models.SubjectDailyStudyData, error) {
+	var dataList []models.SubjectDailyStudyData
+	err := r.db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, startDate, endDate).
+		Order("date ASC, subject_id ASC").
+		Find(&dataList).Error
+	return dataList, err
+}
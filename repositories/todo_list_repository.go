@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TodoListRepository struct {
+	db *gorm.DB
+}
+
+func NewTodoListRepository(db *gorm.DB) *TodoListRepository {
+	return &TodoListRepository{db: db}
+}
+
+func (r *TodoListRepository) CreateTodoList(list *models.TodoList) error {
+	return r.db.Create(list).Error
+}
+
+func (r *TodoListRepository) GetTodoListByID(id uint) (*models.TodoList, error) {
+	var list models.TodoList
+	err := r.db.First(&list, id).Error
+	return &list, err
+}
+
+// 同一用户下清单名称不能重复
+func (r *TodoListRepository) GetTodoListByName(userID uint, name string) (*models.TodoList, error) {
+	var list models.TodoList
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&list).Error
+	return &list, err
+}
+
+func (r *TodoListRepository) GetTodoListsByUserID(userID uint) ([]models.TodoList, error) {
+	var lists []models.TodoList
+	err := r.db.Where("user_id = ?", userID).Order("position ASC, id ASC").Find(&lists).Error
+	return lists, err
+}
+
+func (r *TodoListRepository) UpdateTodoList(list *models.TodoList) error {
+	return r.db.Save(list).Error
+}
+
+// 删除清单，清单中的待办和重复规则移到收集箱，共享的成员和修改记录一并删除
+func (r *TodoListRepository) DeleteTodoList(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("list_id = ?", id).Delete(&models.TodoListMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("list_id = ?", id).Delete(&models.TodoActivity{}).Error; err != nil {
+			return err
+		}
+		// 回收站中的待办也一起移出
+		if err := tx.Unscoped().Model(&models.Todo{}).Where("list_id = ?", id).Update("list_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TodoRecurrence{}).Where("list_id = ?", id).Update("list_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TodoList{}, id).Error
+	})
+}
+
+// 新清单排在最后
+func (r *TodoListRepository) NextTodoListPosition(userID uint) (int, error) {
+	var maxPosition *int
+	err := r.db.Model(&models.TodoList{}).Where("user_id = ?", userID).Select("MAX(position)").Scan(&maxPosition).Error
+	if err != nil {
+		return 0, err
+	}
+	if maxPosition == nil {
+		return models.TodoPositionGap, nil
+	}
+	return *maxPosition + models.TodoPositionGap, nil
+}
+
+// 排在 after 之后的第一个清单的位置，after 为空时返回第一个清单的位置，没有时返回nil
+func (r *TodoListRepository) TodoListPositionAfter(userID uint, after *int, excludeID uint) (*int, error) {
+	var position *int
+	query := r.db.Model(&models.TodoList{}).Where("user_id = ? AND id <> ?", userID, excludeID)
+	if after != nil {
+		query = query.Where("position > ?", *after)
+	}
+	err := query.Select("MIN(position)").Scan(&position).Error
+	return position, err
+}
+
+// 位置间隔用完时，按当前顺序重新分配所有清单的位置
+func (r *TodoListRepository) RebalanceTodoLists(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var lists []models.TodoList
+		if err := tx.Where("user_id = ?", userID).Order("position ASC, id ASC").Find(&lists).Error; err != nil {
+			return err
+		}
+		for i, list := range lists {
+			if err := tx.Model(&models.TodoList{}).Where("id = ?", list.ID).Update("position", (i+1)*models.TodoPositionGap).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 以下是共享清单相关
+
+// 清单中的成员，不是成员时返回nil
+func (r *TodoListRepository) GetTodoListMember(listID, userID uint) (*models.TodoListMember, error) {
+	var member models.TodoListMember
+	err := r.db.Where("list_id = ? AND user_id = ?", listID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *TodoListRepository) GetTodoListMembers(listID uint) ([]models.TodoListMember, error) {
+	var members []models.TodoListMember
+	err := r.db.Where("list_id = ?", listID).Order("id ASC").Find(&members).Error
+	return members, err
+}
+
+func (r *TodoListRepository) CreateTodoListMember(member *models.TodoListMember) error {
+	return r.db.Create(member).Error
+}
+
+func (r *TodoListRepository) UpdateTodoListMember(member *models.TodoListMember) error {
+	return r.db.Save(member).Error
+}
+
+func (r *TodoListRepository) DeleteTodoListMember(id uint) error {
+	return r.db.Delete(&models.TodoListMember{}, id).Error
+}
+
+// 用户已加入的其他人的清单
+func (r *TodoListRepository) GetSharedTodoLists(userID uint) ([]models.TodoList, []models.TodoListMember, error) {
+	var members []models.TodoListMember
+	if err := r.db.Where("user_id = ? AND status = ?", userID, "accepted").Order("id ASC").Find(&members).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(members) == 0 {
+		return nil, members, nil
+	}
+	ids := make([]uint, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ListID)
+	}
+	var lists []models.TodoList
+	err := r.db.Where("id IN ?", ids).Find(&lists).Error
+	return lists, members, err
+}
+
+// 用户收到的还未处理的邀请
+func (r *TodoListRepository) GetPendingTodoListInvites(userID uint) ([]models.TodoListMember, error) {
+	var members []models.TodoListMember
+	err := r.db.Where("user_id = ? AND status = ?", userID, "pending").Order("created_at DESC").Find(&members).Error
+	return members, err
+}
+
+func (r *TodoListRepository) CreateTodoActivity(activity *models.TodoActivity) error {
+	return r.db.Create(activity).Error
+}
+
+// 分页查询清单的修改记录，最新的在前
+func (r *TodoListRepository) GetTodoActivities(listID uint, page, pageSize int) ([]models.TodoActivity, int64, error) {
+	query := r.db.Model(&models.TodoActivity{}).Where("list_id = ?", listID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var activities []models.TodoActivity
+	err := query.Order("created_at DESC, id DESC").
+		Offset((page - 1) * pageSize).Limit(pageSize).Find(&activities).Error
+	return activities, total, err
+}
+
+// 按ID查询用户名，用于展示清单成员和修改者
+func (r *TodoListRepository) GetUsernames(ids []uint) (map[uint]string, error) {
+	usernames := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return usernames, nil
+	}
+	var users []models.User
+	if err := r.db.Select("id", "username").Where("id IN ?", ids).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+	return usernames, nil
+}
+
+// 以下是标签相关
+
+func (r *TodoListRepository) GetTodoTagByID(id uint) (*models.TodoTag, error) {
+	var tag models.TodoTag
+	err := r.db.First(&tag, id).Error
+	return &tag, err
+}
+
+func (r *TodoListRepository) GetTodoTagsByUserID(userID uint) ([]models.TodoTag, error) {
+	var tags []models.TodoTag
+	err := r.db.Where("user_id = ?", userID).Order("name ASC").Find(&tags).Error
+	return tags, err
+}
+
+// 按名称查找标签，不存在的自动创建
+func (r *TodoListRepository) GetOrCreateTodoTags(userID uint, names []string) ([]models.TodoTag, error) {
+	return getOrCreateTodoTags(r.db, userID, names)
+}
+
+func getOrCreateTodoTags(tx *gorm.DB, userID uint, names []string) ([]models.TodoTag, error) {
+	tags := make([]models.TodoTag, 0, len(names))
+	if len(names) == 0 {
+		return tags, nil
+	}
+	created := make([]models.TodoTag, 0, len(names))
+	for _, name := range names {
+		created = append(created, models.TodoTag{UserID: userID, Name: name})
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&created).Error; err != nil {
+		return nil, err
+	}
+	err := tx.Where("user_id = ? AND name IN ?", userID, names).Find(&tags).Error
+	return tags, err
+}
+
+// 同一用户下标签名称不能重复
+func (r *TodoListRepository) GetTodoTagByName(userID uint, name string) (*models.TodoTag, error) {
+	var tag models.TodoTag
+	err := r.db.Where("user_id = ? AND name = ?", userID, name).First(&tag).Error
+	return &tag, err
+}
+
+func (r *TodoListRepository) UpdateTodoTag(tag *models.TodoTag) error {
+	return r.db.Save(tag).Error
+}
+
+// 删除标签及其与待办的关联
+func (r *TodoListRepository) DeleteTodoTag(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TodoTagging{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.TodoTag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("标签不存在")
+		}
+		return nil
+	})
+}
+
+// 用给定的标签替换待办原有的标签
+func (r *TodoListRepository) SetTodoTags(userID, todoID uint, tagIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("todo_id = ?", todoID).Delete(&models.TodoTagging{}).Error; err != nil {
+			return err
+		}
+		return createTodoTaggings(tx, userID, todoID, tagIDs)
+	})
+}
+
+func createTodoTaggings(tx *gorm.DB, userID, todoID uint, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	taggings := make([]models.TodoTagging, 0, len(tagIDs))
+	for _, tagID := range tagIDs {
+		taggings = append(taggings, models.TodoTagging{TodoID: todoID, TagID: tagID, UserID: userID})
+	}
+	return tx.Create(&taggings).Error
+}
+
+// 在一个事务中为多个待办添加、移除或替换标签
+// mode 为 add / remove / set
+func (r *TodoListRepository) BatchUpdateTodoTags(userID uint, todoIDs, tagIDs []uint, mode string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		switch mode {
+		case "remove":
+			if len(tagIDs) == 0 {
+				return nil
+			}
+			return tx.Where("todo_id IN ? AND tag_id IN ?", todoIDs, tagIDs).Delete(&models.TodoTagging{}).Error
+		case "set":
+			if err := tx.Where("todo_id IN ?", todoIDs).Delete(&models.TodoTagging{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		taggings := make([]models.TodoTagging, 0, len(todoIDs)*len(tagIDs))
+		for _, todoID := range todoIDs {
+			for _, tagID := range tagIDs {
+				taggings = append(taggings, models.TodoTagging{TodoID: todoID, TagID: tagID, UserID: userID})
+			}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&taggings).Error
+	})
+}
+
+// 查询多个待办上的标签
+func (r *TodoListRepository) GetTodoTagNames(todoIDs []uint) ([]models.TodoTagName, error) {
+	var names []models.TodoTagName
+	if len(todoIDs) == 0 {
+		return names, nil
+	}
+	err := r.db.Table("todo_taggings").
+		Select("todo_taggings.todo_id, todo_taggings.tag_id, todo_tags.name").
+		Joins("JOIN todo_tags ON todo_tags.id = todo_taggings.tag_id").
+		Where("todo_taggings.todo_id IN ?", todoIDs).
+		Order("todo_tags.name ASC").
+		Scan(&names).Error
+	return names, err
+}
@@ -3,8 +3,10 @@ package repository
 import (
 	"2026-FM247-BackEnd/models"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type TodoRepository struct {
@@ -15,26 +17,103 @@ func NewTodoRepository(db *gorm.DB) *TodoRepository {
 	return &TodoRepository{db: db}
 }
 
-func (r *TodoRepository) CreateTodo(todo *models.Todo) error {
-	return r.db.Create(todo).Error
+// 在一个事务中创建待办和标签，返回待办的标签
+func (r *TodoRepository) CreateTodo(todo *models.Todo, tagNames []string) ([]models.TodoTag, error) {
+	var tags []models.TodoTag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(todo).Error; err != nil {
+			return err
+		}
+		var err error
+		tags, err = tagNewTodo(tx, todo, tagNames)
+		return err
+	})
+	return tags, err
 }
 
-func (r *TodoRepository) GetTodosByUserID(userID uint) ([]models.Todo, error) {
+// 为刚创建的待办添加标签，不存在的标签自动创建
+func tagNewTodo(tx *gorm.DB, todo *models.Todo, tagNames []string) ([]models.TodoTag, error) {
+	tags, err := getOrCreateTodoTags(tx, todo.UserID, tagNames)
+	if err != nil {
+		return nil, err
+	}
+	tagIDs := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	return tags, createTodoTaggings(tx, todo.UserID, todo.ID, tagIDs)
+}
+
+func (r *TodoRepository) GetTodosByUserID(userID uint, filter models.TodoFilter) ([]models.Todo, error) {
 	if userID == 0 {
 		return nil, fmt.Errorf("无效的用户ID")
 	}
 
 	var todos []models.Todo
-	query := r.db.Where("user_id = ?", userID)
+	// 子任务在所属待办的详情中返回，列表只包含顶层待办
+	query := r.db.Where("user_id = ? AND parent_id IS NULL", userID)
+
+	// 筛选
+	switch filter.Status {
+	case "active":
+		query = query.Where("completed = ?", false)
+	case "completed":
+		query = query.Where("completed = ?", true)
+	}
+	if filter.DueBefore != nil {
+		query = query.Where("due_at IS NOT NULL AND due_at < ?", *filter.DueBefore)
+	}
+	if filter.Priority != nil {
+		query = query.Where("priority = ?", *filter.Priority)
+	}
+	if filter.ListID != nil {
+		if *filter.ListID == 0 {
+			query = query.Where("list_id IS NULL")
+		} else {
+			query = query.Where("list_id = ?", *filter.ListID)
+		}
+	}
+	if filter.TagID != nil {
+		query = query.Where("id IN (?)", r.db.Model(&models.TodoTagging{}).Select("todo_id").Where("tag_id = ?", *filter.TagID))
+	}
 
 	// 排序
-	query = query.Order("created_at DESC")
+	order := "DESC"
+	if filter.Order == "asc" {
+		order = "ASC"
+	}
+	switch filter.SortBy {
+	case "due_at":
+		// 没有截止时间的排在最后
+		query = query.Order("due_at IS NULL").Order("due_at " + order)
+	case "priority":
+		query = query.Order("priority " + order)
+	case "position":
+		// 手动排序只按位置升序
+		query = query.Order("position ASC")
+		order = "ASC"
+	}
+	query = query.Order("created_at " + order).Order("id " + order)
 
 	err := query.Find(&todos).Error
 
 	return todos, err
 }
 
+// 分配给用户的待办，包括子任务，status 为 active / completed，为空表示全部
+func (r *TodoRepository) GetAssignedTodos(assigneeID uint, status string) ([]models.Todo, error) {
+	query := r.db.Where("assignee_id = ?", assigneeID)
+	switch status {
+	case "active":
+		query = query.Where("completed = ?", false)
+	case "completed":
+		query = query.Where("completed = ?", true)
+	}
+	var todos []models.Todo
+	err := query.Order("due_at IS NULL").Order("due_at ASC").Order("id ASC").Find(&todos).Error
+	return todos, err
+}
+
 func (r *TodoRepository) GetTodoByID(id uint) (*models.Todo, error) {
 	var todo models.Todo
 	err := r.db.First(&todo, id).Error
@@ -57,5 +136,362 @@ func (r *TodoRepository) DeleteTodo(id uint) error {
 		return fmt.Errorf("任务不存在")
 	}
 
+	// 软删除，移到回收站
 	return r.db.Delete(&models.Todo{}, id).Error
 }
+
+// 以下是子任务相关
+
+// 同级子任务的下一个位置，parentID 为空时表示顶层待办
+func (r *TodoRepository) NextPosition(userID uint, parentID *uint) (int, error) {
+	var maxPosition *int
+	query := r.db.Model(&models.Todo{}).Where("user_id = ?", userID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+	if err := query.Select("MAX(position)").Scan(&maxPosition).Error; err != nil {
+		return 0, err
+	}
+	if maxPosition == nil {
+		return models.TodoPositionGap, nil
+	}
+	return *maxPosition + models.TodoPositionGap, nil
+}
+
+// 逐层查询所有下级子任务
+func (r *TodoRepository) GetDescendants(ids []uint) ([]models.Todo, error) {
+	descendants := make([]models.Todo, 0)
+	parentIDs := ids
+	for len(parentIDs) > 0 {
+		var children []models.Todo
+		if err := r.db.Where("parent_id IN ?", parentIDs).Order("position ASC, id ASC").Find(&children).Error; err != nil {
+			return nil, err
+		}
+		parentIDs = make([]uint, 0, len(children))
+		for _, child := range children {
+			parentIDs = append(parentIDs, child.ID)
+		}
+		descendants = append(descendants, children...)
+	}
+	return descendants, nil
+}
+
+// 把多个待办一起移到回收站，同一批的删除时间相同，恢复时据此找回一起删除的子任务
+func (r *TodoRepository) DeleteTodos(ids []uint) error {
+	return r.db.Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
+
+// 彻底删除待办及其标签、学习数据和提醒
+func (r *TodoRepository) PurgeTodos(ids []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return purgeTodos(tx, ids)
+	})
+}
+
+func purgeTodos(tx *gorm.DB, ids []uint) error {
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.TodoTagging{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.TodoDailyStudyData{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("todo_id IN ?", ids).Delete(&models.TodoReminder{}).Error; err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
+
+// 把未完成的待办标记为已完成，userID 为完成的用户
+func (r *TodoRepository) CompleteTodos(ids []uint, userID uint, at time.Time) error {
+	return r.db.Model(&models.Todo{}).
+		Where("id IN ? AND completed = ?", ids, false).
+		Updates(map[string]interface{}{"completed": true, "completed_at": at, "updated_by": userID}).Error
+}
+
+// 把已完成的待办恢复为未完成
+func (r *TodoRepository) ReopenTodos(ids []uint) error {
+	return r.db.Model(&models.Todo{}).
+		Where("id IN ? AND completed = ?", ids, true).
+		Updates(map[string]interface{}{"completed": false, "completed_at": nil}).Error
+}
+
+// 按给定顺序重排同一个待办下的子任务
+func (r *TodoRepository) ReorderSubtasks(parentID uint, orderedIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range orderedIDs {
+			err := tx.Model(&models.Todo{}).
+				Where("id = ? AND parent_id = ?", id, parentID).
+				Update("position", (i+1)*models.TodoPositionGap).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 以下是回收站相关
+
+// 分页查询回收站，只列出单独删除的待办，随上级一起删除的子任务不单独列出
+func (r *TodoRepository) GetDeletedTodos(userID uint, page, pageSize int) ([]models.Todo, int64, error) {
+	var todos []models.Todo
+	var total int64
+	deletedParents := r.db.Unscoped().Model(&models.Todo{}).Select("id").Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+	query := r.db.Unscoped().Model(&models.Todo{}).
+		Where("user_id = ? AND deleted_at IS NOT NULL", userID).
+		Where("parent_id IS NULL OR parent_id NOT IN (?)", deletedParents)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("deleted_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&todos).Error
+	return todos, total, err
+}
+
+func (r *TodoRepository) GetDeletedTodoByID(id uint) (*models.Todo, error) {
+	var todo models.Todo
+	err := r.db.Unscoped().Where("deleted_at IS NOT NULL").First(&todo, id).Error
+	return &todo, err
+}
+
+// 与该待办同一批删除的所有下级子任务
+func (r *TodoRepository) GetDeletedDescendants(id uint, deletedAt time.Time) ([]models.Todo, error) {
+	descendants := make([]models.Todo, 0)
+	parentIDs := []uint{id}
+	for len(parentIDs) > 0 {
+		var children []models.Todo
+		if err := r.db.Unscoped().Where("parent_id IN ? AND deleted_at = ?", parentIDs, deletedAt).Find(&children).Error; err != nil {
+			return nil, err
+		}
+		parentIDs = make([]uint, 0, len(children))
+		for _, child := range children {
+			parentIDs = append(parentIDs, child.ID)
+		}
+		descendants = append(descendants, children...)
+	}
+	return descendants, nil
+}
+
+func (r *TodoRepository) RestoreTodos(ids []uint) error {
+	return r.db.Unscoped().Model(&models.Todo{}).Where("id IN ?", ids).Update("deleted_at", nil).Error
+}
+
+// 回收站中删除时间早于 before 的待办，每次最多 limit 条
+func (r *TodoRepository) GetExpiredTodoIDs(before time.Time, limit int) ([]uint, error) {
+	var ids []uint
+	err := r.db.Unscoped().Model(&models.Todo{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// 以下是批量操作相关
+
+// 在一个事务中把多个顶层待办移到清单末尾，按给定顺序排列
+func (r *TodoRepository) MoveTodos(ids []uint, listID *uint, start int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			err := tx.Model(&models.Todo{}).Where("id = ?", id).
+				Updates(map[string]interface{}{"list_id": listID, "position": start + i*models.TodoPositionGap}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 以下是待办学习时间相关
+
+// 累加待办的学习时长和番茄钟，同时记录到每日数据
+func (r *TodoRepository) IncrementTodoStudyData(userID, todoID uint, date time.Time, studyTime, tomatoes int) error {
+	data := models.TodoDailyStudyData{
+		UserID:    userID,
+		TodoID:    todoID,
+		Date:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		StudyTime: studyTime,
+		Tomatoes:  tomatoes,
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "todo_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"study_time": gorm.Expr("study_time + ?", studyTime),
+				"tomatoes":   gorm.Expr("tomatoes + ?", tomatoes),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&data).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Todo{}).Where("id = ?", todoID).Updates(map[string]interface{}{
+			"actual_study_time": gorm.Expr("actual_study_time + ?", studyTime),
+			"actual_tomatoes":   gorm.Expr("actual_tomatoes + ?", tomatoes),
+		}).Error
+	})
+}
+
+// 扣除待办的学习时长和番茄钟，只修改已有的数据且不会减到负数
+func (r *TodoRepository) DecrementTodoStudyData(userID, todoID uint, date time.Time, studyTime, tomatoes int) error {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.TodoDailyStudyData{}).
+			Where("user_id = ? AND todo_id = ? AND date = ?", userID, todoID, day).
+			Updates(map[string]interface{}{
+				"study_time": gorm.Expr("GREATEST(study_time - ?, 0)", studyTime),
+				"tomatoes":   gorm.Expr("GREATEST(tomatoes - ?, 0)", tomatoes),
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Todo{}).Unscoped().Where("id = ?", todoID).Updates(map[string]interface{}{
+			"actual_study_time": gorm.Expr("GREATEST(actual_study_time - ?, 0)", studyTime),
+			"actual_tomatoes":   gorm.Expr("GREATEST(actual_tomatoes - ?, 0)", tomatoes),
+		}).Error
+	})
+}
+
+// 以下是清单内排序相关
+
+// 清单中排在 after 之后的第一个顶层待办的位置，after 为空时返回第一个待办的位置，没有时返回nil
+// listID 为空表示收集箱
+func (r *TodoRepository) TodoPositionAfter(userID uint, listID *uint, after *int, excludeID uint) (*int, error) {
+	var position *int
+	query := r.listQuery(r.db, userID, listID).Where("id <> ?", excludeID)
+	if after != nil {
+		query = query.Where("position > ?", *after)
+	}
+	err := query.Select("MIN(position)").Scan(&position).Error
+	return position, err
+}
+
+// 位置间隔用完时，按当前顺序重新分配清单中所有顶层待办的位置
+func (r *TodoRepository) RebalanceTodoPositions(userID uint, listID *uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var todos []models.Todo
+		if err := r.listQuery(tx, userID, listID).Order("position ASC, id ASC").Find(&todos).Error; err != nil {
+			return err
+		}
+		for i, todo := range todos {
+			if err := tx.Model(&models.Todo{}).Where("id = ?", todo.ID).Update("position", (i+1)*models.TodoPositionGap).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// 移动顶层待办到清单中的指定位置
+func (r *TodoRepository) MoveTodo(id uint, listID *uint, position int) error {
+	return r.db.Model(&models.Todo{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"list_id": listID, "position": position}).Error
+}
+
+func (r *TodoRepository) listQuery(db *gorm.DB, userID uint, listID *uint) *gorm.DB {
+	query := db.Model(&models.Todo{}).Where("user_id = ? AND parent_id IS NULL", userID)
+	if listID == nil {
+		return query.Where("list_id IS NULL")
+	}
+	return query.Where("list_id = ?", *listID)
+}
+
+// 以下是重复待办相关
+
+// 在一个事务中创建重复规则、第一次的待办及其标签
+func (r *TodoRepository) CreateRecurringTodo(recurrence *models.TodoRecurrence, todo *models.Todo, tagNames []string) ([]models.TodoTag, error) {
+	var tags []models.TodoTag
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(recurrence).Error; err != nil {
+			return err
+		}
+		todo.RecurrenceID = &recurrence.ID
+		if err := tx.Create(todo).Error; err != nil {
+			return err
+		}
+		var err error
+		tags, err = tagNewTodo(tx, todo, tagNames)
+		return err
+	})
+	return tags, err
+}
+
+func (r *TodoRepository) GetRecurrenceByID(id uint) (*models.TodoRecurrence, error) {
+	var recurrence models.TodoRecurrence
+	err := r.db.First(&recurrence, id).Error
+	return &recurrence, err
+}
+
+func (r *TodoRepository) UpdateRecurrence(recurrence *models.TodoRecurrence) error {
+	return r.db.Save(recurrence).Error
+}
+
+// 在一个事务中修改重复待办以后的每一次：保存重复规则和待办，彻底删除按旧规则生成的待办
+func (r *TodoRepository) UpdateFutureOccurrences(recurrence *models.TodoRecurrence, todos []models.Todo, purgeIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(purgeIDs) > 0 {
+			if err := purgeTodos(tx, purgeIDs); err != nil {
+				return err
+			}
+		}
+		for i := range todos {
+			if err := tx.Save(&todos[i]).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(recurrence).Error
+	})
+}
+
+func (r *TodoRepository) GetActiveRecurrences() ([]models.TodoRecurrence, error) {
+	var recurrences []models.TodoRecurrence
+	err := r.db.Where("active = ?", true).Find(&recurrences).Error
+	return recurrences, err
+}
+
+// 生成下一次的待办，previous 为规则当前的最近一次时间
+// 规则已被其它请求或实例推进过时不生成，返回false
+func (r *TodoRepository) CreateOccurrence(previous time.Time, todo *models.Todo) (bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TodoRecurrence{}).
+			Where("id = ? AND active = ? AND last_occurrence_at = ?", *todo.RecurrenceID, true, previous).
+			Update("last_occurrence_at", *todo.OccurrenceAt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		created = true
+		return tx.Create(todo).Error
+	})
+	return created, err
+}
+
+// 分页查询重复规则生成过的待办，按时间倒序
+func (r *TodoRepository) GetOccurrences(recurrenceID uint, page, pageSize int) ([]models.Todo, int64, error) {
+	var todos []models.Todo
+	var total int64
+	query := r.db.Model(&models.Todo{}).Where("recurrence_id = ?", recurrenceID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := query.Order("occurrence_at DESC, id DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&todos).Error
+	return todos, total, err
+}
+
+// 从 from 开始（含）还未完成的待办
+func (r *TodoRepository) GetPendingOccurrences(recurrenceID uint, from time.Time) ([]models.Todo, error) {
+	var todos []models.Todo
+	err := r.db.Where("recurrence_id = ? AND completed = ? AND occurrence_at >= ?", recurrenceID, false, from).
+		Order("occurrence_at ASC").Find(&todos).Error
+	return todos, err
+}
@@ -103,15 +103,86 @@ func (r *UserRepository) DeleteUser(userid uint) error {
 		if err := tx.Where("user_id = ?", userid).Delete(&models.TotalStudyData{}).Error; err != nil {
 			return err
 		}
-		if err := tx.Where("user_id = ?", userid).Delete(&models.Todo{}).Error; err != nil {
+		if err := tx.Unscoped().Where("user_id = ?", userid).Delete(&models.Todo{}).Error; err != nil {
 			return err
 		}
-		if err := tx.Where("user_id = ?", userid).Delete(&models.Note{}).Error; err != nil {
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoReminder{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.Notification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.NotificationSetting{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userid).Delete(&models.Note{}).Error; err != nil {
 			return err
 		}
 		if err := tx.Where("uploader_id = ?", userid).Delete(&models.Music{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.ExperienceLog{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.UserAchievement{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR friend_id = ?", userid, userid).Delete(&models.Friendship{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.Subject{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.SubjectDailyStudyData{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.StudyDataCorrection{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.StudyDataFlag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.StudyReport{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.HourlyStudyData{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.MediaPlayStat{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.YearReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.StudySession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoRecurrence{}).Error; err != nil {
+			return err
+		}
+		// 自己创建的共享清单的成员和修改记录一并删除，参与的共享清单中分配给自己的待办取消分配
+		ownedLists := tx.Model(&models.TodoList{}).Select("id").Where("user_id = ?", userid)
+		if err := tx.Where("list_id IN (?) OR user_id = ?", ownedLists, userid).Delete(&models.TodoListMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("list_id IN (?)", ownedLists).Delete(&models.TodoActivity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Todo{}).Where("assignee_id = ?", userid).Update("assignee_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoList{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoTagging{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userid).Delete(&models.TodoDailyStudyData{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Where("id = ?", userid).Delete(&models.User{}).Error; err != nil {
 			return err
 		}
@@ -123,6 +194,14 @@ func (r *UserRepository) DeleteUser(userid uint) error {
 	return nil
 }
 
+// UpdateDailyGoal 更新每日学习目标
+func (r *UserRepository) UpdateDailyGoal(userID uint, dailyGoal int) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("daily_goal", dailyGoal).
+		Error
+}
+
 // UpdateAvatarURL 更新用户头像URL
 func (r *UserRepository) UpdateAvatarURL(userID uint, avatarURL string) error {
 	return r.db.Model(&models.User{}).
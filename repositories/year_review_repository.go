@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"2026-FM247-BackEnd/models"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type YearReviewRepository struct {
+	db    *gorm.DB
+	redis *redis.Client
+	ctx   context.Context
+}
+
+func NewYearReviewRepository(db *gorm.DB, redis *redis.Client) *YearReviewRepository {
+	return &YearReviewRepository{
+		db:    db,
+		redis: redis,
+		ctx:   context.Background(),
+	}
+}
+
+// 保存年度回顾，重新生成时只更新内容，分享链接保持不变
+func (r *YearReviewRepository) SaveYearReview(review *models.YearReview) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "year"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "updated_at"}),
+	}).Create(review).Error
+	if err != nil {
+		return err
+	}
+	return r.db.Where("user_id = ? AND year = ?", review.UserID, review.Year).First(review).Error
+}
+
+// 还没有生成时返回nil
+func (r *YearReviewRepository) GetYearReview(userID uint, year int) (*models.YearReview, error) {
+	var review models.YearReview
+	err := r.db.Where("user_id = ? AND year = ?", userID, year).First(&review).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *YearReviewRepository) GetYearReviewByToken(token string) (*models.YearReview, error) {
+	var review models.YearReview
+	err := r.db.Where("share_token = ?", token).First(&review).Error
+	return &review, err
+}
+
+// 生成任务标记，保证每年的年度回顾只批量生成一次
+func (r *YearReviewRepository) GenerateReviewRunKey(year int) string {
+	return fmt.Sprintf("year_review:run:%d", year)
+}
+
+func (r *YearReviewRepository) ClaimReviewRun(year int) (bool, error) {
+	return r.redis.SetNX(r.ctx, r.GenerateReviewRunKey(year), time.Now().Unix(), 400*24*time.Hour).Result()
+}
+
+func (r *YearReviewRepository) ReleaseReviewRun(year int) error {
+	return r.redis.Del(r.ctx, r.GenerateReviewRunKey(year)).Err()
+}
@@ -16,12 +16,33 @@ func RegisterRoutes(
 	musichandler *handler.MusicHandler,
 	ambientSoundHandler *handler.AmbientSoundHandler,
 	aiChatHandler *handler.AIChatHandler,
+	experienceHandler *handler.ExperienceHandler,
+	achievementHandler *handler.AchievementHandler,
+	leaderboardHandler *handler.LeaderboardHandler,
+	friendHandler *handler.FriendHandler,
+	subjectHandler *handler.SubjectHandler,
+	reconcileHandler *handler.ReconcileHandler,
+	correctionHandler *handler.CorrectionHandler,
+	antiCheatHandler *handler.AntiCheatHandler,
+	reportHandler *handler.ReportHandler,
+	playHandler *handler.PlayHandler,
+	yearReviewHandler *handler.YearReviewHandler,
+	exportHandler *handler.ExportHandler,
+	analyticsHandler *handler.AnalyticsHandler,
+	insightHandler *handler.InsightHandler,
+	todoListHandler *handler.TodoListHandler,
+	notificationHandler *handler.NotificationHandler,
+	noteHandler *handler.NoteHandler,
+	searchHandler *handler.SearchHandler,
 ) {
 	publicGroup := r.Group("/api")
 	{
 		// 用户相关
 		publicGroup.POST("/auth/register", authhandler.RegisterUserHandler)
 		publicGroup.POST("/auth/login", authhandler.LoginHandler)
+
+		// 年度回顾分享链接
+		publicGroup.GET("/share/year-review/:token", yearReviewHandler.GetSharedYearReview)
 	}
 
 	authGroup := r.Group("/api")
@@ -33,7 +54,10 @@ func RegisterRoutes(
 		authGroup.POST("/user/update_info", authhandler.UpdateUserInfoHandler)
 		authGroup.POST("/user/update_email", authhandler.UpdateEmailHandler)
 		authGroup.POST("/user/update_password", authhandler.UpdatePasswordHandler)
+		authGroup.POST("/user/update_goal", authhandler.UpdateDailyGoalHandler)
+		authGroup.POST("/user/update_privacy", leaderboardHandler.UpdatePrivacy)
 		authGroup.GET("/user/info", authhandler.GetUserInfoHandler)
+		authGroup.GET("/user/experience", experienceHandler.GetExperienceLogs)
 
 		authGroup.POST("/user/avatar", avatarHandler.UploadAvatar)
 
@@ -43,18 +67,101 @@ func RegisterRoutes(
 		authGroup.GET("/todos/:id", todohandler.GetTodoByID)
 		authGroup.PUT("/todos/:id", todohandler.UpdateTodo)
 		authGroup.DELETE("/todos/:id", todohandler.DeleteTodo)
+		authGroup.POST("/todos/:id/complete", todohandler.CompleteTodo)
+		authGroup.POST("/todos/:id/reopen", todohandler.ReopenTodo)
+		authGroup.PUT("/todos/:id/subtasks/order", todohandler.ReorderSubtasks)
+		authGroup.GET("/todos/recurrences/:id/history", todohandler.GetRecurrenceHistory)
+		authGroup.PUT("/todos/:id/move", todohandler.MoveTodo)
+		authGroup.PUT("/todos/:id/tags", todohandler.SetTodoTags)
+		authGroup.GET("/todos/trash", todohandler.GetTrash)
+		authGroup.POST("/todos/:id/restore", todohandler.RestoreTodo)
+		authGroup.POST("/todos/batch", todohandler.BatchTodos)
+		authGroup.GET("/todos/assigned", todohandler.GetAssignedTodos)
+		authGroup.PUT("/todos/:id/assignee", todohandler.AssignTodo)
+		authGroup.GET("/todos/:id/reminders", notificationHandler.GetTodoReminders)
+		authGroup.POST("/todos/:id/reminders", notificationHandler.AddTodoReminder)
+		authGroup.DELETE("/todos/reminders/:id", notificationHandler.DeleteTodoReminder)
+
+		// 待办清单和标签
+		authGroup.GET("/todo-lists", todoListHandler.GetTodoLists)
+		authGroup.POST("/todo-lists", todoListHandler.CreateTodoList)
+		authGroup.PUT("/todo-lists/:id", todoListHandler.UpdateTodoList)
+		authGroup.DELETE("/todo-lists/:id", todoListHandler.DeleteTodoList)
+		authGroup.PUT("/todo-lists/:id/move", todoListHandler.MoveTodoList)
+		authGroup.GET("/todo-lists/invites", todoListHandler.GetTodoListInvites)
+		authGroup.POST("/todo-lists/:id/invite", todoListHandler.RespondTodoListInvite)
+		authGroup.GET("/todo-lists/:id/members", todoListHandler.GetTodoListMembers)
+		authGroup.POST("/todo-lists/:id/members", todoListHandler.InviteTodoListMember)
+		authGroup.PUT("/todo-lists/:id/members/:user_id", todoListHandler.UpdateTodoListMember)
+		authGroup.DELETE("/todo-lists/:id/members/:user_id", todoListHandler.RemoveTodoListMember)
+		authGroup.GET("/todo-lists/:id/activities", todoListHandler.GetTodoActivities)
+		authGroup.GET("/todo-tags", todoListHandler.GetTodoTags)
+		authGroup.POST("/todo-tags", todoListHandler.CreateTodoTag)
+		authGroup.PUT("/todo-tags/:id", todoListHandler.RenameTodoTag)
+		authGroup.DELETE("/todo-tags/:id", todoListHandler.DeleteTodoTag)
+
+		// 通知相关
+		authGroup.GET("/notifications", notificationHandler.GetNotifications)
+		authGroup.POST("/notifications/:id/read", notificationHandler.MarkNotificationRead)
+		authGroup.POST("/notifications/read-all", notificationHandler.MarkAllNotificationsRead)
+		authGroup.GET("/notifications/settings", notificationHandler.GetNotificationSetting)
+		authGroup.PUT("/notifications/settings", notificationHandler.UpdateNotificationSetting)
+		authGroup.GET("/notifications/ws", notificationHandler.SubscribeNotifications)
+
+		// 备忘录相关
+		authGroup.GET("/notes", noteHandler.GetNotes)
+		authGroup.POST("/notes", noteHandler.CreateNote)
+		authGroup.GET("/notes/:id", noteHandler.GetNote)
+		authGroup.PUT("/notes/:id", noteHandler.UpdateNote)
+		authGroup.PUT("/notes/:id/pin", noteHandler.PinNote)
+		authGroup.DELETE("/notes/:id", noteHandler.DeleteNote)
+
+		// 搜索备忘录和待办
+		authGroup.GET("/search", searchHandler.Search)
 
 		// 学习数据相关
 		authGroup.POST("/studydata", studydatahandler.AddStudyData)
+		authGroup.POST("/studydata/batch", studydatahandler.AddStudyDataBatch)
+		authGroup.POST("/studydata/sessions", studydatahandler.AddStudySession)
 		authGroup.GET("/studydata/daily", studydatahandler.GetDailyStudyData)
 		authGroup.GET("/studydata/total", studydatahandler.GetTotalStudyData)
 		authGroup.GET("/studydata/weekly", studydatahandler.GetWeekStudyData)
 		authGroup.GET("/studydata/monthly", studydatahandler.GetMonthlyStudyData)
 		authGroup.GET("/studydata/yearly", studydatahandler.GetYearStudyData)
+		authGroup.GET("/studydata/heatmap", studydatahandler.GetHeatmap)
+		authGroup.GET("/studydata/export", exportHandler.ExportStudyData)
+		authGroup.GET("/studydata/analytics", analyticsHandler.GetStudyAnalytics)
+		authGroup.GET("/reports", reportHandler.GetReports)
+		authGroup.GET("/year-review", yearReviewHandler.GetYearReview)
+		authGroup.POST("/year-review/regenerate", yearReviewHandler.RegenerateYearReview)
+		authGroup.POST("/studydata/history", correctionHandler.AddStudyHistory)
+		authGroup.PUT("/studydata/history", correctionHandler.UpdateStudyHistory)
+		authGroup.DELETE("/studydata/history", correctionHandler.RemoveStudyHistory)
+		authGroup.GET("/studydata/corrections", correctionHandler.GetCorrections)
+
+		// 科目相关
+		authGroup.GET("/subjects", subjectHandler.GetSubjects)
+		authGroup.POST("/subjects", subjectHandler.CreateSubject)
+		authGroup.GET("/subjects/stats", subjectHandler.GetSubjectStats)
+		authGroup.PUT("/subjects/:id", subjectHandler.UpdateSubject)
+		authGroup.DELETE("/subjects/:id", subjectHandler.DeleteSubject)
+
+		// 成就相关
+		authGroup.GET("/achievements", achievementHandler.GetAchievements)
+
+		// 排行榜相关
+		authGroup.GET("/leaderboard", leaderboardHandler.GetLeaderboard)
+		authGroup.GET("/leaderboard/me", leaderboardHandler.GetMyRank)
+
+		// 好友相关
+		authGroup.GET("/friends", friendHandler.GetFriends)
+		authGroup.POST("/friends", friendHandler.AddFriend)
+		authGroup.DELETE("/friends/:id", friendHandler.RemoveFriend)
 
 		// 音乐相关
 		authGroup.GET("/music", musichandler.GetAllMusic)
 		authGroup.POST("/music", musichandler.UploadMusic)
+		authGroup.POST("/plays", playHandler.RecordPlay)
 	}
 
 	// 环境音相关
@@ -74,11 +181,24 @@ func RegisterRoutes(
 		aiChatGroup.GET("", aiChatHandler.GetChatHistory)
 	}
 
+	// AI学习周报相关
+	insightGroup := r.Group("/api/insights")
+	insightGroup.Use(middleware.AuthMiddleware(authhandler.Tokenservice))
+	{
+		insightGroup.GET("/weekly", insightHandler.GetWeeklyInsight)
+		insightGroup.POST("/weekly/regenerate", insightHandler.RegenerateWeeklyInsight)
+	}
+
 	// 管理员特有路由
 	adminGroup := r.Group("/api/admin")
 	adminGroup.Use(middleware.AuthMiddleware(authhandler.Tokenservice), middleware.AdminMiddleware())
 	{
 		adminGroup.POST("/music", musichandler.UploadSystemMusic)
+		adminGroup.POST("/leaderboard/rebuild", leaderboardHandler.RebuildLeaderboard)
+		adminGroup.POST("/studydata/reconcile", reconcileHandler.ReconcileStudyData)
+		adminGroup.GET("/studydata/flags", antiCheatHandler.GetStudyDataFlags)
+		adminGroup.POST("/studydata/flags/:id/void", antiCheatHandler.VoidStudyDataFlag)
+		adminGroup.POST("/studydata/flags/:id/dismiss", antiCheatHandler.DismissStudyDataFlag)
 	}
 
 }
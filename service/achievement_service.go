@@ -0,0 +1,210 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"time"
+)
+
+// 成就统计口径
+const (
+	MetricTotalTomatoes     = "total_tomatoes"
+	MetricTotalStudyMinutes = "total_study_minutes"
+	MetricStudyStreak       = "study_streak"
+	MetricGoalStreak        = "goal_streak"
+	MetricNightStudy        = "night_study"
+	MetricCompletedTodos    = "completed_todos"
+)
+
+// 默认成就目录，启动时写入数据库；新增成就只需在此追加或直接插入 achievements 表
+var defaultAchievements = []models.Achievement{
+	{Code: "first_tomato", Name: "第一个番茄", Description: "完成第一个番茄钟", Metric: MetricTotalTomatoes, Threshold: 1, SortOrder: 10},
+	{Code: "tomatoes_100", Name: "番茄农场主", Description: "累计完成100个番茄钟", Metric: MetricTotalTomatoes, Threshold: 100, SortOrder: 20},
+	{Code: "study_10_hours", Name: "初窥门径", Description: "累计学习10小时", Metric: MetricTotalStudyMinutes, Threshold: 10 * 60, SortOrder: 30},
+	{Code: "study_100_hours", Name: "百炼成钢", Description: "累计学习100小时", Metric: MetricTotalStudyMinutes, Threshold: 100 * 60, SortOrder: 40},
+	{Code: "streak_7", Name: "一周不断更", Description: "连续学习7天", Metric: MetricStudyStreak, Threshold: 7, SortOrder: 50},
+	{Code: "streak_30", Name: "月度坚持", Description: "连续学习30天", Metric: MetricStudyStreak, Threshold: 30, SortOrder: 60},
+	{Code: "goal_7", Name: "目标达人", Description: "连续7天完成每日学习目标", Metric: MetricGoalStreak, Threshold: 7, SortOrder: 70},
+	{Code: "night_owl", Name: "夜猫子", Description: "在23点到凌晨5点之间记录学习", Metric: MetricNightStudy, Threshold: 1, SortOrder: 80},
+	{Code: "first_todo", Name: "说到做到", Description: "完成第一个待办事项", Metric: MetricCompletedTodos, Threshold: 1, SortOrder: 90},
+	{Code: "todos_100", Name: "待办清道夫", Description: "累计完成100个待办事项", Metric: MetricCompletedTodos, Threshold: 100, SortOrder: 100},
+}
+
+type AchievementRepository interface {
+	SeedAchievements(achievements []models.Achievement) error
+	GetAllAchievements() ([]models.Achievement, error)
+	GetUserAchievements(userID uint) ([]models.UserAchievement, error)
+	UnlockAchievement(userID uint, code string, unlockedAt time.Time) (bool, error)
+	CountCompletedTodos(userID uint) (int64, error)
+}
+
+type AchievementService struct {
+	repo      AchievementRepository
+	studyRepo StudyDataRepository
+	userRepo  UserRepository
+}
+
+func NewAchievementService(repo AchievementRepository, studyRepo StudyDataRepository, userRepo UserRepository) *AchievementService {
+	return &AchievementService{
+		repo:      repo,
+		studyRepo: studyRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// 写入默认成就目录
+func (s *AchievementService) SeedAchievements() error {
+	return s.repo.SeedAchievements(defaultAchievements)
+}
+
+// 学习数据变化后评估成就，返回本次新解锁的成就
+// studiedAt 为本次记录的每次学习的结束时间，用于判断夜猫子等与时刻相关的成就；补交离线记录时早于 now
+func (s *AchievementService) EvaluateAchievements(userID uint, studiedAt []time.Time, now time.Time) ([]AchievementInfo, error) {
+	metrics, err := s.collectMetrics(userID, now)
+	if err != nil {
+		return nil, err
+	}
+	for _, at := range studiedAt {
+		if hour := at.Hour(); hour >= 23 || hour < 5 {
+			metrics[MetricNightStudy] = 1
+		}
+	}
+	return s.unlockAchievements(userID, metrics, now)
+}
+
+// 完成待办后评估成就，不是学习记录，不计入夜猫子等与学习时刻相关的成就
+func (s *AchievementService) EvaluateTodoAchievements(userID uint, at time.Time) ([]AchievementInfo, error) {
+	metrics, err := s.collectMetrics(userID, at)
+	if err != nil {
+		return nil, err
+	}
+	return s.unlockAchievements(userID, metrics, at)
+}
+
+// 解锁达到阈值的成就，返回本次新解锁的成就
+func (s *AchievementService) unlockAchievements(userID uint, metrics map[string]int, at time.Time) ([]AchievementInfo, error) {
+	achievements, unlockedAt, err := s.loadAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	newlyUnlocked := []AchievementInfo{}
+	for _, achievement := range achievements {
+		if _, ok := unlockedAt[achievement.Code]; ok {
+			continue
+		}
+		if metrics[achievement.Metric] < achievement.Threshold {
+			continue
+		}
+		ok, err := s.repo.UnlockAchievement(userID, achievement.Code, at)
+		if err != nil {
+			return nil, fmt.Errorf("解锁成就失败: %w", err)
+		}
+		if ok {
+			info := newAchievementInfo(achievement, achievement.Threshold)
+			info.Unlocked = true
+			info.UnlockedAt = &at
+			newlyUnlocked = append(newlyUnlocked, info)
+		}
+	}
+	return newlyUnlocked, nil
+}
+
+// 获取全部成就，已解锁的带解锁时间，未解锁的带当前进度
+func (s *AchievementService) GetAchievements(userID uint) ([]AchievementInfo, error) {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	metrics, err := s.collectMetrics(userID, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	achievements, unlockedAt, err := s.loadAchievements(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]AchievementInfo, 0, len(achievements))
+	for _, achievement := range achievements {
+		if t, ok := unlockedAt[achievement.Code]; ok {
+			info := newAchievementInfo(achievement, achievement.Threshold)
+			info.Unlocked = true
+			info.UnlockedAt = &t
+			infos = append(infos, info)
+			continue
+		}
+		infos = append(infos, newAchievementInfo(achievement, min(metrics[achievement.Metric], achievement.Threshold)))
+	}
+	return infos, nil
+}
+
+func (s *AchievementService) loadAchievements(userID uint) ([]models.Achievement, map[string]time.Time, error) {
+	achievements, err := s.repo.GetAllAchievements()
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询成就目录失败: %w", err)
+	}
+	unlocked, err := s.repo.GetUserAchievements(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询已解锁成就失败: %w", err)
+	}
+	unlockedAt := make(map[string]time.Time, len(unlocked))
+	for _, u := range unlocked {
+		unlockedAt[u.AchievementCode] = u.UnlockedAt
+	}
+	return achievements, unlockedAt, nil
+}
+
+// 统计基于学习数据和待办的成就口径
+func (s *AchievementService) collectMetrics(userID uint, now time.Time) (map[string]int, error) {
+	metrics := make(map[string]int)
+
+	total, err, notFound := s.studyRepo.GetTotalStudyData(userID)
+	if err != nil && !notFound {
+		return nil, fmt.Errorf("查询总学习数据失败: %w", err)
+	}
+	if total != nil {
+		metrics[MetricTotalTomatoes] = total.Tomatoes
+		metrics[MetricTotalStudyMinutes] = total.StudyTime
+	}
+
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	dataList, err := s.studyRepo.GetStudyDataSummary(userID, today.AddDate(-1, 0, 0), today)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习记录失败: %w", err)
+	}
+	metrics[MetricStudyStreak] = ongoingStreak(dataList, today, func(data models.DailyStudyData) bool {
+		return data.StudyTime > 0
+	})
+	metrics[MetricGoalStreak] = ongoingStreak(dataList, today, func(data models.DailyStudyData) bool {
+		return user.DailyGoal > 0 && data.StudyTime >= user.DailyGoal
+	})
+
+	completedTodos, err := s.repo.CountCompletedTodos(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询已完成待办失败: %w", err)
+	}
+	metrics[MetricCompletedTodos] = int(completedTodos)
+	return metrics, nil
+}
+
+// 今天还没学习时连续记录不算中断，从昨天开始计算
+func ongoingStreak(dataList []models.DailyStudyData, today time.Time, ok func(data models.DailyStudyData) bool) int {
+	if streak := countStreak(dataList, today, ok); streak > 0 {
+		return streak
+	}
+	return countStreak(dataList, today.AddDate(0, 0, -1), ok)
+}
+
+func newAchievementInfo(achievement models.Achievement, progress int) AchievementInfo {
+	return AchievementInfo{
+		Code:        achievement.Code,
+		Name:        achievement.Name,
+		Description: achievement.Description,
+		Icon:        achievement.Icon,
+		Progress:    progress,
+		Target:      achievement.Threshold,
+	}
+}
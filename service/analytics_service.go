@@ -0,0 +1,116 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 默认统计最近几周
+const DefaultAnalyticsWeeks = 8
+
+type AnalyticsService struct {
+	repo StudyDataRepository
+}
+
+func NewAnalyticsService(repo StudyDataRepository) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// 统计包含本周在内最近 weeks 周的学习分析
+func (s *AnalyticsService) GetStudyAnalytics(userID uint, weeks int, now time.Time) (*StudyAnalyticsInfo, error) {
+	if weeks <= 0 {
+		weeks = DefaultAnalyticsWeeks
+	}
+	monday, _, err := studyPeriodRange("week", now)
+	if err != nil {
+		return nil, err
+	}
+	start := monday.AddDate(0, 0, -7*(weeks-1))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	days, err := s.repo.GetStudyDataSummary(userID, start, today)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	sessions, err := s.repo.GetStudySessions(userID, start, today.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, fmt.Errorf("查询学习记录失败: %w", err)
+	}
+	info := buildStudyAnalytics(start, weeks, days, sessions)
+	info.EndDate = today
+	return &info, nil
+}
+
+// 根据每日数据和学习记录计算分析结果，start 为第一周的周一
+func buildStudyAnalytics(start time.Time, weeks int, days []models.DailyStudyData, sessions []models.StudySession) StudyAnalyticsInfo {
+	loc := start.Location()
+	info := StudyAnalyticsInfo{
+		Weeks:               weeks,
+		StartDate:           start,
+		EndDate:             start.AddDate(0, 0, 7*weeks-1),
+		HourDistribution:    make([]int, 24),
+		WeekdayDistribution: make([]int, 7),
+		WeeklyTrend:         make([]WeeklyTrendPoint, weeks),
+	}
+	for i := range info.WeeklyTrend {
+		info.WeeklyTrend[i].WeekStart = start.AddDate(0, 0, 7*i)
+	}
+	weekIndex := func(t time.Time) int {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return int(math.Floor(day.Sub(start).Hours() / 24 / 7))
+	}
+
+	for _, day := range days {
+		date := day.Date.In(loc)
+		// time.Weekday 以周日为0，这里换成以周一为0
+		info.WeekdayDistribution[(int(date.Weekday())+6)%7] += day.StudyTime
+		if i := weekIndex(date); i >= 0 && i < weeks {
+			info.WeeklyTrend[i].StudyTime += day.StudyTime
+			info.WeeklyTrend[i].Tomatoes += day.Tomatoes
+		}
+	}
+
+	totalMinutes := 0
+	for _, session := range sessions {
+		endedAt := session.EndedAt.In(loc)
+		for _, slice := range splitStudyTimeByHour(endedAt, session.StudyTime) {
+			info.HourDistribution[slice.Start.Hour()] += slice.StudyTime
+		}
+		if i := weekIndex(session.StartedAt.In(loc)); i >= 0 && i < weeks {
+			info.WeeklyTrend[i].Sessions++
+		}
+		totalMinutes += session.StudyTime
+		if session.Abandoned {
+			info.AbandonedSessions++
+		} else {
+			info.CompletedSessions++
+		}
+	}
+	info.SessionCount = len(sessions)
+	if info.SessionCount > 0 {
+		info.AverageSessionMinutes = math.Round(float64(totalMinutes)/float64(info.SessionCount)*10) / 10
+		info.CompletionRate = math.Round(float64(info.CompletedSessions)/float64(info.SessionCount)*1000) / 1000
+	}
+	info.TrendSlope = trendSlope(info.WeeklyTrend)
+	return info
+}
+
+// 以周序号为自变量、每周学习分钟为因变量做最小二乘拟合，返回斜率
+func trendSlope(points []WeeklyTrendPoint) float64 {
+	n := float64(len(points))
+	if n < 2 {
+		return 0
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for i, point := range points {
+		x, y := float64(i), float64(point.StudyTime)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	return math.Round(slope*10) / 10
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBuildStudyAnalytics(t *testing.T) {
+	at := func(d, h, m int) time.Time {
+		return time.Date(2026, 10, d, h, m, 0, 0, time.UTC)
+	}
+	// 10月5日、12日均为周一
+	days := []models.DailyStudyData{
+		{Date: at(5, 0, 0), StudyTime: 60, Tomatoes: 2},
+		{Date: at(11, 0, 0), StudyTime: 30, Tomatoes: 1},
+		{Date: at(13, 0, 0), StudyTime: 150, Tomatoes: 5},
+	}
+	sessions := []models.StudySession{
+		{StartedAt: at(13, 8, 30), EndedAt: at(13, 9, 30), StudyTime: 60, Tomatoes: 2},
+		{StartedAt: at(13, 20, 0), EndedAt: at(13, 21, 30), StudyTime: 90, Tomatoes: 3, Abandoned: true},
+	}
+
+	info := buildStudyAnalytics(at(5, 0, 0), 2, days, sessions)
+
+	assert.Equal(t, 30, info.HourDistribution[8])
+	assert.Equal(t, 30, info.HourDistribution[9])
+	assert.Equal(t, 60, info.HourDistribution[20])
+	assert.Equal(t, 30, info.HourDistribution[21])
+	assert.Equal(t, 60, info.WeekdayDistribution[0])
+	assert.Equal(t, 150, info.WeekdayDistribution[1])
+	assert.Equal(t, 30, info.WeekdayDistribution[6])
+	assert.Equal(t, 2, info.SessionCount)
+	assert.Equal(t, 75.0, info.AverageSessionMinutes)
+	assert.Equal(t, 1, info.CompletedSessions)
+	assert.Equal(t, 1, info.AbandonedSessions)
+	assert.Equal(t, 0.5, info.CompletionRate)
+	assert.Equal(t, 90, info.WeeklyTrend[0].StudyTime)
+	assert.Equal(t, 150, info.WeeklyTrend[1].StudyTime)
+	assert.Equal(t, 2, info.WeeklyTrend[1].Sessions)
+	assert.Equal(t, 60.0, info.TrendSlope)
+}
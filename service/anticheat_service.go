@@ -0,0 +1,180 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 可疑记录状态
+const (
+	FlagStatusPending   = "pending"
+	FlagStatusVoided    = "voided"
+	FlagStatusDismissed = "dismissed"
+)
+
+// 可疑规则
+const (
+	FlagRuleDailyTotal = "daily_total" // 当日学习时长过长
+	FlagRuleOverlap    = "overlap"     // 学习时长超过与上次提交的间隔
+)
+
+type AntiCheatRepository interface {
+	IncrementSubmissionCount(userID uint, at time.Time) (int64, error)
+	SwapLastSubmission(userID uint, at time.Time) (time.Time, bool, error)
+	CreateFlag(flag *models.StudyDataFlag) error
+	GetFlagByID(id uint) (*models.StudyDataFlag, error)
+	GetFlags(status string, page, pageSize int) ([]models.StudyDataFlag, int64, error)
+	UpdateFlagStatus(id uint, fromStatus, toStatus string, reviewedAt time.Time) (bool, error)
+}
+
+// 作废可疑记录时扣除这次提交记录的所有学习数据
+type StudyDataVoider interface {
+	VoidStudyData(flag *models.StudyDataFlag, reason string) error
+}
+
+type AntiCheatService struct {
+	repo   AntiCheatRepository
+	voider StudyDataVoider
+	cfg    *config.StudyDataConfig
+}
+
+func NewAntiCheatService(repo AntiCheatRepository, voider StudyDataVoider, cfg *config.StudyDataConfig) *AntiCheatService {
+	return &AntiCheatService{
+		repo:   repo,
+		voider: voider,
+		cfg:    cfg,
+	}
+}
+
+// 限制每个用户每分钟的提交次数
+func (s *AntiCheatService) CheckRateLimit(userID uint, now time.Time) error {
+	count, err := s.repo.IncrementSubmissionCount(userID, now)
+	if err != nil {
+		return fmt.Errorf("检查提交频率失败: %w", err)
+	}
+	if count > int64(s.cfg.SubmissionsPerMinute) {
+		return errors.New("提交过于频繁，请稍后再试")
+	}
+	return nil
+}
+
+// 校验一次提交，dayStudyTime 为提交前当天已记录的学习时长
+func (s *AntiCheatService) ValidateSubmission(studyTime, tomatoes, dayStudyTime int) error {
+	return validateStudySubmission(s.cfg, studyTime, tomatoes, dayStudyTime)
+}
+
+// 每日学习时长上限
+func (s *AntiCheatService) MaxDayStudyTime() int {
+	return s.cfg.MaxMinutesPerDay
+}
+
+func validateStudySubmission(cfg *config.StudyDataConfig, studyTime, tomatoes, dayStudyTime int) error {
+	if studyTime < 0 || tomatoes < 0 {
+		return errors.New("学习时长和番茄钟数量不能为负数")
+	}
+	if studyTime > cfg.MaxMinutesPerSubmission {
+		return fmt.Errorf("单次提交的学习时长不能超过%d分钟", cfg.MaxMinutesPerSubmission)
+	}
+	if tomatoes*cfg.MinMinutesPerTomato > studyTime {
+		return fmt.Errorf("番茄钟数量与学习时长不符，每个番茄钟至少%d分钟", cfg.MinMinutesPerTomato)
+	}
+	if dayStudyTime+studyTime > cfg.MaxMinutesPerDay {
+		return fmt.Errorf("每日学习时长不能超过%d分钟", cfg.MaxMinutesPerDay)
+	}
+	return nil
+}
+
+// 检查已记录的提交是否可疑，可疑时保存待管理员审核；检查失败只记录日志
+// subjectID、todoID 为提交归属的科目和待办，dayStudyTime 为提交后当天的学习时长
+func (s *AntiCheatService) InspectSubmission(userID, subjectID, todoID uint, date time.Time, studyTime, tomatoes, dayStudyTime int) {
+	var rules, details []string
+	if dayStudyTime > s.cfg.SuspiciousMinutesPerDay {
+		rules = append(rules, FlagRuleDailyTotal)
+		details = append(details, fmt.Sprintf("当日学习%d分钟", dayStudyTime))
+	}
+
+	last, found, err := s.repo.SwapLastSubmission(userID, date)
+	if err != nil {
+		logger.Log.Errorf("记录提交时间失败: user=%d err=%v", userID, err)
+	}
+	// 两次提交之间的学习时长不可能超过间隔本身
+	if found && date.After(last) {
+		gap := int(date.Sub(last).Minutes())
+		if studyTime > gap+s.cfg.OverlapToleranceMinutes {
+			rules = append(rules, FlagRuleOverlap)
+			details = append(details, fmt.Sprintf("距上次提交%d分钟却记录了%d分钟", gap, studyTime))
+		}
+	}
+
+	if len(rules) == 0 {
+		return
+	}
+	flag := &models.StudyDataFlag{
+		UserID:      userID,
+		SubjectID:   subjectID,
+		TodoID:      todoID,
+		Date:        time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location()),
+		SubmittedAt: &date,
+		StudyTime:   studyTime,
+		Tomatoes:    tomatoes,
+		Rules:       strings.Join(rules, ","),
+		Detail:      strings.Join(details, "；"),
+		Status:      FlagStatusPending,
+	}
+	if err := s.repo.CreateFlag(flag); err != nil {
+		logger.Log.Errorf("保存可疑记录失败: user=%d err=%v", userID, err)
+	}
+}
+
+// 分页查询可疑记录
+func (s *AntiCheatService) GetFlags(status string, page, pageSize int) ([]models.StudyDataFlag, int64, error) {
+	flags, total, err := s.repo.GetFlags(status, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询可疑记录失败: %w", err)
+	}
+	return flags, total, nil
+}
+
+// 作废可疑记录，扣除这次提交记录的学习时长和番茄钟
+func (s *AntiCheatService) VoidFlag(flagID uint, now time.Time) error {
+	flag, err := s.repo.GetFlagByID(flagID)
+	if err != nil {
+		return errors.New("可疑记录不存在")
+	}
+	claimed, err := s.repo.UpdateFlagStatus(flagID, FlagStatusPending, FlagStatusVoided, now)
+	if err != nil {
+		return fmt.Errorf("更新可疑记录失败: %w", err)
+	}
+	if !claimed {
+		return errors.New("该记录已审核")
+	}
+	reason := fmt.Sprintf("管理员作废可疑记录#%d", flag.ID)
+	if err := s.voider.VoidStudyData(flag, reason); err != nil {
+		// 扣除失败时恢复为待审核，允许重试
+		if _, rollbackErr := s.repo.UpdateFlagStatus(flagID, FlagStatusVoided, FlagStatusPending, now); rollbackErr != nil {
+			logger.Log.Errorf("恢复可疑记录状态失败: flag=%d err=%v", flagID, rollbackErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// 忽略可疑记录，数据保持不变
+func (s *AntiCheatService) DismissFlag(flagID uint, now time.Time) error {
+	if _, err := s.repo.GetFlagByID(flagID); err != nil {
+		return errors.New("可疑记录不存在")
+	}
+	dismissed, err := s.repo.UpdateFlagStatus(flagID, FlagStatusPending, FlagStatusDismissed, now)
+	if err != nil {
+		return fmt.Errorf("更新可疑记录失败: %w", err)
+	}
+	if !dismissed {
+		return errors.New("该记录已审核")
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestValidateStudySubmission(t *testing.T) {
+	cfg := &config.StudyDataConfig{
+		MaxMinutesPerSubmission: 240,
+		MaxMinutesPerDay:        1200,
+		MinMinutesPerTomato:     15,
+	}
+
+	tests := []struct {
+		name         string
+		studyTime    int
+		tomatoes     int
+		dayStudyTime int
+		wantErr      bool
+	}{
+		{"正常提交", 25, 1, 0, false},
+		{"只有学习时长", 10, 0, 0, false},
+		{"学习时长为负", -10, 0, 0, true},
+		{"番茄钟为负", 30, -1, 0, true},
+		{"超过单次上限", 300, 0, 0, true},
+		{"番茄钟与时长不符", 20, 2, 0, true},
+		{"刚好达到每日上限", 200, 0, 1000, false},
+		{"超过每日上限", 201, 0, 1000, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStudySubmission(cfg, tt.studyTime, tt.tomatoes, tt.dayStudyTime)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
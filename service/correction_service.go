@@ -0,0 +1,223 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type StudyDataCorrectionRepository interface {
+	CorrectDailyStudyData(userID uint, date time.Time, studyTime, tomatoes int, add bool, reason string, maxStudyTime, maxTomatoes int) (*models.StudyDataCorrection, error)
+	GetStudyDataCorrections(userID uint, page, pageSize int) ([]models.StudyDataCorrection, int64, error)
+	IncrementHourlyStudyData(userID uint, year, hour, studyTime int) error
+	DeleteStudySession(userID uint, endedAt time.Time, studyTime int) error
+	LockStudyData(userID uint, ttl time.Duration) (bool, error)
+	UnlockStudyData(userID uint) error
+}
+
+// 修改学习数据时锁的过期时间，防止进程退出后一直锁住
+const correctionLockTTL = 30 * time.Second
+
+// 作废提交时扣除待办上记录的学习数据
+type TodoStudyReverter interface {
+	RevokeTodoStudy(userID, todoID uint, date time.Time, studyTime, tomatoes int) error
+}
+
+// 作废提交时扣除科目上记录的学习数据
+type SubjectStudyReverter interface {
+	RevokeSubjectStudy(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error
+}
+
+type CorrectionService struct {
+	repo        StudyDataCorrectionRepository
+	flusher     StudyDataFlush
+	leaderboard LeaderboardRecorder
+	todos       TodoStudyReverter
+	subjects    SubjectStudyReverter
+	cfg         *config.StudyDataConfig
+}
+
+func NewCorrectionService(repo StudyDataCorrectionRepository, flusher StudyDataFlush, leaderboard LeaderboardRecorder, todos TodoStudyReverter, subjects SubjectStudyReverter, cfg *config.StudyDataConfig) *CorrectionService {
+	return &CorrectionService{
+		repo:        repo,
+		flusher:     flusher,
+		leaderboard: leaderboard,
+		todos:       todos,
+		subjects:    subjects,
+		cfg:         cfg,
+	}
+}
+
+// 补录过去某天的学习数据，在原值基础上增加
+func (s *CorrectionService) AddHistoryStudyData(userID uint, date time.Time, studyTime, tomatoes int, reason string, now time.Time) (*StudyDataCorrectionInfo, error) {
+	return s.correct(userID, date, studyTime, tomatoes, true, reason, now)
+}
+
+// 把过去某天的学习数据改为指定值
+func (s *CorrectionService) SetHistoryStudyData(userID uint, date time.Time, studyTime, tomatoes int, reason string, now time.Time) (*StudyDataCorrectionInfo, error) {
+	return s.correct(userID, date, studyTime, tomatoes, false, reason, now)
+}
+
+// 清空过去某天的学习数据
+func (s *CorrectionService) RemoveHistoryStudyData(userID uint, date time.Time, reason string, now time.Time) (*StudyDataCorrectionInfo, error) {
+	return s.correct(userID, date, 0, 0, false, reason, now)
+}
+
+// 只能修改今天之前、CorrectionWindowDays 天以内的数据
+// 补录时每次补录的时长受单次提交上限限制，修改后当天的数据受每日上限限制，与正常提交一致
+// 修改的是整天的数据，无法对应到具体待办，因此不调整按待办拆分的学习数据
+func (s *CorrectionService) correct(userID uint, date time.Time, studyTime, tomatoes int, add bool, reason string, now time.Time) (*StudyDataCorrectionInfo, error) {
+	if err := validateCorrection(s.cfg, studyTime, tomatoes, add); err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		return nil, errors.New("修改原因不能为空")
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, now.Location())
+	if !day.Before(today) {
+		return nil, errors.New("只能修改今天之前的学习数据")
+	}
+	if day.Before(today.AddDate(0, 0, -s.cfg.CorrectionWindowDays)) {
+		return nil, fmt.Errorf("只能修改最近%d天的学习数据", s.cfg.CorrectionWindowDays)
+	}
+
+	var correction *models.StudyDataCorrection
+	err := s.withStudyDataLock(userID, func() error {
+		var err error
+		correction, err = s.repo.CorrectDailyStudyData(userID, day, studyTime, tomatoes, add, reason, s.cfg.MaxMinutesPerDay, maxTomatoesPerDay(s.cfg))
+		if err != nil {
+			return fmt.Errorf("修改学习数据失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 排行榜可通过重建恢复，更新失败不影响本次修改
+	if err := s.leaderboard.RecordStudyTime(userID, day, correction.NewStudyTime-correction.OldStudyTime); err != nil {
+		logger.Log.Errorf("更新排行榜失败: user=%d err=%v", userID, err)
+	}
+	info := newCorrectionInfo(*correction)
+	return &info, nil
+}
+
+// 从某天的数据中扣除一次可疑提交，不受修改窗口限制，供管理员作废可疑记录使用
+// 同时扣除这次提交在待办、科目、分时段统计上记录的数据，并删除对应的学习记录
+func (s *CorrectionService) VoidStudyData(flag *models.StudyDataFlag, reason string) error {
+	var correction *models.StudyDataCorrection
+	err := s.withStudyDataLock(flag.UserID, func() error {
+		var err error
+		correction, err = s.repo.CorrectDailyStudyData(flag.UserID, flag.Date, -flag.StudyTime, -flag.Tomatoes, true, reason, 0, 0)
+		if err != nil {
+			return fmt.Errorf("扣除学习数据失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.leaderboard.RecordStudyTime(flag.UserID, flag.Date, correction.NewStudyTime-correction.OldStudyTime); err != nil {
+		logger.Log.Errorf("更新排行榜失败: user=%d err=%v", flag.UserID, err)
+	}
+	// 当天数据已扣除，其余数据扣除失败只记录日志，避免重试时重复扣除
+	if flag.TodoID != 0 {
+		if err := s.todos.RevokeTodoStudy(flag.UserID, flag.TodoID, flag.Date, flag.StudyTime, flag.Tomatoes); err != nil {
+			logger.Log.Errorf("扣除待办学习数据失败: user=%d todo=%d err=%v", flag.UserID, flag.TodoID, err)
+		}
+	}
+	if flag.SubjectID != 0 {
+		if err := s.subjects.RevokeSubjectStudy(flag.UserID, flag.SubjectID, flag.Date, flag.StudyTime, flag.Tomatoes); err != nil {
+			logger.Log.Errorf("扣除科目学习数据失败: user=%d subject=%d err=%v", flag.UserID, flag.SubjectID, err)
+		}
+	}
+	// 较早的可疑记录没有保存提交时间，无法定位分时段数据和学习记录
+	if flag.SubmittedAt == nil {
+		return nil
+	}
+	for _, slice := range splitStudyTimeByHour(*flag.SubmittedAt, flag.StudyTime) {
+		if err := s.repo.IncrementHourlyStudyData(flag.UserID, slice.Start.Year(), slice.Start.Hour(), -slice.StudyTime); err != nil {
+			logger.Log.Errorf("扣除分时段学习数据失败: user=%d err=%v", flag.UserID, err)
+		}
+	}
+	if err := s.repo.DeleteStudySession(flag.UserID, *flag.SubmittedAt, flag.StudyTime); err != nil {
+		logger.Log.Errorf("删除学习记录失败: user=%d err=%v", flag.UserID, err)
+	}
+	return nil
+}
+
+// 修改以mysql为准：锁住该用户的学习数据后先把redis中未落库的数据写入mysql，再执行修改
+// 修改完成后缓存已删除才解锁，期间的提交会等待，不会在落库和删除缓存之间丢失
+func (s *CorrectionService) withStudyDataLock(userID uint, correct func() error) error {
+	locked, err := s.repo.LockStudyData(userID, correctionLockTTL)
+	if err != nil {
+		return fmt.Errorf("锁定学习数据失败: %w", err)
+	}
+	if !locked {
+		return errors.New("学习数据正在修改，请稍后再试")
+	}
+	defer func() {
+		if err := s.repo.UnlockStudyData(userID); err != nil {
+			logger.Log.Errorf("解锁学习数据失败: user=%d err=%v", userID, err)
+		}
+	}()
+	if err := s.flusher.Flush(); err != nil {
+		return fmt.Errorf("学习数据落库失败: %w", err)
+	}
+	return correct()
+}
+
+// 分页查询学习数据修改记录
+func (s *CorrectionService) GetCorrections(userID uint, page, pageSize int) ([]StudyDataCorrectionInfo, int64, error) {
+	corrections, total, err := s.repo.GetStudyDataCorrections(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询修改记录失败: %w", err)
+	}
+	infos := make([]StudyDataCorrectionInfo, 0, len(corrections))
+	for _, correction := range corrections {
+		infos = append(infos, newCorrectionInfo(correction))
+	}
+	return infos, total, nil
+}
+
+// 校验补录或修改的数值，补录时与单次提交的规则一致，修改为指定值时不能超过每日上限
+func validateCorrection(cfg *config.StudyDataConfig, studyTime, tomatoes int, add bool) error {
+	if studyTime < 0 || tomatoes < 0 {
+		return errors.New("学习时长和番茄钟数量不能为负数")
+	}
+	if tomatoes*cfg.MinMinutesPerTomato > studyTime {
+		return fmt.Errorf("番茄钟数量与学习时长不符，每个番茄钟至少%d分钟", cfg.MinMinutesPerTomato)
+	}
+	if add && studyTime > cfg.MaxMinutesPerSubmission {
+		return fmt.Errorf("每次补录的学习时长不能超过%d分钟", cfg.MaxMinutesPerSubmission)
+	}
+	if studyTime > cfg.MaxMinutesPerDay {
+		return fmt.Errorf("每日学习时长不能超过%d分钟", cfg.MaxMinutesPerDay)
+	}
+	return nil
+}
+
+// 每日番茄钟数量上限，由每日学习时长上限和每个番茄钟的最短时长推算
+func maxTomatoesPerDay(cfg *config.StudyDataConfig) int {
+	if cfg.MinMinutesPerTomato <= 0 {
+		return 0
+	}
+	return cfg.MaxMinutesPerDay / cfg.MinMinutesPerTomato
+}
+
+func newCorrectionInfo(correction models.StudyDataCorrection) StudyDataCorrectionInfo {
+	return StudyDataCorrectionInfo{
+		ID:           correction.ID,
+		Date:         correction.Date,
+		OldStudyTime: correction.OldStudyTime,
+		NewStudyTime: correction.NewStudyTime,
+		OldTomatoes:  correction.OldTomatoes,
+		NewTomatoes:  correction.NewTomatoes,
+		Reason:       correction.Reason,
+		CreatedAt:    correction.CreatedAt,
+	}
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestValidateCorrection(t *testing.T) {
+	cfg := &config.StudyDataConfig{
+		MaxMinutesPerSubmission: 240,
+		MaxMinutesPerDay:        1200,
+		MinMinutesPerTomato:     15,
+	}
+
+	tests := []struct {
+		name      string
+		studyTime int
+		tomatoes  int
+		add       bool
+		wantErr   bool
+	}{
+		{"补录", 120, 4, true, false},
+		{"补录超过单次上限", 241, 0, true, true},
+		{"修改为超过单次上限的值", 600, 20, false, false},
+		{"修改为超过每日上限的值", 1201, 0, false, true},
+		{"番茄钟与时长不符", 30, 3, false, true},
+		{"学习时长为负", -1, 0, true, true},
+		{"清空", 0, 0, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCorrection(cfg, tt.studyTime, tt.tomatoes, tt.add)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+	assert.Equal(t, 80, maxTomatoesPerDay(cfg))
+}
+
+// 记录作废时对各项数据的扣除
+type memoryVoidRepository struct {
+	StudyDataCorrectionRepository
+	daily    int
+	hourly   map[int]int
+	sessions []models.StudySession
+	locked   bool
+}
+
+func (r *memoryVoidRepository) CorrectDailyStudyData(userID uint, date time.Time, studyTime, tomatoes int, add bool, reason string, maxStudyTime, maxTomatoes int) (*models.StudyDataCorrection, error) {
+	old := r.daily
+	r.daily = max(r.daily+studyTime, 0)
+	return &models.StudyDataCorrection{OldStudyTime: old, NewStudyTime: r.daily}, nil
+}
+
+func (r *memoryVoidRepository) IncrementHourlyStudyData(userID uint, year, hour, studyTime int) error {
+	r.hourly[hour] += studyTime
+	return nil
+}
+
+func (r *memoryVoidRepository) DeleteStudySession(userID uint, endedAt time.Time, studyTime int) error {
+	for i, session := range r.sessions {
+		if session.UserID == userID && session.EndedAt.Equal(endedAt) && session.StudyTime == studyTime {
+			r.sessions = append(r.sessions[:i], r.sessions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryVoidRepository) LockStudyData(userID uint, ttl time.Duration) (bool, error) {
+	if r.locked {
+		return false, nil
+	}
+	r.locked = true
+	return true, nil
+}
+
+func (r *memoryVoidRepository) UnlockStudyData(userID uint) error {
+	r.locked = false
+	return nil
+}
+
+type memoryStudyReverter map[uint]int
+
+func (r memoryStudyReverter) RevokeTodoStudy(userID, todoID uint, date time.Time, studyTime, tomatoes int) error {
+	r[todoID] -= studyTime
+	return nil
+}
+
+func (r memoryStudyReverter) RevokeSubjectStudy(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error {
+	r[subjectID] -= studyTime
+	return nil
+}
+
+func (r memoryStudyReverter) RecordStudyTime(userID uint, date time.Time, studyTime int) error {
+	r[0] += studyTime
+	return nil
+}
+
+func (r memoryStudyReverter) Flush() error {
+	return nil
+}
+
+func TestVoidStudyData(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	endedAt := time.Date(2026, 10, 19, 10, 30, 0, 0, time.UTC)
+	repo := &memoryVoidRepository{
+		daily:  200,
+		hourly: map[int]int{8: 30, 9: 60, 10: 30},
+		sessions: []models.StudySession{
+			{ID: 1, UserID: 1, StartedAt: endedAt.Add(-2 * time.Hour), EndedAt: endedAt, StudyTime: 120},
+			{ID: 2, UserID: 1, StartedAt: endedAt.Add(-4 * time.Hour), EndedAt: endedAt.Add(-3 * time.Hour), StudyTime: 60},
+		},
+	}
+	todos := memoryStudyReverter{5: 120}
+	subjects := memoryStudyReverter{7: 120}
+	leaderboard := memoryStudyReverter{}
+	service := NewCorrectionService(repo, leaderboard, leaderboard, todos, subjects, &config.StudyDataConfig{})
+
+	// 作废时扣除当天、待办、科目、分时段数据，并删除对应的学习记录
+	flag := &models.StudyDataFlag{
+		UserID:      1,
+		SubjectID:   7,
+		TodoID:      5,
+		Date:        time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		SubmittedAt: &endedAt,
+		StudyTime:   120,
+	}
+	assert.Equal(t, nil, service.VoidStudyData(flag, "作废"))
+	assert.Equal(t, 80, repo.daily)
+	assert.Equal(t, -120, leaderboard[0])
+	assert.Equal(t, 0, todos[5])
+	assert.Equal(t, 0, subjects[7])
+	assert.Equal(t, map[int]int{8: 0, 9: 0, 10: 0}, repo.hourly)
+	assert.Equal(t, 1, len(repo.sessions))
+	assert.Equal(t, uint(2), repo.sessions[0].ID)
+
+	assert.Equal(t, false, repo.locked)
+
+	// 正在修改该用户的学习数据时不能同时作废
+	repo.locked = true
+	assert.NotEqual(t, nil, service.VoidStudyData(flag, "作废"))
+	assert.Equal(t, 80, repo.daily)
+	repo.locked = false
+
+	// 没有提交时间的旧记录只扣除当天、待办和科目数据
+	flag.SubmittedAt = nil
+	flag.StudyTime = 60
+	assert.Equal(t, nil, service.VoidStudyData(flag, "作废"))
+	assert.Equal(t, 20, repo.daily)
+	assert.Equal(t, 0, repo.hourly[9])
+	assert.Equal(t, 1, len(repo.sessions))
+}
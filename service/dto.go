@@ -12,27 +12,130 @@ type UserInfo struct {
 	Avatar     string    `json:"avatarpath"`
 	Experience int       `json:"experience"`
 	Level      int       `json:"level"`
+	DailyGoal  int       `json:"dailygoal"`
 	CreatedAt  time.Time `json:"createdat"`
+
+	LeaderboardOptOut bool `json:"leaderboardoptout"`
 }
 
 // 待办事项dto
 type TodoInfo struct {
-	ID    uint   `json:"id"`
-	Event string `json:"event"`
+	ID                uint          `json:"id"`
+	Event             string        `json:"event"`
+	Description       string        `json:"description"`
+	Priority          int           `json:"priority"`
+	DueAt             *time.Time    `json:"dueat"`
+	EstimatedTomatoes int           `json:"estimatedtomatoes"`
+	ActualStudyTime   int           `json:"actualstudytime"`            // 实际学习的分钟数
+	ActualTomatoes    int           `json:"actualtomatoes"`             // 实际完成的番茄钟数量
+	TomatoAttainment  *float64      `json:"tomatoattainment,omitempty"` // 实际番茄钟占预计的百分比，没有预计时不返回
+	Completed         bool          `json:"completed"`
+	CompletedAt       *time.Time    `json:"completedat"`
+	CreatedAt         time.Time     `json:"createdat"`
+	DeletedAt         *time.Time    `json:"deletedat,omitempty"` // 仅回收站中返回
+	ParentID          *uint         `json:"parentid,omitempty"`
+	ListID            *uint         `json:"listid"` // 为空表示收集箱
+	Position          int           `json:"position"`
+	Tags              []TodoTagInfo `json:"tags"`
+	RecurrenceID      *uint         `json:"recurrenceid,omitempty"`
+	OccurrenceAt      *time.Time    `json:"occurrenceat,omitempty"`
+	AssigneeID        *uint         `json:"assigneeid"`
+	CreatedBy         uint          `json:"createdby"`
+	UpdatedBy         uint          `json:"updatedby"`
+	Progress          *TodoProgress `json:"progress,omitempty"` // 有子任务时返回
+	Subtasks          []TodoInfo    `json:"subtasks,omitempty"` // 仅在详情中返回
+}
+
+// 批量操作中单个待办的结果
+type TodoBatchResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// 待办清单dto，Role 为当前用户在清单中的角色
+type TodoListInfo struct {
+	ID      uint   `json:"id"`
+	Name    string `json:"name"`
+	Color   string `json:"color"`
+	OwnerID uint   `json:"ownerid"`
+	Role    string `json:"role"` // owner / editor / viewer
+}
+
+// 共享清单成员dto，创建者的 Role 为 owner
+type TodoListMemberInfo struct {
+	UserID    uint      `json:"userid"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"` // pending 已邀请 / accepted 已加入
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// 收到的共享清单邀请
+type TodoListInviteInfo struct {
+	ListID      uint      `json:"listid"`
+	ListName    string    `json:"listname"`
+	Role        string    `json:"role"`
+	InvitedBy   uint      `json:"invitedby"`
+	InviterName string    `json:"invitername"`
+	InvitedAt   time.Time `json:"invitedat"`
+}
+
+// 清单中待办的修改记录
+type TodoActivityInfo struct {
+	ID        uint      `json:"id"`
+	TodoID    uint      `json:"todoid"`
+	UserID    uint      `json:"userid"`
+	Username  string    `json:"username"`
+	Action    string    `json:"action"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// 待办标签dto
+type TodoTagInfo struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// 重复规则dto
+type TodoRecurrenceInfo struct {
+	ID               uint      `json:"id"`
+	RRule            string    `json:"rrule"`
+	StartAt          time.Time `json:"startat"`
+	LastOccurrenceAt time.Time `json:"lastoccurrenceat"`
+	Active           bool      `json:"active"`
 }
 
-// 每日学习数据dto
+// 子任务完成进度，统计所有层级的子任务
+type TodoProgress struct {
+	Completed int     `json:"completed"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+}
+
+// 每日学习数据dto，Breakdown 仅在请求科目拆分时返回
 type DailyStudyDataInfo struct {
-	Date      time.Time `json:"date"`
-	StudyTime int       `json:"studytime"`
-	Tomatoes  int       `json:"tomatoes"`
+	Date      time.Time          `json:"date"`
+	StudyTime int                `json:"studytime"`
+	Tomatoes  int                `json:"tomatoes"`
+	Breakdown []SubjectStudyInfo `json:"breakdown,omitempty"`
 }
 
-// 每月学习数据dto
+// 每月学习数据dto，Breakdown 仅在请求科目拆分时返回
 type MonthlyStudyDataInfo struct {
-	Date      time.Time `json:"date"`
-	StudyTime int       `json:"studytime"`
-	Tomatoes  int       `json:"tomatoes"`
+	Date      time.Time          `json:"date"`
+	StudyTime int                `json:"studytime"`
+	Tomatoes  int                `json:"tomatoes"`
+	Breakdown []SubjectStudyInfo `json:"breakdown,omitempty"`
+}
+
+// 学习数据提交结果dto，Status 为 applied / duplicate / failed
+type StudySubmissionResult struct {
+	IdempotencyKey string    `json:"idempotencykey"`
+	Date           time.Time `json:"date"`
+	Status         string    `json:"status"`
+	Message        string    `json:"message"`
 }
 
 // 总学习数据dto
@@ -54,3 +157,287 @@ type AmbientSoundInfo struct {
 	Name string `json:"name"`
 	URL  string `json:"url"`
 }
+
+// 单次经验发放dto
+type ExperienceAwardInfo struct {
+	Source string `json:"source"`
+	Amount int    `json:"amount"`
+}
+
+// 经验发放结果dto，LevelUp 为 true 时前端可展示升级动画
+type ExperienceResult struct {
+	Gained              int                   `json:"experiencegained"`
+	Experience          int                   `json:"experience"`
+	Level               int                   `json:"level"`
+	PreviousLevel       int                   `json:"previouslevel"`
+	LevelUp             bool                  `json:"levelup"`
+	NextLevelExperience int                   `json:"nextlevelexperience"`
+	Awards              []ExperienceAwardInfo `json:"awards"`
+}
+
+// 经验流水dto
+type ExperienceLogInfo struct {
+	Source    string    `json:"source"`
+	Amount    int       `json:"amount"`
+	Date      time.Time `json:"date"`
+	CreatedAt time.Time `json:"createdat"`
+}
+
+// 成就信息dto，未解锁时 Progress 表示当前进度
+type AchievementInfo struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlockedat"`
+	Progress    int        `json:"progress"`
+	Target      int        `json:"target"`
+}
+
+// 排行榜条目dto
+type LeaderboardEntry struct {
+	Rank      int64  `json:"rank"`
+	UserID    uint   `json:"userid"`
+	Username  string `json:"username"`
+	Avatar    string `json:"avatarpath"`
+	Level     int    `json:"level"`
+	StudyTime int    `json:"studytime"`
+}
+
+// 排行榜dto，Me 为当前用户的名次，不在榜上时为 null
+type LeaderboardInfo struct {
+	Period  string             `json:"period"`
+	Scope   string             `json:"scope"`
+	Entries []LeaderboardEntry `json:"entries"`
+	Me      *LeaderboardEntry  `json:"me"`
+}
+
+// 好友信息dto
+type FriendInfo struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Avatar   string `json:"avatarpath"`
+	Level    int    `json:"level"`
+}
+
+// 科目信息dto
+type SubjectInfo struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// 科目学习数据dto，SubjectID 为0表示未分类
+type SubjectStudyInfo struct {
+	SubjectID uint   `json:"subjectid"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+	StudyTime int    `json:"studytime"`
+	Tomatoes  int    `json:"tomatoes"`
+}
+
+// 科目统计dto
+type SubjectStatsInfo struct {
+	Range     string             `json:"range"`
+	StartDate time.Time          `json:"startdate"`
+	EndDate   time.Time          `json:"enddate"`
+	StudyTime int                `json:"studytime"`
+	Tomatoes  int                `json:"tomatoes"`
+	Subjects  []SubjectStudyInfo `json:"subjects"`
+}
+
+// 学习数据对账差异dto，Period 为空表示总数据
+type StudyDataDiscrepancy struct {
+	UserID            uint   `json:"userid"`
+	Source            string `json:"source"`
+	Period            string `json:"period,omitempty"`
+	ExpectedStudyTime int    `json:"expectedstudytime"`
+	ExpectedTomatoes  int    `json:"expectedtomatoes"`
+	ActualStudyTime   int    `json:"actualstudytime"`
+	ActualTomatoes    int    `json:"actualtomatoes"`
+}
+
+// 学习数据对账报告dto
+type ReconcileReport struct {
+	CheckedUsers  int                    `json:"checkedusers"`
+	Repaired      bool                   `json:"repaired"`
+	RepairedUsers int                    `json:"repairedusers"`
+	Discrepancies []StudyDataDiscrepancy `json:"discrepancies"`
+}
+
+// 学习数据修改记录dto
+type StudyDataCorrectionInfo struct {
+	ID           uint      `json:"id"`
+	Date         time.Time `json:"date"`
+	OldStudyTime int       `json:"oldstudytime"`
+	NewStudyTime int       `json:"newstudytime"`
+	OldTomatoes  int       `json:"oldtomatoes"`
+	NewTomatoes  int       `json:"newtomatoes"`
+	Reason       string    `json:"reason"`
+	CreatedAt    time.Time `json:"createdat"`
+}
+
+// 热力图每日数据dto，Level 为 0~4 的热力等级
+type HeatmapDay struct {
+	Date      string `json:"date"`
+	StudyTime int    `json:"studytime"`
+	Level     int    `json:"level"`
+}
+
+// 年度热力图dto
+type HeatmapInfo struct {
+	Year           int          `json:"year"`
+	TotalStudyTime int          `json:"totalstudytime"`
+	ActiveDays     int          `json:"activedays"`
+	Days           []HeatmapDay `json:"days"`
+}
+
+// 学习报告dto，ChangePercent 为相对上一周期的变化百分比，上一周期没有学习时为空
+type StudyReportInfo struct {
+	ID                uint            `json:"id"`
+	Type              string          `json:"type"`
+	PeriodStart       time.Time       `json:"periodstart"`
+	PeriodEnd         time.Time       `json:"periodend"`
+	StudyTime         int             `json:"studytime"`
+	Tomatoes          int             `json:"tomatoes"`
+	ActiveDays        int             `json:"activedays"`
+	DailyAverage      float64         `json:"dailyaverage"`
+	BestDay           *time.Time      `json:"bestday"`
+	BestDayStudyTime  int             `json:"bestdaystudytime"`
+	PreviousStudyTime int             `json:"previousstudytime"`
+	ChangePercent     *float64        `json:"changepercent"`
+	DailyGoal         int             `json:"dailygoal"`
+	GoalDays          int             `json:"goaldays"`
+	GoalAttainment    float64         `json:"goalattainment"` // 达成目标天数占比，百分比
+	Streak            int             `json:"streak"`
+	Todos             []TodoStudyInfo `json:"todos"` // 学习时间最多的待办
+	CreatedAt         time.Time       `json:"createdat"`
+}
+
+// 报告中单个待办的学习数据dto
+type TodoStudyInfo struct {
+	TodoID            uint     `json:"todoid"`
+	Event             string   `json:"event"`
+	StudyTime         int      `json:"studytime"`
+	Tomatoes          int      `json:"tomatoes"`
+	EstimatedTomatoes int      `json:"estimatedtomatoes"`
+	TomatoAttainment  *float64 `json:"tomatoattainment,omitempty"`
+}
+
+// 播放统计dto
+type MediaPlayInfo struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	PlayCount   int    `json:"playcount"`
+	PlaySeconds int    `json:"playseconds"`
+}
+
+// 年度回顾中的成就dto
+type YearReviewAchievement struct {
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Icon       string    `json:"icon"`
+	UnlockedAt time.Time `json:"unlockedat"`
+}
+
+// 年度回顾dto，MostProductiveHour 为学习时长最多的小时（0~23），没有数据时为空
+type YearReviewInfo struct {
+	Year                   int                     `json:"year"`
+	Username               string                  `json:"username"`
+	TotalStudyTime         int                     `json:"totalstudytime"`
+	TotalHours             float64                 `json:"totalhours"`
+	TotalTomatoes          int                     `json:"totaltomatoes"`
+	ActiveDays             int                     `json:"activedays"`
+	BusiestMonth           int                     `json:"busiestmonth"`
+	BusiestMonthStudyTime  int                     `json:"busiestmonthstudytime"`
+	LongestStreak          int                     `json:"longeststreak"`
+	MostProductiveHour     *int                    `json:"mostproductivehour"`
+	FavouriteMusic         []MediaPlayInfo         `json:"favouritemusic"`
+	FavouriteAmbientSounds []MediaPlayInfo         `json:"favouriteambientsounds"`
+	Achievements           []YearReviewAchievement `json:"achievements"`
+	ShareToken             string                  `json:"sharetoken,omitempty"`
+	GeneratedAt            time.Time               `json:"generatedat"`
+}
+
+// 每周学习趋势
+type WeeklyTrendPoint struct {
+	WeekStart time.Time `json:"weekstart"`
+	StudyTime int       `json:"studytime"`
+	Tomatoes  int       `json:"tomatoes"`
+	Sessions  int       `json:"sessions"`
+}
+
+// 学习分析dto，分时段分布和番茄钟完成率来自学习记录，按星期分布和趋势来自每日数据
+type StudyAnalyticsInfo struct {
+	Weeks                 int                `json:"weeks"`
+	StartDate             time.Time          `json:"startdate"`
+	EndDate               time.Time          `json:"enddate"`
+	HourDistribution      []int              `json:"hourdistribution"`    // 下标为0~23点，值为学习分钟数
+	WeekdayDistribution   []int              `json:"weekdaydistribution"` // 下标0为周一，值为学习分钟数
+	SessionCount          int                `json:"sessioncount"`
+	AverageSessionMinutes float64            `json:"averagesessionminutes"`
+	CompletedSessions     int                `json:"completedsessions"`
+	AbandonedSessions     int                `json:"abandonedsessions"`
+	CompletionRate        float64            `json:"completionrate"` // 没有学习记录时为0
+	WeeklyTrend           []WeeklyTrendPoint `json:"weeklytrend"`
+	TrendSlope            float64            `json:"trendslope"` // 最小二乘拟合的每周学习分钟变化量
+}
+
+// AI学习周报dto
+type StudyInsightInfo struct {
+	WeekStart   time.Time `json:"weekstart"`
+	Content     string    `json:"content"`
+	GeneratedAt time.Time `json:"generatedat"`
+}
+
+// 待办提醒dto
+type TodoReminderInfo struct {
+	ID            uint       `json:"id"`
+	TodoID        uint       `json:"todoid"`
+	OffsetMinutes int        `json:"offsetminutes"` // 截止时间前多少分钟提醒
+	RemindAt      time.Time  `json:"remindat"`
+	Status        string     `json:"status"`
+	SentAt        *time.Time `json:"sentat"`
+}
+
+// 站内信dto
+type NotificationInfo struct {
+	ID        uint       `json:"id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	TodoID    *uint      `json:"todoid"`
+	IsRead    bool       `json:"isread"`
+	ReadAt    *time.Time `json:"readat"`
+	CreatedAt time.Time  `json:"createdat"`
+}
+
+// 通知设置dto，EmailAvailable 表示服务器是否配置了邮件发送
+type NotificationSettingInfo struct {
+	EmailEnabled   bool   `json:"emailenabled"`
+	PushEnabled    bool   `json:"pushenabled"`
+	QuietStart     string `json:"quietstart"`
+	QuietEnd       string `json:"quietend"`
+	EmailAvailable bool   `json:"emailavailable"`
+}
+
+// 备忘录dto，content 为markdown原文
+type NoteInfo struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Date      time.Time `json:"date"`
+	Content   string    `json:"content"`
+	Pinned    bool      `json:"pinned"`
+	CreatedAt time.Time `json:"createdat"`
+	UpdatedAt time.Time `json:"updatedat"`
+}
+
+// 搜索结果dto，title 和 snippet 中关键词用 <em> 标出，其余内容已做HTML转义
+type SearchResultInfo struct {
+	Type      string    `json:"type"` // note 备忘录 / todo 待办
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet"`
+	UpdatedAt time.Time `json:"updatedat"`
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 经验来源
+const (
+	ExperienceSourceStudyTime = "study_time"
+	ExperienceSourceTomato    = "tomato"
+	ExperienceSourceTodo      = "todo"
+	ExperienceSourceStreak    = "streak"
+)
+
+// 连续学习天数里程碑及对应奖励
+var streakMilestones = []struct {
+	Days   int
+	Amount int
+}{
+	{3, 20},
+	{7, 50},
+	{14, 100},
+	{30, 200},
+	{60, 400},
+	{100, 800},
+}
+
+// 统计连续学习天数时最多往前查询的天数，需要大于最大的里程碑
+const streakLookbackDays = 366
+
+type ExperienceRepository interface {
+	AwardExperience(log *models.ExperienceLog) (*models.User, bool, error)
+	AwardExperienceWithinCap(log *models.ExperienceLog, dailyCap int) (*models.User, bool, error)
+	UpdateUserLevel(userID uint, level int) error
+	GetUserByID(userID uint) (*models.User, error)
+	GetExperienceLogs(userID uint, page, pageSize int) ([]models.ExperienceLog, int64, error)
+}
+
+type ExperienceService struct {
+	repo      ExperienceRepository
+	studyRepo StudyDataRepository
+	cfg       *config.ExperienceConfig
+}
+
+func NewExperienceService(repo ExperienceRepository, studyRepo StudyDataRepository, cfg *config.ExperienceConfig) *ExperienceService {
+	return &ExperienceService{
+		repo:      repo,
+		studyRepo: studyRepo,
+		cfg:       cfg,
+	}
+}
+
+// ExperienceForLevel 升到指定等级所需的累计经验
+func ExperienceForLevel(cfg *config.ExperienceConfig, level int) int {
+	if level <= 1 {
+		return 0
+	}
+	return int(float64(cfg.LevelBase) * math.Pow(float64(level-1), cfg.LevelExponent))
+}
+
+// LevelForExperience 根据累计经验计算等级
+func LevelForExperience(cfg *config.ExperienceConfig, experience int) int {
+	level := 1
+	for level < cfg.MaxLevel && ExperienceForLevel(cfg, level+1) <= experience {
+		level++
+	}
+	return level
+}
+
+// 根据当日累计学习数据发放学习时长和番茄钟经验，并检查连续学习里程碑
+// 经验按“当日应得 - 当日已得”的差额发放，SourceKey 中带上应得总额，重复或并发调用都不会重复发放
+func (s *ExperienceService) AwardStudyExperience(userID uint, date time.Time) (*ExperienceResult, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	result, err := s.newResult(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err, notFound := s.studyRepo.GetDailyStudyData(userID, day)
+	if notFound {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	studyTarget := min(data.StudyTime*s.cfg.PerStudyMinute, s.cfg.StudyDailyCap)
+	if err := s.awardDailyDelta(userID, result, ExperienceSourceStudyTime, day, studyTarget); err != nil {
+		return nil, err
+	}
+	tomatoTarget := min(data.Tomatoes*s.cfg.PerTomato, s.cfg.TomatoDailyCap)
+	if err := s.awardDailyDelta(userID, result, ExperienceSourceTomato, day, tomatoTarget); err != nil {
+		return nil, err
+	}
+
+	if data.StudyTime > 0 {
+		if err := s.awardStreak(userID, result, day); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// 对多个日期依次结算学习经验（例如批量补交离线记录），合并为一次结果返回
+func (s *ExperienceService) AwardStudyExperienceForDays(userID uint, days []time.Time) (*ExperienceResult, error) {
+	var merged *ExperienceResult
+	for _, day := range days {
+		result, err := s.AwardStudyExperience(userID, day)
+		if err != nil {
+			return nil, err
+		}
+		if merged == nil {
+			merged = result
+			continue
+		}
+		merged.Gained += result.Gained
+		merged.Awards = append(merged.Awards, result.Awards...)
+		merged.Experience = result.Experience
+		merged.Level = result.Level
+		merged.NextLevelExperience = result.NextLevelExperience
+		merged.LevelUp = merged.Level > merged.PreviousLevel
+	}
+	if merged == nil {
+		return s.newResult(userID)
+	}
+	return merged, nil
+}
+
+// 完成待办发放经验，每个待办只发放一次，受每日上限约束
+func (s *ExperienceService) AwardTodoExperience(userID, todoID uint, date time.Time) (*ExperienceResult, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	result, err := s.newResult(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("%s:%d", ExperienceSourceTodo, todoID)
+	if err := s.awardWithinCap(userID, result, ExperienceSourceTodo, key, s.cfg.PerTodo, s.cfg.TodoDailyCap, day); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// 分页获取经验流水
+func (s *ExperienceService) GetExperienceLogs(userID uint, page, pageSize int) ([]ExperienceLogInfo, int64, error) {
+	logs, total, err := s.repo.GetExperienceLogs(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询经验记录失败: %w", err)
+	}
+	infos := make([]ExperienceLogInfo, 0, len(logs))
+	for _, log := range logs {
+		infos = append(infos, ExperienceLogInfo{
+			Source:    log.Source,
+			Amount:    log.Amount,
+			Date:      log.Date,
+			CreatedAt: log.CreatedAt,
+		})
+	}
+	return infos, total, nil
+}
+
+func (s *ExperienceService) newResult(userID uint) (*ExperienceResult, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	result := &ExperienceResult{
+		Experience:    user.Experience,
+		Level:         user.Level,
+		PreviousLevel: user.Level,
+		Awards:        []ExperienceAwardInfo{},
+	}
+	result.NextLevelExperience = ExperienceForLevel(s.cfg, result.Level+1)
+	return result, nil
+}
+
+// 以当日应得总额为上限发放差额，已得的部分由仓储在同一事务中统计
+func (s *ExperienceService) awardDailyDelta(userID uint, result *ExperienceResult, source string, day time.Time, target int) error {
+	if target <= 0 {
+		return nil
+	}
+	key := fmt.Sprintf("%s:%s:%d", source, day.Format("2006-01-02"), target)
+	return s.awardWithinCap(userID, result, source, key, target, target, day)
+}
+
+// 计算截止到 day 的连续学习天数，达到里程碑时发放奖励
+// SourceKey 中带上本轮连续学习的起始日期，中断后重新累计可以再次获得
+func (s *ExperienceService) awardStreak(userID uint, result *ExperienceResult, day time.Time) error {
+	streak, err := s.currentStreak(userID, day)
+	if err != nil {
+		return err
+	}
+	// 连续天数占满统计范围时无法确定真正的起始日期，起始日期会每天后移导致重复发放
+	// 所有里程碑都远小于统计范围，已经在达到时发放过
+	if streak >= streakLookbackDays {
+		return nil
+	}
+	start := day.AddDate(0, 0, -streak+1)
+	for _, milestone := range streakMilestones {
+		if streak < milestone.Days {
+			break
+		}
+		key := fmt.Sprintf("%s:%d:%s", ExperienceSourceStreak, milestone.Days, start.Format("2006-01-02"))
+		if err := s.award(userID, result, ExperienceSourceStreak, key, milestone.Amount, day); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ExperienceService) currentStreak(userID uint, day time.Time) (int, error) {
+	dataList, err := s.studyRepo.GetStudyDataSummary(userID, day.AddDate(0, 0, -streakLookbackDays+1), day)
+	if err != nil {
+		return 0, err
+	}
+	return countStreak(dataList, day, func(data models.DailyStudyData) bool {
+		return data.StudyTime > 0
+	}), nil
+}
+
+func (s *ExperienceService) award(userID uint, result *ExperienceResult, source, key string, amount int, day time.Time) error {
+	log := &models.ExperienceLog{
+		UserID:    userID,
+		Source:    source,
+		SourceKey: key,
+		Amount:    amount,
+		Date:      day,
+	}
+	user, awarded, err := s.repo.AwardExperience(log)
+	if err != nil {
+		return fmt.Errorf("发放经验失败: %w", err)
+	}
+	return s.applyAward(result, user, awarded, log)
+}
+
+// 最多发放 amount，当天该来源的总和不超过 dailyCap
+func (s *ExperienceService) awardWithinCap(userID uint, result *ExperienceResult, source, key string, amount, dailyCap int, day time.Time) error {
+	log := &models.ExperienceLog{
+		UserID:    userID,
+		Source:    source,
+		SourceKey: key,
+		Amount:    amount,
+		Date:      day,
+	}
+	user, awarded, err := s.repo.AwardExperienceWithinCap(log, dailyCap)
+	if err != nil {
+		return fmt.Errorf("发放经验失败: %w", err)
+	}
+	return s.applyAward(result, user, awarded, log)
+}
+
+// 把发放结果计入 result，等级变化时更新用户等级
+func (s *ExperienceService) applyAward(result *ExperienceResult, user *models.User, awarded bool, log *models.ExperienceLog) error {
+	result.Experience = user.Experience
+	if !awarded {
+		return nil
+	}
+	result.Gained += log.Amount
+	result.Awards = append(result.Awards, ExperienceAwardInfo{Source: log.Source, Amount: log.Amount})
+
+	level := LevelForExperience(s.cfg, user.Experience)
+	if level != user.Level {
+		if err := s.repo.UpdateUserLevel(user.ID, level); err != nil {
+			return fmt.Errorf("更新等级失败: %w", err)
+		}
+	}
+	result.Level = level
+	result.LevelUp = result.Level > result.PreviousLevel
+	result.NextLevelExperience = ExperienceForLevel(s.cfg, level+1)
+	return nil
+}
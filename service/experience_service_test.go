@@ -0,0 +1,112 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLevelForExperience(t *testing.T) {
+	cfg := &config.ExperienceConfig{LevelBase: 100, LevelExponent: 2, MaxLevel: 10}
+
+	tests := []struct {
+		name       string
+		experience int
+		want       int
+	}{
+		{"零经验", 0, 1},
+		{"差一点升级", 99, 1},
+		{"刚好升级", 100, 2},
+		{"跨多级", 450, 3},
+		{"达到等级上限", 1000000, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, LevelForExperience(cfg, tt.experience))
+		})
+	}
+}
+
+// 内存中的经验仓库，相同的 SourceKey 只发放一次
+type memoryExperienceRepository struct {
+	ExperienceRepository
+	user models.User
+	keys map[string]bool
+}
+
+func (r *memoryExperienceRepository) AwardExperience(log *models.ExperienceLog) (*models.User, bool, error) {
+	if r.keys[log.SourceKey] {
+		return &r.user, false, nil
+	}
+	r.keys[log.SourceKey] = true
+	r.user.Experience += log.Amount
+	return &r.user, true, nil
+}
+
+func (r *memoryExperienceRepository) UpdateUserLevel(userID uint, level int) error {
+	r.user.Level = level
+	return nil
+}
+
+// 从 first 到 last 每天都有学习记录
+type memoryStreakRepository struct {
+	StudyDataRepository
+	first, last time.Time
+}
+
+func (r *memoryStreakRepository) GetStudyDataSummary(userID uint, startDate, endDate time.Time) ([]models.DailyStudyData, error) {
+	dataList := make([]models.DailyStudyData, 0)
+	for day := startDate; !day.After(endDate); day = day.AddDate(0, 0, 1) {
+		if !day.Before(r.first) && !day.After(r.last) {
+			dataList = append(dataList, models.DailyStudyData{UserID: userID, Date: day, StudyTime: 30})
+		}
+	}
+	return dataList, nil
+}
+
+func TestAwardStreak(t *testing.T) {
+	cfg := &config.ExperienceConfig{LevelBase: 100, LevelExponent: 2, MaxLevel: 10}
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	total := 0
+	for _, milestone := range streakMilestones {
+		total += milestone.Amount
+	}
+
+	tests := []struct {
+		name string
+		days int
+		want int
+	}{
+		{"未达到里程碑", 2, 0},
+		{"达到第一个里程碑", 3, 20},
+		{"达到第二个里程碑", 7, 70},
+		{"达到所有里程碑", 100, total},
+		{"超过统计范围的连续学习不重复发放", 600, total},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 连续学习期间每天都结算一次
+			repo := &memoryExperienceRepository{user: models.User{ID: 1, Level: 1}, keys: make(map[string]bool)}
+			studyRepo := &memoryStreakRepository{first: first}
+			service := NewExperienceService(repo, studyRepo, cfg)
+			for i := 0; i < tt.days; i++ {
+				day := first.AddDate(0, 0, i)
+				studyRepo.last = day
+				assert.Equal(t, nil, service.awardStreak(1, &ExperienceResult{}, day))
+			}
+			assert.Equal(t, tt.want, repo.user.Experience)
+		})
+	}
+
+	// 中断后重新累计可以再次获得
+	repo := &memoryExperienceRepository{user: models.User{ID: 1, Level: 1}, keys: make(map[string]bool)}
+	service := NewExperienceService(repo, &memoryStreakRepository{first: first, last: first.AddDate(0, 0, 2)}, cfg)
+	assert.Equal(t, nil, service.awardStreak(1, &ExperienceResult{}, first.AddDate(0, 0, 2)))
+	service = NewExperienceService(repo, &memoryStreakRepository{first: first.AddDate(0, 0, 4), last: first.AddDate(0, 0, 6)}, cfg)
+	assert.Equal(t, nil, service.awardStreak(1, &ExperienceResult{}, first.AddDate(0, 0, 6)))
+	assert.Equal(t, 40, repo.user.Experience)
+}
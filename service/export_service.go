@@ -0,0 +1,171 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 导出格式
+const (
+	ExportFormatCSV = "csv"
+	ExportFormatICS = "ics"
+)
+
+// 单次导出最多覆盖的天数
+const MaxExportDays = 366
+
+// StudyDataExport 一次导出的内容，由 Write 写入响应
+type StudyDataExport struct {
+	FileName    string
+	ContentType string
+	userID      uint
+	format      string
+	days        []models.DailyStudyData
+	sessions    []models.StudySession
+	now         time.Time
+}
+
+type ExportService struct {
+	repo StudyDataRepository
+}
+
+func NewExportService(repo StudyDataRepository) *ExportService {
+	return &ExportService{repo: repo}
+}
+
+// 导出 [start, end] 内的学习数据，日期都取当天零点
+func (s *ExportService) ExportStudyData(userID uint, format string, start, end, now time.Time) (*StudyDataExport, error) {
+	if format != ExportFormatCSV && format != ExportFormatICS {
+		return nil, errors.New("不支持的导出格式")
+	}
+	if end.Before(start) {
+		return nil, errors.New("结束日期不能早于开始日期")
+	}
+	if int(end.Sub(start).Hours()/24)+1 > MaxExportDays {
+		return nil, fmt.Errorf("单次最多导出%d天的数据", MaxExportDays)
+	}
+	days, err := s.repo.GetStudyDataSummary(userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	// 日历按逐次的学习记录导出
+	var sessions []models.StudySession
+	if format == ExportFormatICS {
+		sessions, err = s.repo.GetStudySessions(userID, start, end.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, fmt.Errorf("查询学习记录失败: %w", err)
+		}
+	}
+	export := &StudyDataExport{
+		FileName: fmt.Sprintf("fm247-studydata-%s-%s.%s", start.Format("20060102"), end.Format("20060102"), format),
+		userID:   userID,
+		format:   format,
+		days:     days,
+		sessions: sessions,
+		now:      now,
+	}
+	if format == ExportFormatCSV {
+		export.ContentType = "text/csv; charset=utf-8"
+	} else {
+		export.ContentType = "text/calendar; charset=utf-8"
+	}
+	return export, nil
+}
+
+// 按导出格式写出内容
+func (e *StudyDataExport) Write(w io.Writer) error {
+	if e.format == ExportFormatCSV {
+		return writeStudyDataCSV(w, e.days)
+	}
+	return writeStudyDataICS(w, e.userID, e.days, e.sessions, e.now)
+}
+
+// 每天一行的学习数据汇总
+func writeStudyDataCSV(w io.Writer, days []models.DailyStudyData) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"date", "study_time", "tomatoes"}); err != nil {
+		return err
+	}
+	for _, day := range days {
+		record := []string{day.Date.Format("2006-01-02"), strconv.Itoa(day.StudyTime), strconv.Itoa(day.Tomatoes)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// 每次学习导出为一个事件，没有逐次记录的日期（如早期数据）导出为一个全天事件
+func writeStudyDataICS(w io.Writer, userID uint, days []models.DailyStudyData, sessions []models.StudySession, now time.Time) error {
+	buf := bufio.NewWriter(w)
+	writeICSLine(buf, "BEGIN:VCALENDAR")
+	writeICSLine(buf, "VERSION:2.0")
+	writeICSLine(buf, "PRODID:-//FM247//Study Data//ZH")
+	writeICSLine(buf, "CALSCALE:GREGORIAN")
+	stamp := now.UTC().Format("20060102T150405Z")
+	sessionDays := make(map[string]bool)
+	for _, session := range sessions {
+		sessionDays[session.EndedAt.In(now.Location()).Format("2006-01-02")] = true
+		summary := fmt.Sprintf("专注学习%d分钟，%d个番茄钟", session.StudyTime, session.Tomatoes)
+		if session.Abandoned {
+			summary += "（中途放弃）"
+		}
+		writeICSLine(buf, "BEGIN:VEVENT")
+		writeICSLine(buf, fmt.Sprintf("UID:studysession-%d@fm247", session.ID))
+		writeICSLine(buf, "DTSTAMP:"+stamp)
+		writeICSLine(buf, "DTSTART:"+session.StartedAt.UTC().Format("20060102T150405Z"))
+		writeICSLine(buf, "DTEND:"+session.EndedAt.UTC().Format("20060102T150405Z"))
+		writeICSLine(buf, "SUMMARY:"+escapeICSText(summary))
+		writeICSLine(buf, "END:VEVENT")
+	}
+	for _, day := range days {
+		if day.StudyTime <= 0 || sessionDays[day.Date.Format("2006-01-02")] {
+			continue
+		}
+		writeICSLine(buf, "BEGIN:VEVENT")
+		writeICSLine(buf, fmt.Sprintf("UID:studyday-%d-%s@fm247", userID, day.Date.Format("20060102")))
+		writeICSLine(buf, "DTSTAMP:"+stamp)
+		writeICSLine(buf, "DTSTART;VALUE=DATE:"+day.Date.Format("20060102"))
+		writeICSLine(buf, "DTEND;VALUE=DATE:"+day.Date.AddDate(0, 0, 1).Format("20060102"))
+		writeICSLine(buf, "SUMMARY:"+escapeICSText(fmt.Sprintf("专注学习%d分钟，%d个番茄钟", day.StudyTime, day.Tomatoes)))
+		writeICSLine(buf, "END:VEVENT")
+	}
+	writeICSLine(buf, "END:VCALENDAR")
+	return buf.Flush()
+}
+
+// iCalendar 要求每行不超过75字节，超出的部分折行并以空格开头，行尾为CRLF
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// 不在多字节字符中间折行
+		for cut > 0 && !isUTF8Start(line[cut]) {
+			cut--
+		}
+		w.WriteString(line[:cut])
+		w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
+
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// 转义 iCalendar 文本中的特殊字符
+func escapeICSText(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return replacer.Replace(text)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestWriteStudyDataICS(t *testing.T) {
+	days := []models.DailyStudyData{
+		{Date: time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), StudyTime: 0},
+		{Date: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), StudyTime: 120, Tomatoes: 4},
+		{Date: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), StudyTime: 50, Tomatoes: 2},
+	}
+	// 19日有逐次记录，只导出这次学习，不再导出全天事件
+	sessions := []models.StudySession{{
+		ID:        7,
+		StartedAt: time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC),
+		EndedAt:   time.Date(2026, 10, 19, 6, 50, 0, 0, time.UTC),
+		StudyTime: 50,
+		Tomatoes:  2,
+	}}
+	var buf bytes.Buffer
+	err := writeStudyDataICS(&buf, 1, days, sessions, time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC))
+	assert.Equal(t, nil, err)
+
+	content := buf.String()
+	assert.Equal(t, 2, strings.Count(content, "BEGIN:VEVENT"))
+	assert.Equal(t, true, strings.Contains(content, "DTSTART;VALUE=DATE:20261018\r\n"))
+	assert.Equal(t, true, strings.Contains(content, "DTEND;VALUE=DATE:20261019\r\n"))
+	assert.Equal(t, true, strings.Contains(content, "DTSTART:20261019T060000Z\r\n"))
+	assert.Equal(t, false, strings.Contains(content, "DTSTART;VALUE=DATE:20261019"))
+	for _, line := range strings.Split(strings.TrimSuffix(content, "\r\n"), "\r\n") {
+		assert.Equal(t, true, len(line) <= 75)
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/storage"
+	"errors"
+	"fmt"
+)
+
+type FriendService struct {
+	repo     FriendRepository
+	userRepo UserRepository
+	storage  storage.Storage
+}
+
+func NewFriendService(repo FriendRepository, userRepo UserRepository, storage storage.Storage) *FriendService {
+	return &FriendService{
+		repo:     repo,
+		userRepo: userRepo,
+		storage:  storage,
+	}
+}
+
+// 添加好友
+func (s *FriendService) AddFriend(userID, friendID uint) error {
+	if userID == friendID {
+		return errors.New("不能添加自己为好友")
+	}
+	if _, err := s.userRepo.GetUserByID(friendID); err != nil {
+		return errors.New("用户不存在")
+	}
+	if err := s.repo.AddFriend(userID, friendID); err != nil {
+		return fmt.Errorf("添加好友失败: %w", err)
+	}
+	return nil
+}
+
+// 删除好友
+func (s *FriendService) RemoveFriend(userID, friendID uint) error {
+	if err := s.repo.RemoveFriend(userID, friendID); err != nil {
+		return fmt.Errorf("删除好友失败: %w", err)
+	}
+	return nil
+}
+
+// 获取好友列表
+func (s *FriendService) GetFriends(userID uint) ([]FriendInfo, error) {
+	users, err := s.repo.GetFriends(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询好友失败: %w", err)
+	}
+	infos := make([]FriendInfo, 0, len(users))
+	for _, user := range users {
+		avatar, _ := s.storage.GetURL(user.Avatar)
+		infos = append(infos, FriendInfo{
+			ID:       user.ID,
+			Username: user.Username,
+			Avatar:   avatar,
+			Level:    user.Level,
+		})
+	}
+	return infos, nil
+}
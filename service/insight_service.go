@@ -0,0 +1,175 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type InsightRepository interface {
+	GetInsight(userID uint, weekStart time.Time) (string, bool, error)
+	SaveInsight(userID uint, weekStart time.Time, content string, ttl time.Duration) error
+	IncrementRegenerateCount(userID uint, day time.Time) (int64, error)
+	DecrementRegenerateCount(userID uint, day time.Time) error
+	GetUserByID(userID uint) (*models.User, error)
+	GetRecentTodos(userID uint, limit int) ([]models.Todo, error)
+}
+
+const insightSystemPrompt = "你是线上自习室电台的主持人Monica，性格温和、善解人意。下面是一位用户最近的学习数据和待办事项，请据此写一份简短的学习周报：先用两三句话回顾这段时间的学习情况（总时长、达标天数、变化趋势），再指出一个做得好的地方和一个需要改进的地方，最后给出三条具体、可执行的下周建议。只依据提供的数据，不要编造，语气亲切、鼓励，不超过400字。"
+
+type InsightService struct {
+	repo      InsightRepository
+	studyRepo StudyDataRepository
+	ai        *openai.Client
+	cfg       *config.InsightConfig
+}
+
+func NewInsightService(repo InsightRepository, studyRepo StudyDataRepository, ai *openai.Client, cfg *config.InsightConfig) *InsightService {
+	return &InsightService{
+		repo:      repo,
+		studyRepo: studyRepo,
+		ai:        ai,
+		cfg:       cfg,
+	}
+}
+
+// 获取本周的学习周报，本周还没有生成时立即生成
+func (s *InsightService) GetWeeklyInsight(ctx context.Context, userID uint, now time.Time) (*StudyInsightInfo, error) {
+	weekStart, _, err := studyPeriodRange("week", now)
+	if err != nil {
+		return nil, err
+	}
+	content, found, err := s.repo.GetInsight(userID, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习周报失败: %w", err)
+	}
+	if found {
+		var info StudyInsightInfo
+		if err := json.Unmarshal([]byte(content), &info); err == nil {
+			return &info, nil
+		}
+	}
+	return s.generate(ctx, userID, weekStart, now)
+}
+
+// 按需重新生成本周的学习周报，每天次数有限
+func (s *InsightService) RegenerateWeeklyInsight(ctx context.Context, userID uint, now time.Time) (*StudyInsightInfo, error) {
+	weekStart, _, err := studyPeriodRange("week", now)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.repo.IncrementRegenerateCount(userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("检查生成次数失败: %w", err)
+	}
+	if count > int64(s.cfg.RegeneratePerDay) {
+		return nil, fmt.Errorf("每天最多重新生成%d次，请明天再试", s.cfg.RegeneratePerDay)
+	}
+	info, err := s.generate(ctx, userID, weekStart, now)
+	if err != nil {
+		// 生成失败不占用次数
+		if decErr := s.repo.DecrementRegenerateCount(userID, now); decErr != nil {
+			return nil, errors.Join(err, fmt.Errorf("恢复生成次数失败: %w", decErr))
+		}
+		return nil, err
+	}
+	return info, nil
+}
+
+func (s *InsightService) generate(ctx context.Context, userID uint, weekStart, now time.Time) (*StudyInsightInfo, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, errors.New("用户不存在")
+	}
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := end.AddDate(0, 0, -(s.cfg.RecentDays - 1))
+	days, err := s.studyRepo.GetStudyDataSummary(userID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	todos, err := s.repo.GetRecentTodos(userID, s.cfg.MaxTodos)
+	if err != nil {
+		return nil, fmt.Errorf("查询待办事项失败: %w", err)
+	}
+
+	resp, err := s.ai.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: s.cfg.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: insightSystemPrompt},
+			{Role: openai.ChatMessageRoleUser, Content: buildInsightPrompt(user.DailyGoal, start, end, days, todos)},
+		},
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("调用AI接口失败: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("AI接口返回空结果: request_id=%s, model=%s", resp.ID, resp.Model)
+	}
+
+	info := &StudyInsightInfo{
+		WeekStart:   weekStart,
+		Content:     resp.Choices[0].Message.Content,
+		GeneratedAt: now,
+	}
+	content, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("序列化学习周报失败: %w", err)
+	}
+	if err := s.repo.SaveInsight(userID, weekStart, string(content), s.cfg.CacheTTL); err != nil {
+		return nil, fmt.Errorf("保存学习周报失败: %w", err)
+	}
+	return info, nil
+}
+
+// 把学习数据整理成精简的文本提供给模型，没有记录的日期按0分钟计
+func buildInsightPrompt(dailyGoal int, start, end time.Time, days []models.DailyStudyData, todos []models.Todo) string {
+	dayMap := make(map[string]models.DailyStudyData, len(days))
+	for _, day := range days {
+		dayMap[day.Date.Format("2006-01-02")] = day
+	}
+	weekdays := []string{"日", "一", "二", "三", "四", "五", "六"}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "每日学习目标：%d分钟\n", dailyGoal)
+	fmt.Fprintf(&b, "%s至%s每日学习情况：\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	total, tomatoes, goalDays, activeDays := 0, 0, 0, 0
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		data := dayMap[day.Format("2006-01-02")]
+		fmt.Fprintf(&b, "%s 周%s：%d分钟，%d个番茄钟\n", day.Format("01-02"), weekdays[day.Weekday()], data.StudyTime, data.Tomatoes)
+		total += data.StudyTime
+		tomatoes += data.Tomatoes
+		if data.StudyTime > 0 {
+			activeDays++
+		}
+		if dailyGoal > 0 && data.StudyTime >= dailyGoal {
+			goalDays++
+		}
+	}
+	fmt.Fprintf(&b, "合计：%d分钟，%d个番茄钟，学习%d天，达标%d天\n", total, tomatoes, activeDays, goalDays)
+
+	if len(todos) == 0 {
+		b.WriteString("待办事项：无\n")
+		return b.String()
+	}
+	b.WriteString("最近的待办事项：\n")
+	for _, todo := range todos {
+		status := "未完成"
+		if todo.Completed {
+			status = "已完成"
+		}
+		fmt.Fprintf(&b, "- [%s] %s", status, todo.Event)
+		if todo.DueAt != nil {
+			fmt.Fprintf(&b, "（截止%s）", todo.DueAt.In(end.Location()).Format("01-02 15:04"))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBuildInsightPrompt(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+	days := []models.DailyStudyData{
+		{Date: day(17), StudyTime: 150, Tomatoes: 5},
+		{Date: day(19), StudyTime: 60, Tomatoes: 2},
+	}
+	due := time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC)
+	todos := []models.Todo{{Event: "背单词", Completed: true}, {Event: "数学作业", DueAt: &due}}
+
+	prompt := buildInsightPrompt(120, day(17), day(19), days, todos)
+
+	assert.Equal(t, true, strings.Contains(prompt, "10-17 周六：150分钟，5个番茄钟\n"))
+	assert.Equal(t, true, strings.Contains(prompt, "10-18 周日：0分钟，0个番茄钟\n"))
+	assert.Equal(t, true, strings.Contains(prompt, "合计：210分钟，7个番茄钟，学习2天，达标1天\n"))
+	assert.Equal(t, true, strings.Contains(prompt, "- [已完成] 背单词\n"))
+	assert.Equal(t, true, strings.Contains(prompt, "- [未完成] 数学作业（截止10-20 18:00）\n"))
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"2026-FM247-BackEnd/storage"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 排行榜范围
+const (
+	LeaderboardScopeGlobal  = "global"
+	LeaderboardScopeFriends = "friends"
+)
+
+var validLeaderboardPeriods = map[string]bool{
+	"daily":   true,
+	"weekly":  true,
+	"monthly": true,
+	"all":     true,
+}
+
+type LeaderboardRepository interface {
+	IncrementStudyTime(userID uint, date time.Time, studyTime int) error
+	GetRange(period string, date time.Time, start, stop int64) ([]redis.Z, error)
+	GetRank(period string, date time.Time, userID uint) (rank int64, found bool, err error)
+	GetScores(period string, date time.Time, userIDs []uint) ([]float64, error)
+	RemoveUser(period string, date time.Time, userID uint) error
+	RemoveUserFromAll(userID uint, date time.Time) error
+	GetUsersByIDs(userIDs []uint) ([]models.User, error)
+	SetOptOut(userID uint, optOut bool, date time.Time) error
+	Rebuild(date time.Time) error
+}
+
+type FriendRepository interface {
+	AddFriend(userID, friendID uint) error
+	RemoveFriend(userID, friendID uint) error
+	GetFriendIDs(userID uint) ([]uint, error)
+	GetFriends(userID uint) ([]models.User, error)
+}
+
+type LeaderboardService struct {
+	repo       LeaderboardRepository
+	friendRepo FriendRepository
+	flusher    StudyDataFlush
+	storage    storage.Storage
+}
+
+func NewLeaderboardService(repo LeaderboardRepository, friendRepo FriendRepository, flusher StudyDataFlush, storage storage.Storage) *LeaderboardService {
+	return &LeaderboardService{
+		repo:       repo,
+		friendRepo: friendRepo,
+		flusher:    flusher,
+		storage:    storage,
+	}
+}
+
+func leaderboardNow() time.Time {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	return time.Now().In(loc)
+}
+
+// 记录学习时长到各周期排行榜
+func (s *LeaderboardService) RecordStudyTime(userID uint, date time.Time, studyTime int) error {
+	if studyTime == 0 {
+		return nil
+	}
+	return s.repo.IncrementStudyTime(userID, date, studyTime)
+}
+
+// 获取排行榜前 limit 名，同时返回当前用户的名次
+func (s *LeaderboardService) GetLeaderboard(userID uint, period, scope string, limit int) (*LeaderboardInfo, error) {
+	if !validLeaderboardPeriods[period] {
+		return nil, errors.New("不支持的排行榜周期")
+	}
+	now := leaderboardNow()
+	info := &LeaderboardInfo{Period: period, Scope: scope}
+
+	if scope == LeaderboardScopeFriends {
+		entries, err := s.friendEntries(userID, period, now)
+		if err != nil {
+			return nil, err
+		}
+		info.Me = findEntry(entries, userID)
+		info.Entries = entries[:min(limit, len(entries))]
+		return info, nil
+	}
+
+	var err error
+	info.Entries, err = s.topEntries(period, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	info.Me, err = s.globalEntry(userID, period, now)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// 获取当前用户的名次及前后 neighbours 名用户
+func (s *LeaderboardService) GetMyRank(userID uint, period, scope string, neighbours int) (*LeaderboardInfo, error) {
+	if !validLeaderboardPeriods[period] {
+		return nil, errors.New("不支持的排行榜周期")
+	}
+	now := leaderboardNow()
+	info := &LeaderboardInfo{Period: period, Scope: scope, Entries: []LeaderboardEntry{}}
+
+	if scope == LeaderboardScopeFriends {
+		entries, err := s.friendEntries(userID, period, now)
+		if err != nil {
+			return nil, err
+		}
+		info.Me = findEntry(entries, userID)
+		if info.Me != nil {
+			index := int(info.Me.Rank - 1)
+			info.Entries = entries[max(0, index-neighbours):min(len(entries), index+neighbours+1)]
+		}
+		return info, nil
+	}
+
+	rank, found, err := s.repo.GetRank(period, now, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询名次失败: %w", err)
+	}
+	if !found {
+		return info, nil
+	}
+	start := max(0, rank-int64(neighbours))
+	zs, err := s.repo.GetRange(period, now, start, rank+int64(neighbours))
+	if err != nil {
+		return nil, fmt.Errorf("查询排行榜失败: %w", err)
+	}
+	var stale []uint
+	info.Entries, stale, err = s.toEntries(zs, start+1)
+	if err != nil {
+		return nil, err
+	}
+	s.removeStale(period, now, stale)
+	info.Me = findEntry(info.Entries, userID)
+	return info, nil
+}
+
+// 更新排行榜隐私设置
+func (s *LeaderboardService) SetOptOut(userID uint, optOut bool) error {
+	return s.repo.SetOptOut(userID, optOut, leaderboardNow())
+}
+
+// 注销账号时把用户从当前各周期排行榜移除
+func (s *LeaderboardService) RemoveUser(userID uint) error {
+	return s.repo.RemoveUserFromAll(userID, leaderboardNow())
+}
+
+// 根据 mysql 数据重建当前各周期排行榜
+// 先把未落库的学习数据写入mysql，否则还在redis中的学习时长会从排行榜中丢失
+func (s *LeaderboardService) Rebuild() error {
+	if err := s.flusher.Flush(); err != nil {
+		return fmt.Errorf("学习数据落库失败: %w", err)
+	}
+	return s.repo.Rebuild(leaderboardNow())
+}
+
+func (s *LeaderboardService) globalEntry(userID uint, period string, now time.Time) (*LeaderboardEntry, error) {
+	rank, found, err := s.repo.GetRank(period, now, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询名次失败: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	zs, err := s.repo.GetRange(period, now, rank, rank)
+	if err != nil {
+		return nil, fmt.Errorf("查询排行榜失败: %w", err)
+	}
+	entries, stale, err := s.toEntries(zs, rank+1)
+	if err != nil {
+		return nil, err
+	}
+	s.removeStale(period, now, stale)
+	return findEntry(entries, userID), nil
+}
+
+// 好友榜：自己和关注的人，不参与排行榜的用户不展示
+func (s *LeaderboardService) friendEntries(userID uint, period string, now time.Time) ([]LeaderboardEntry, error) {
+	friendIDs, err := s.friendRepo.GetFriendIDs(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询好友失败: %w", err)
+	}
+	userIDs := append([]uint{userID}, friendIDs...)
+	users, err := s.repo.GetUsersByIDs(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	visible := make([]models.User, 0, len(users))
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		if user.LeaderboardOptOut {
+			continue
+		}
+		visible = append(visible, user)
+		ids = append(ids, user.ID)
+	}
+	scores, err := s.repo.GetScores(period, now, ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询排行榜失败: %w", err)
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(visible))
+	for i, user := range visible {
+		entries = append(entries, s.newEntry(user, int(scores[i])))
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].StudyTime != entries[j].StudyTime {
+			return entries[i].StudyTime > entries[j].StudyTime
+		}
+		return entries[i].UserID < entries[j].UserID
+	})
+	for i := range entries {
+		entries[i].Rank = int64(i + 1)
+	}
+	return entries, nil
+}
+
+// 取前 limit 名，跳过已注销的用户后不足时继续往后取，名次只按返回的用户连续计算
+func (s *LeaderboardService) topEntries(period string, now time.Time, limit int) ([]LeaderboardEntry, error) {
+	entries := make([]LeaderboardEntry, 0, limit)
+	var stale []uint
+	start := int64(0)
+	for len(entries) < limit {
+		size := int64(limit - len(entries))
+		zs, err := s.repo.GetRange(period, now, start, start+size-1)
+		if err != nil {
+			return nil, fmt.Errorf("查询排行榜失败: %w", err)
+		}
+		batch, missing, err := s.toEntries(zs, int64(len(entries))+1)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, batch...)
+		stale = append(stale, missing...)
+		if int64(len(zs)) < size {
+			break
+		}
+		start += size
+	}
+	s.removeStale(period, now, stale)
+	return entries, nil
+}
+
+// 把 ZSET 结果补全为带用户信息的榜单，名次从 firstRank 开始只按返回的用户连续计算
+// 同时返回已注销的用户，由调用方在取完榜单后从榜单移除，避免移除后分页错位
+func (s *LeaderboardService) toEntries(zs []redis.Z, firstRank int64) ([]LeaderboardEntry, []uint, error) {
+	ids := make([]uint, len(zs))
+	for i, z := range zs {
+		id, _ := strconv.ParseUint(fmt.Sprint(z.Member), 10, 64)
+		ids[i] = uint(id)
+	}
+	users, err := s.repo.GetUsersByIDs(ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	userMap := make(map[uint]models.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(zs))
+	stale := make([]uint, 0)
+	for i, id := range ids {
+		user, ok := userMap[id]
+		if !ok {
+			stale = append(stale, id)
+			continue
+		}
+		entry := s.newEntry(user, int(zs[i].Score))
+		entry.Rank = firstRank + int64(len(entries))
+		entries = append(entries, entry)
+	}
+	return entries, stale, nil
+}
+
+// 从榜单移除已注销的用户，失败只记录日志，下次查询时会再次移除
+func (s *LeaderboardService) removeStale(period string, now time.Time, userIDs []uint) {
+	for _, userID := range userIDs {
+		if err := s.repo.RemoveUser(period, now, userID); err != nil {
+			logger.Log.Errorf("移除排行榜中已注销的用户失败: user=%d err=%v", userID, err)
+		}
+	}
+}
+
+func (s *LeaderboardService) newEntry(user models.User, studyTime int) LeaderboardEntry {
+	avatar, _ := s.storage.GetURL(user.Avatar)
+	return LeaderboardEntry{
+		UserID:    user.ID,
+		Username:  user.Username,
+		Avatar:    avatar,
+		Level:     user.Level,
+		StudyTime: studyTime,
+	}
+}
+
+func findEntry(entries []LeaderboardEntry, userID uint) *LeaderboardEntry {
+	for i := range entries {
+		if entries[i].UserID == userID {
+			entry := entries[i]
+			return &entry
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type NoteRepository interface {
+	CreateNote(note *models.Note) error
+	GetNoteByID(id uint) (*models.Note, error)
+	GetNotes(userID uint, start, end *time.Time, page, pageSize int) ([]models.Note, int64, error)
+	UpdateNote(note *models.Note) error
+	DeleteNote(id uint) error
+}
+
+type NoteService struct {
+	repo NoteRepository
+	cfg  *config.NoteConfig
+}
+
+func NewNoteService(repo NoteRepository, cfg *config.NoteConfig) *NoteService {
+	return &NoteService{repo: repo, cfg: cfg}
+}
+
+// 创建备忘录，内容按markdown原文保存
+func (s *NoteService) CreateNote(userID uint, title, content string, date time.Time) (*NoteInfo, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, errors.New("标题不能为空")
+	}
+	if err := s.validateContent(content); err != nil {
+		return nil, err
+	}
+	note := &models.Note{
+		UserID:  userID,
+		Title:   title,
+		Date:    noteDate(date),
+		Content: content,
+	}
+	if err := s.repo.CreateNote(note); err != nil {
+		return nil, fmt.Errorf("创建备忘录失败: %w", err)
+	}
+	info := newNoteInfo(*note)
+	return &info, nil
+}
+
+// 更新备忘录，为空的字段保持不变
+func (s *NoteService) UpdateNote(userID, noteID uint, title, content *string, date *time.Time) (*NoteInfo, error) {
+	note, err := s.getOwnedNote(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if title != nil {
+		if strings.TrimSpace(*title) == "" {
+			return nil, errors.New("标题不能为空")
+		}
+		note.Title = strings.TrimSpace(*title)
+	}
+	if content != nil {
+		if err := s.validateContent(*content); err != nil {
+			return nil, err
+		}
+		note.Content = *content
+	}
+	if date != nil {
+		note.Date = noteDate(*date)
+	}
+	if err := s.repo.UpdateNote(note); err != nil {
+		return nil, fmt.Errorf("更新备忘录失败: %w", err)
+	}
+	info := newNoteInfo(*note)
+	return &info, nil
+}
+
+// 置顶或取消置顶
+func (s *NoteService) PinNote(userID, noteID uint, pinned bool) error {
+	note, err := s.getOwnedNote(userID, noteID)
+	if err != nil {
+		return err
+	}
+	if note.Pinned == pinned {
+		return nil
+	}
+	note.Pinned = pinned
+	if err := s.repo.UpdateNote(note); err != nil {
+		return fmt.Errorf("更新备忘录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *NoteService) GetNote(userID, noteID uint) (*NoteInfo, error) {
+	note, err := s.getOwnedNote(userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+	info := newNoteInfo(*note)
+	return &info, nil
+}
+
+// 分页查询备忘录，start、end 为空时不限制日期
+func (s *NoteService) GetNotes(userID uint, start, end *time.Time, page, pageSize int) ([]NoteInfo, int64, error) {
+	if start != nil && end != nil && start.After(*end) {
+		return nil, 0, errors.New("开始日期不能晚于结束日期")
+	}
+	notes, total, err := s.repo.GetNotes(userID, start, end, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询备忘录失败: %w", err)
+	}
+	infos := make([]NoteInfo, 0, len(notes))
+	for _, note := range notes {
+		infos = append(infos, newNoteInfo(note))
+	}
+	return infos, total, nil
+}
+
+// 删除备忘录，软删除
+func (s *NoteService) DeleteNote(userID, noteID uint) error {
+	if _, err := s.getOwnedNote(userID, noteID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteNote(noteID); err != nil {
+		return fmt.Errorf("删除备忘录失败: %w", err)
+	}
+	return nil
+}
+
+func (s *NoteService) getOwnedNote(userID, noteID uint) (*models.Note, error) {
+	note, err := s.repo.GetNoteByID(noteID)
+	if err != nil {
+		return nil, errors.New("备忘录不存在")
+	}
+	if note.UserID != userID {
+		return nil, errors.New("无权限操作该备忘录")
+	}
+	return note, nil
+}
+
+// 内容按字符数限制长度
+func (s *NoteService) validateContent(content string) error {
+	if utf8.RuneCountInString(content) > s.cfg.MaxContentLength {
+		return fmt.Errorf("备忘录内容不能超过%d个字符", s.cfg.MaxContentLength)
+	}
+	return nil
+}
+
+// 备忘录的日期只保留到天
+func noteDate(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+}
+
+func newNoteInfo(note models.Note) NoteInfo {
+	return NoteInfo{
+		ID:        note.ID,
+		Title:     note.Title,
+		Date:      note.Date,
+		Content:   note.Content,
+		Pinned:    note.Pinned,
+		CreatedAt: note.CreatedAt,
+		UpdatedAt: note.UpdatedAt,
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+type memoryNoteRepository struct {
+	notes map[uint]*models.Note
+}
+
+func (r *memoryNoteRepository) CreateNote(note *models.Note) error {
+	note.ID = uint(len(r.notes) + 1)
+	saved := *note
+	r.notes[note.ID] = &saved
+	return nil
+}
+
+func (r *memoryNoteRepository) GetNoteByID(id uint) (*models.Note, error) {
+	note, ok := r.notes[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *note
+	return &found, nil
+}
+
+func (r *memoryNoteRepository) GetNotes(userID uint, start, end *time.Time, page, pageSize int) ([]models.Note, int64, error) {
+	notes := make([]models.Note, 0)
+	for id := uint(1); id <= uint(len(r.notes)); id++ {
+		note, ok := r.notes[id]
+		if !ok || note.UserID != userID {
+			continue
+		}
+		if (start != nil && note.Date.Before(*start)) || (end != nil && note.Date.After(*end)) {
+			continue
+		}
+		notes = append(notes, *note)
+	}
+	return notes, int64(len(notes)), nil
+}
+
+func (r *memoryNoteRepository) UpdateNote(note *models.Note) error {
+	saved := *note
+	r.notes[note.ID] = &saved
+	return nil
+}
+
+func (r *memoryNoteRepository) DeleteNote(id uint) error {
+	delete(r.notes, id)
+	return nil
+}
+
+// 用户1有10月18日、19日两条备忘录，用户2有10月19日一条
+func newMemoryNoteService() (*NoteService, *memoryNoteRepository) {
+	day := func(d int) time.Time { return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) }
+	repo := &memoryNoteRepository{notes: map[uint]*models.Note{
+		1: {ID: 1, UserID: 1, Title: "读书笔记", Date: day(18), Content: "# 第一章"},
+		2: {ID: 2, UserID: 1, Title: "错题", Date: day(19)},
+		3: {ID: 3, UserID: 2, Title: "计划", Date: day(19)},
+	}}
+	return NewNoteService(repo, &config.NoteConfig{MaxContentLength: 10}), repo
+}
+
+func TestCreateNote(t *testing.T) {
+	tests := []struct {
+		name    string
+		title   string
+		content string
+		wantErr bool
+	}{
+		{"创建", "周记", "## 本周", false},
+		{"标题去掉首尾空格后为空", "  ", "", true},
+		{"内容按字符数计算", "周记", "一二三四五六七八九十", false},
+		{"内容过长", "周记", strings.Repeat("a", 11), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newMemoryNoteService()
+			date := time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)
+			info, err := service.CreateNote(1, tt.title, tt.content, date)
+			assert.Equal(t, tt.wantErr, err != nil)
+			if err != nil {
+				assert.Equal(t, 3, len(repo.notes))
+				return
+			}
+			// 日期只保留到天，内容原样保存
+			assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), info.Date)
+			assert.Equal(t, tt.content, repo.notes[info.ID].Content)
+			assert.Equal(t, uint(1), repo.notes[info.ID].UserID)
+		})
+	}
+}
+
+func TestUpdateNote(t *testing.T) {
+	text := func(s string) *string { return &s }
+	date := time.Date(2026, 10, 20, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		userID    uint
+		title     *string
+		content   *string
+		date      *time.Time
+		wantErr   bool
+		wantTitle string
+		wantBody  string
+	}{
+		{"只改标题", 1, text(" 读书笔记（一） "), nil, nil, false, "读书笔记（一）", "# 第一章"},
+		{"只改内容", 1, nil, text("# 第二章"), nil, false, "读书笔记", "# 第二章"},
+		{"修改日期", 1, nil, nil, &date, false, "读书笔记", "# 第一章"},
+		{"标题不能改为空", 1, text(""), nil, nil, true, "读书笔记", "# 第一章"},
+		{"内容过长", 1, nil, text(strings.Repeat("字", 11)), nil, true, "读书笔记", "# 第一章"},
+		{"不能修改其他用户的备忘录", 2, text("改掉"), nil, nil, true, "读书笔记", "# 第一章"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newMemoryNoteService()
+			_, err := service.UpdateNote(tt.userID, 1, tt.title, tt.content, tt.date)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantTitle, repo.notes[1].Title)
+			assert.Equal(t, tt.wantBody, repo.notes[1].Content)
+			if tt.date != nil {
+				assert.Equal(t, time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), repo.notes[1].Date)
+			}
+		})
+	}
+}
+
+func TestPinNote(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     uint
+		noteID     uint
+		pinned     bool
+		wantErr    bool
+		wantPinned bool
+	}{
+		{"置顶", 1, 1, true, false, true},
+		{"未置顶时取消置顶", 1, 1, false, false, false},
+		{"不能置顶其他用户的备忘录", 2, 1, true, true, false},
+		{"备忘录不存在", 1, 9, true, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, repo := newMemoryNoteService()
+			err := service.PinNote(tt.userID, tt.noteID, tt.pinned)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.wantPinned, repo.notes[1].Pinned)
+		})
+	}
+}
+
+func TestGetNotes(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	tests := []struct {
+		name    string
+		userID  uint
+		start   *time.Time
+		end     *time.Time
+		wantErr bool
+		wantIDs []uint
+	}{
+		{"不限制日期", 1, nil, nil, false, []uint{1, 2}},
+		{"包含开始日期", 1, day(19), nil, false, []uint{2}},
+		{"包含结束日期", 1, nil, day(18), false, []uint{1}},
+		{"同一天", 1, day(18), day(18), false, []uint{1}},
+		{"开始日期晚于结束日期", 1, day(19), day(18), true, nil},
+		{"只能查到自己的备忘录", 2, nil, nil, false, []uint{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newMemoryNoteService()
+			infos, total, err := service.GetNotes(tt.userID, tt.start, tt.end, 1, 10)
+			assert.Equal(t, tt.wantErr, err != nil)
+			var ids []uint
+			for _, info := range infos {
+				ids = append(ids, info.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+			assert.Equal(t, int64(len(tt.wantIDs)), total)
+		})
+	}
+}
+
+func TestNoteOwnership(t *testing.T) {
+	service, repo := newMemoryNoteService()
+
+	// 其他用户不能查看或删除备忘录
+	_, err := service.GetNote(2, 1)
+	assert.NotEqual(t, nil, err)
+	assert.NotEqual(t, nil, service.DeleteNote(2, 1))
+	assert.Equal(t, 3, len(repo.notes))
+
+	info, err := service.GetNote(1, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "读书笔记", info.Title)
+	assert.Equal(t, nil, service.DeleteNote(1, 1))
+	assert.Equal(t, 2, len(repo.notes))
+}
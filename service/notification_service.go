@@ -0,0 +1,417 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 通知类型
+const NotificationTypeTodoReminder = "todo_reminder"
+
+// 每个WebSocket连接缓存的待推送消息数量，连接处理不过来时丢弃新消息
+const pushBufferSize = 16
+
+type NotificationRepository interface {
+	CreateNotification(notification *models.Notification) error
+	GetNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error)
+	CountUnreadNotifications(userID uint) (int64, error)
+	MarkNotificationsRead(userID, id uint, at time.Time) (int64, error)
+	GetNotificationSetting(userID uint) (*models.NotificationSetting, error)
+	SaveNotificationSetting(setting *models.NotificationSetting) error
+	GetUserByID(userID uint) (*models.User, error)
+}
+
+// NotificationChannel 通知渠道，新增推送方式时实现该接口并在创建 NotificationService 时注册
+type NotificationChannel interface {
+	Name() string
+	// 按用户的通知设置判断是否通过该渠道发送
+	Enabled(setting *models.NotificationSetting) bool
+	Send(user *models.User, notification *models.Notification) error
+}
+
+// NotificationSettingInput 更新通知设置，免打扰开始和结束时间需要同时设置或同时为空
+type NotificationSettingInput struct {
+	EmailEnabled bool
+	PushEnabled  bool
+	QuietStart   string
+	QuietEnd     string
+}
+
+type NotificationService struct {
+	repo     NotificationRepository
+	channels []NotificationChannel
+	email    bool // 是否配置了邮件渠道
+}
+
+// channels 按顺序发送，站内信需要放在第一个，之后的渠道推送时才能带上通知ID
+func NewNotificationService(repo NotificationRepository, cfg *config.NotificationConfig, channels ...NotificationChannel) *NotificationService {
+	return &NotificationService{
+		repo:     repo,
+		channels: channels,
+		email:    cfg.SMTPHost != "",
+	}
+}
+
+// 通过用户开启的所有渠道发送通知，站内信保存失败时返回错误，其它渠道失败只记录日志
+func (s *NotificationService) Notify(userID uint, notification *models.Notification) error {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	setting, err := s.getSetting(userID)
+	if err != nil {
+		return err
+	}
+	notification.UserID = userID
+	for _, channel := range s.channels {
+		if !channel.Enabled(setting) {
+			continue
+		}
+		if err := channel.Send(user, notification); err != nil {
+			if channel.Name() == InboxChannelName {
+				return fmt.Errorf("保存站内信失败: %w", err)
+			}
+			logger.Log.Errorf("发送通知失败: channel=%s user=%d err=%v", channel.Name(), userID, err)
+		}
+	}
+	return nil
+}
+
+// 用户当前处于免打扰时间时返回免打扰结束的时间
+func (s *NotificationService) QuietUntil(userID uint, now time.Time) (time.Time, bool, error) {
+	setting, err := s.getSetting(userID)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	until, quiet := quietHoursEnd(now, setting.QuietStart, setting.QuietEnd)
+	return until, quiet, nil
+}
+
+// 分页获取通知，同时返回未读数量
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]NotificationInfo, int64, int64, error) {
+	notifications, total, err := s.repo.GetNotifications(userID, unreadOnly, page, pageSize)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("查询通知失败: %w", err)
+	}
+	unread, err := s.repo.CountUnreadNotifications(userID)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("查询未读通知数量失败: %w", err)
+	}
+	infos := make([]NotificationInfo, 0, len(notifications))
+	for _, notification := range notifications {
+		infos = append(infos, newNotificationInfo(notification))
+	}
+	return infos, total, unread, nil
+}
+
+// 把一条通知标记为已读，已经是已读时不做处理
+func (s *NotificationService) MarkNotificationRead(userID, notificationID uint, now time.Time) error {
+	if _, err := s.repo.MarkNotificationsRead(userID, notificationID, now); err != nil {
+		return fmt.Errorf("标记通知已读失败: %w", err)
+	}
+	return nil
+}
+
+// 把所有未读通知标记为已读，返回标记的数量
+func (s *NotificationService) MarkAllNotificationsRead(userID uint, now time.Time) (int64, error) {
+	count, err := s.repo.MarkNotificationsRead(userID, 0, now)
+	if err != nil {
+		return 0, fmt.Errorf("标记通知已读失败: %w", err)
+	}
+	return count, nil
+}
+
+func (s *NotificationService) GetNotificationSetting(userID uint) (*NotificationSettingInfo, error) {
+	setting, err := s.getSetting(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.newNotificationSettingInfo(setting), nil
+}
+
+func (s *NotificationService) UpdateNotificationSetting(userID uint, input NotificationSettingInput) (*NotificationSettingInfo, error) {
+	if (input.QuietStart == "") != (input.QuietEnd == "") {
+		return nil, errors.New("免打扰开始和结束时间需要同时设置")
+	}
+	if input.QuietStart != "" {
+		start, err := parseClock(input.QuietStart)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(input.QuietEnd)
+		if err != nil {
+			return nil, err
+		}
+		if start == end {
+			return nil, errors.New("免打扰开始和结束时间不能相同")
+		}
+	}
+	if input.EmailEnabled && !s.email {
+		return nil, errors.New("暂不支持邮件提醒")
+	}
+	setting := &models.NotificationSetting{
+		UserID:       userID,
+		EmailEnabled: input.EmailEnabled,
+		PushEnabled:  input.PushEnabled,
+		QuietStart:   input.QuietStart,
+		QuietEnd:     input.QuietEnd,
+	}
+	if err := s.repo.SaveNotificationSetting(setting); err != nil {
+		return nil, fmt.Errorf("保存通知设置失败: %w", err)
+	}
+	return s.newNotificationSettingInfo(setting), nil
+}
+
+// 用户的通知设置，没有设置时只发送站内信和WebSocket推送
+func (s *NotificationService) getSetting(userID uint) (*models.NotificationSetting, error) {
+	setting, err := s.repo.GetNotificationSetting(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询通知设置失败: %w", err)
+	}
+	if setting == nil {
+		setting = &models.NotificationSetting{UserID: userID, PushEnabled: true}
+	}
+	return setting, nil
+}
+
+func (s *NotificationService) newNotificationSettingInfo(setting *models.NotificationSetting) *NotificationSettingInfo {
+	return &NotificationSettingInfo{
+		EmailEnabled:   setting.EmailEnabled,
+		PushEnabled:    setting.PushEnabled,
+		QuietStart:     setting.QuietStart,
+		QuietEnd:       setting.QuietEnd,
+		EmailAvailable: s.email,
+	}
+}
+
+// 解析 HH:MM，返回当天的第几分钟
+func parseClock(value string) (int, error) {
+	hour, minute, ok := strings.Cut(value, ":")
+	h, err := strconv.Atoi(hour)
+	if !ok || len(hour) != 2 || err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("时间格式有误: %s", value)
+	}
+	m, err := strconv.Atoi(minute)
+	if len(minute) != 2 || err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("时间格式有误: %s", value)
+	}
+	return h*60 + m, nil
+}
+
+// now 处于 [start, end) 的免打扰时间内时返回免打扰结束的时间，start 晚于 end 表示跨过零点
+func quietHoursEnd(now time.Time, start, end string) (time.Time, bool) {
+	startMinute, err := parseClock(start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	endMinute, err := parseClock(end)
+	if err != nil || startMinute == endMinute {
+		return time.Time{}, false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	var quiet bool
+	if startMinute < endMinute {
+		quiet = minute >= startMinute && minute < endMinute
+	} else {
+		quiet = minute >= startMinute || minute < endMinute
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+	until := time.Date(now.Year(), now.Month(), now.Day(), endMinute/60, endMinute%60, 0, 0, now.Location())
+	if minute >= endMinute {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+func newNotificationInfo(notification models.Notification) NotificationInfo {
+	return NotificationInfo{
+		ID:        notification.ID,
+		Type:      notification.Type,
+		Title:     notification.Title,
+		Content:   notification.Content,
+		TodoID:    notification.TodoID,
+		IsRead:    notification.IsRead,
+		ReadAt:    notification.ReadAt,
+		CreatedAt: notification.CreatedAt,
+	}
+}
+
+const (
+	InboxChannelName = "inbox"
+	EmailChannelName = "email"
+	PushChannelName  = "push"
+)
+
+// InboxChannel 站内信，所有通知都会保存
+type InboxChannel struct {
+	repo NotificationRepository
+}
+
+func NewInboxChannel(repo NotificationRepository) *InboxChannel {
+	return &InboxChannel{repo: repo}
+}
+
+func (c *InboxChannel) Name() string {
+	return InboxChannelName
+}
+
+func (c *InboxChannel) Enabled(setting *models.NotificationSetting) bool {
+	return true
+}
+
+func (c *InboxChannel) Send(user *models.User, notification *models.Notification) error {
+	return c.repo.CreateNotification(notification)
+}
+
+// EmailChannel 通过SMTP发送邮件，没有配置SMTP服务器时不发送
+type EmailChannel struct {
+	cfg *config.NotificationConfig
+}
+
+func NewEmailChannel(cfg *config.NotificationConfig) *EmailChannel {
+	return &EmailChannel{cfg: cfg}
+}
+
+func (c *EmailChannel) Name() string {
+	return EmailChannelName
+}
+
+func (c *EmailChannel) Enabled(setting *models.NotificationSetting) bool {
+	return c.cfg.SMTPHost != "" && setting.EmailEnabled
+}
+
+func (c *EmailChannel) Send(user *models.User, notification *models.Notification) error {
+	if user.Email == "" {
+		return errors.New("用户没有设置邮箱")
+	}
+	var message strings.Builder
+	message.WriteString("From: " + c.cfg.SMTPFrom + "\r\n")
+	message.WriteString("To: " + user.Email + "\r\n")
+	message.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", notification.Title) + "\r\n")
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	message.WriteString(notification.Content)
+
+	return c.sendMail(user.Email, []byte(message.String()))
+}
+
+// 与 smtp.SendMail 相同，但连接和整个发送过程都有超时，避免SMTP服务器无响应时阻塞提醒任务
+func (c *EmailChannel) sendMail(to string, message []byte) error {
+	addr := net.JoinHostPort(c.cfg.SMTPHost, strconv.Itoa(c.cfg.SMTPPort))
+	conn, err := net.DialTimeout("tcp", addr, c.cfg.SMTPTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(c.cfg.SMTPTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	client, err := smtp.NewClient(conn, c.cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.cfg.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if c.cfg.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", c.cfg.SMTPUsername, c.cfg.SMTPPassword, c.cfg.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.cfg.SMTPFrom); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// PushHub 保存在线用户的WebSocket连接，把通知推送给该用户的所有连接
+type PushHub struct {
+	mu          sync.Mutex
+	subscribers map[uint]map[chan []byte]struct{}
+}
+
+func NewPushHub() *PushHub {
+	return &PushHub{subscribers: make(map[uint]map[chan []byte]struct{})}
+}
+
+// 订阅用户的推送消息，连接断开时调用返回的函数取消订阅
+func (h *PushHub) Subscribe(userID uint) (<-chan []byte, func()) {
+	ch := make(chan []byte, pushBufferSize)
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[chan []byte]struct{})
+	}
+	h.subscribers[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			delete(h.subscribers[userID], ch)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			close(ch)
+		})
+	}
+}
+
+func (h *PushHub) Name() string {
+	return PushChannelName
+}
+
+func (h *PushHub) Enabled(setting *models.NotificationSetting) bool {
+	return setting.PushEnabled
+}
+
+// 用户不在线时不推送，之后可以在站内信中查看
+func (h *PushHub) Send(user *models.User, notification *models.Notification) error {
+	data, err := json.Marshal(newNotificationInfo(*notification))
+	if err != nil {
+		return fmt.Errorf("序列化通知失败: %w", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[user.ID] {
+		select {
+		case ch <- data:
+		default:
+			logger.Log.Errorf("推送队列已满，丢弃通知: user=%d notification=%d", user.ID, notification.ID)
+		}
+	}
+	return nil
+}
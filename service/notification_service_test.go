@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestQuietHoursEnd(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// 跨过零点的免打扰时间
+	until, quiet := quietHoursEnd(at(20, 23, 30), "22:00", "07:00")
+	assert.Equal(t, true, quiet)
+	assert.Equal(t, at(21, 7, 0), until)
+
+	until, quiet = quietHoursEnd(at(21, 6, 59), "22:00", "07:00")
+	assert.Equal(t, true, quiet)
+	assert.Equal(t, at(21, 7, 0), until)
+
+	_, quiet = quietHoursEnd(at(21, 7, 0), "22:00", "07:00")
+	assert.Equal(t, false, quiet)
+
+	// 同一天内的免打扰时间
+	until, quiet = quietHoursEnd(at(20, 12, 15), "12:00", "13:30")
+	assert.Equal(t, true, quiet)
+	assert.Equal(t, at(20, 13, 30), until)
+
+	_, quiet = quietHoursEnd(at(20, 11, 59), "12:00", "13:30")
+	assert.Equal(t, false, quiet)
+
+	// 没有开启或格式有误
+	_, quiet = quietHoursEnd(at(20, 23, 0), "", "")
+	assert.Equal(t, false, quiet)
+	_, quiet = quietHoursEnd(at(20, 23, 0), "22:00", "7:00")
+	assert.Equal(t, false, quiet)
+
+	_, err := parseClock("24:00")
+	assert.NotEqual(t, nil, err)
+	minute, err := parseClock("08:05")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 485, minute)
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 播放的媒体类型
+const (
+	MediaTypeMusic   = "music"
+	MediaTypeAmbient = "ambient"
+)
+
+// 单次播放上报的最长时长，防止异常数据
+const maxPlaySeconds = 24 * 60 * 60
+
+type PlayRepository interface {
+	RecordPlay(userID uint, mediaType string, mediaID uint, year, seconds int) error
+	GetTopPlays(userID uint, mediaType string, year, limit int) ([]models.MediaPlayStat, error)
+	GetMusicByID(id uint) (*models.Music, error)
+	GetAmbientSoundByID(id uint) (*models.AmbientSound, error)
+	GetMusicByIDs(ids []uint) ([]models.Music, error)
+	GetAmbientSoundsByIDs(ids []uint) ([]models.AmbientSound, error)
+}
+
+type PlayService struct {
+	repo PlayRepository
+}
+
+func NewPlayService(repo PlayRepository) *PlayService {
+	return &PlayService{repo: repo}
+}
+
+// 记录一次音乐/白噪音播放，用户自己上传的音乐只能由本人上报
+func (s *PlayService) RecordPlay(userID uint, mediaType string, mediaID uint, seconds int, now time.Time) error {
+	if seconds < 0 || seconds > maxPlaySeconds {
+		return errors.New("播放时长不合法")
+	}
+	switch mediaType {
+	case MediaTypeMusic:
+		music, err := s.repo.GetMusicByID(mediaID)
+		if err != nil {
+			return errors.New("音乐不存在")
+		}
+		if music.UploaderID != 0 && music.UploaderID != userID {
+			return errors.New("无权限播放该音乐")
+		}
+	case MediaTypeAmbient:
+		if _, err := s.repo.GetAmbientSoundByID(mediaID); err != nil {
+			return errors.New("白噪音不存在")
+		}
+	default:
+		return errors.New("不支持的媒体类型")
+	}
+	if err := s.repo.RecordPlay(userID, mediaType, mediaID, now.Year(), seconds); err != nil {
+		return fmt.Errorf("记录播放失败: %w", err)
+	}
+	return nil
+}
+
+// 某年最常播放的音乐/白噪音
+func (s *PlayService) GetFavourites(userID uint, mediaType string, year, limit int) ([]MediaPlayInfo, error) {
+	stats, err := s.repo.GetTopPlays(userID, mediaType, year, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询播放记录失败: %w", err)
+	}
+	ids := make([]uint, 0, len(stats))
+	for _, stat := range stats {
+		ids = append(ids, stat.MediaID)
+	}
+	names := make(map[uint]string, len(ids))
+	switch mediaType {
+	case MediaTypeMusic:
+		musics, err := s.repo.GetMusicByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("查询音乐失败: %w", err)
+		}
+		for _, music := range musics {
+			names[music.ID] = music.Title
+		}
+	case MediaTypeAmbient:
+		sounds, err := s.repo.GetAmbientSoundsByIDs(ids)
+		if err != nil {
+			return nil, fmt.Errorf("查询白噪音失败: %w", err)
+		}
+		for _, sound := range sounds {
+			names[sound.ID] = sound.Name
+		}
+	}
+	infos := make([]MediaPlayInfo, 0, len(stats))
+	for _, stat := range stats {
+		// 已删除的音乐不再展示
+		name, ok := names[stat.MediaID]
+		if !ok {
+			continue
+		}
+		infos = append(infos, MediaPlayInfo{
+			ID:          stat.MediaID,
+			Name:        name,
+			PlayCount:   stat.PlayCount,
+			PlaySeconds: stat.PlaySeconds,
+		})
+	}
+	return infos, nil
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"time"
+)
+
+// 对账差异来源
+const (
+	DiscrepancyMonthly      = "monthly"
+	DiscrepancyTotal        = "total"
+	DiscrepancyMonthlyCache = "monthly_cache"
+	DiscrepancyTotalCache   = "total_cache"
+)
+
+type StudyDataReconcileRepository interface {
+	GetStudyDataUserIDs() ([]uint, error)
+	GetAllDailyStudyData(userID uint) ([]models.DailyStudyData, error)
+	GetAllMonthlyStudyData(userID uint) ([]models.MonthlyStudyData, error)
+	GetTotalStudyDataRow(userID uint) (*models.TotalStudyData, error)
+	PeekMonthlyCache(userID uint, month time.Time) (*models.MonthlyStudyData, error)
+	PeekTotalCache(userID uint) (*models.TotalStudyData, error)
+	RepairStudyData(userID uint, monthlyList []models.MonthlyStudyData, total models.TotalStudyData) error
+}
+
+// 对账前先把redis中未落库的数据写入mysql
+type StudyDataFlush interface {
+	Flush() error
+}
+
+type ReconcileService struct {
+	repo    StudyDataReconcileRepository
+	flusher StudyDataFlush
+}
+
+func NewReconcileService(repo StudyDataReconcileRepository, flusher StudyDataFlush) *ReconcileService {
+	return &ReconcileService{
+		repo:    repo,
+		flusher: flusher,
+	}
+}
+
+// 以每日数据为准核对每月、总数据及其redis缓存，userID 为0时核对所有用户
+// repair 为 true 时用每日数据重新计算的结果覆盖有差异的用户
+func (s *ReconcileService) Reconcile(userID uint, repair bool) (*ReconcileReport, error) {
+	if err := s.flusher.Flush(); err != nil {
+		return nil, fmt.Errorf("学习数据落库失败: %w", err)
+	}
+	userIDs := []uint{userID}
+	if userID == 0 {
+		var err error
+		userIDs, err = s.repo.GetStudyDataUserIDs()
+		if err != nil {
+			return nil, fmt.Errorf("查询用户失败: %w", err)
+		}
+	}
+
+	report := &ReconcileReport{Repaired: repair, Discrepancies: []StudyDataDiscrepancy{}}
+	for _, id := range userIDs {
+		discrepancies, monthlyList, total, err := s.reconcileUser(id)
+		if err != nil {
+			return nil, err
+		}
+		report.CheckedUsers++
+		report.Discrepancies = append(report.Discrepancies, discrepancies...)
+		if repair && len(discrepancies) > 0 {
+			if err := s.repo.RepairStudyData(id, monthlyList, total); err != nil {
+				return nil, fmt.Errorf("修复用户%d的学习数据失败: %w", id, err)
+			}
+			report.RepairedUsers++
+		}
+	}
+	return report, nil
+}
+
+func (s *ReconcileService) reconcileUser(userID uint) ([]StudyDataDiscrepancy, []models.MonthlyStudyData, models.TotalStudyData, error) {
+	dailyList, err := s.repo.GetAllDailyStudyData(userID)
+	if err != nil {
+		return nil, nil, models.TotalStudyData{}, fmt.Errorf("查询每日学习数据失败: %w", err)
+	}
+	monthlyList, err := s.repo.GetAllMonthlyStudyData(userID)
+	if err != nil {
+		return nil, nil, models.TotalStudyData{}, fmt.Errorf("查询每月学习数据失败: %w", err)
+	}
+	total, err := s.repo.GetTotalStudyDataRow(userID)
+	if err != nil {
+		return nil, nil, models.TotalStudyData{}, fmt.Errorf("查询总学习数据失败: %w", err)
+	}
+
+	expectedMonthly, expectedTotal := aggregateDailyStudyData(userID, dailyList)
+	discrepancies := diffStudyData(userID, expectedMonthly, expectedTotal, monthlyList, total)
+
+	// 缓存只核对存在的key，缺失的缓存下次读取时会从mysql回源
+	for _, expected := range mergeMonths(expectedMonthly, monthlyList) {
+		cached, err := s.repo.PeekMonthlyCache(userID, expected.Month)
+		if err != nil {
+			return nil, nil, models.TotalStudyData{}, fmt.Errorf("查询每月缓存失败: %w", err)
+		}
+		if cached != nil && (cached.StudyTime != expected.StudyTime || cached.Tomatoes != expected.Tomatoes) {
+			discrepancies = append(discrepancies, newDiscrepancy(userID, DiscrepancyMonthlyCache, expected.Month.Format("2006-01"),
+				expected.StudyTime, expected.Tomatoes, cached.StudyTime, cached.Tomatoes))
+		}
+	}
+	cachedTotal, err := s.repo.PeekTotalCache(userID)
+	if err != nil {
+		return nil, nil, models.TotalStudyData{}, fmt.Errorf("查询总数据缓存失败: %w", err)
+	}
+	if cachedTotal != nil && (cachedTotal.StudyTime != expectedTotal.StudyTime || cachedTotal.Tomatoes != expectedTotal.Tomatoes) {
+		discrepancies = append(discrepancies, newDiscrepancy(userID, DiscrepancyTotalCache, "",
+			expectedTotal.StudyTime, expectedTotal.Tomatoes, cachedTotal.StudyTime, cachedTotal.Tomatoes))
+	}
+	return discrepancies, expectedMonthly, expectedTotal, nil
+}
+
+// 由每日数据汇总出每月、总数据，每月结果按月份升序
+func aggregateDailyStudyData(userID uint, dailyList []models.DailyStudyData) ([]models.MonthlyStudyData, models.TotalStudyData) {
+	total := models.TotalStudyData{UserID: userID}
+	monthlyList := make([]models.MonthlyStudyData, 0)
+	index := make(map[string]int)
+	for _, daily := range dailyList {
+		total.StudyTime += daily.StudyTime
+		total.Tomatoes += daily.Tomatoes
+
+		key := daily.Date.Format("2006-01")
+		i, ok := index[key]
+		if !ok {
+			i = len(monthlyList)
+			index[key] = i
+			monthlyList = append(monthlyList, models.MonthlyStudyData{
+				UserID: userID,
+				Month:  time.Date(daily.Date.Year(), daily.Date.Month(), 1, 0, 0, 0, 0, daily.Date.Location()),
+			})
+		}
+		monthlyList[i].StudyTime += daily.StudyTime
+		monthlyList[i].Tomatoes += daily.Tomatoes
+	}
+	return monthlyList, total
+}
+
+// 比较重新计算的结果与mysql中的每月、总数据，缺失的记录按0处理
+func diffStudyData(userID uint, expectedMonthly []models.MonthlyStudyData, expectedTotal models.TotalStudyData, actualMonthly []models.MonthlyStudyData, actualTotal *models.TotalStudyData) []StudyDataDiscrepancy {
+	discrepancies := make([]StudyDataDiscrepancy, 0)
+	actual := make(map[string]models.MonthlyStudyData, len(actualMonthly))
+	for _, monthly := range actualMonthly {
+		actual[monthly.Month.Format("2006-01")] = monthly
+	}
+	expected := make(map[string]models.MonthlyStudyData, len(expectedMonthly))
+	for _, monthly := range expectedMonthly {
+		expected[monthly.Month.Format("2006-01")] = monthly
+	}
+
+	for _, monthly := range mergeMonths(expectedMonthly, actualMonthly) {
+		key := monthly.Month.Format("2006-01")
+		want, got := expected[key], actual[key]
+		if want.StudyTime != got.StudyTime || want.Tomatoes != got.Tomatoes {
+			discrepancies = append(discrepancies, newDiscrepancy(userID, DiscrepancyMonthly, key,
+				want.StudyTime, want.Tomatoes, got.StudyTime, got.Tomatoes))
+		}
+	}
+
+	got := models.TotalStudyData{}
+	if actualTotal != nil {
+		got = *actualTotal
+	}
+	if expectedTotal.StudyTime != got.StudyTime || expectedTotal.Tomatoes != got.Tomatoes {
+		discrepancies = append(discrepancies, newDiscrepancy(userID, DiscrepancyTotal, "",
+			expectedTotal.StudyTime, expectedTotal.Tomatoes, got.StudyTime, got.Tomatoes))
+	}
+	return discrepancies
+}
+
+// 合并两组月份，保留 expected 中的值，只在 actual 中出现的月份按0处理
+func mergeMonths(expected, actual []models.MonthlyStudyData) []models.MonthlyStudyData {
+	merged := make([]models.MonthlyStudyData, 0, len(expected)+len(actual))
+	seen := make(map[string]bool)
+	merged = append(merged, expected...)
+	for _, monthly := range expected {
+		seen[monthly.Month.Format("2006-01")] = true
+	}
+	for _, monthly := range actual {
+		if !seen[monthly.Month.Format("2006-01")] {
+			merged = append(merged, models.MonthlyStudyData{UserID: monthly.UserID, Month: monthly.Month})
+		}
+	}
+	return merged
+}
+
+func newDiscrepancy(userID uint, source, period string, expectedStudyTime, expectedTomatoes, actualStudyTime, actualTomatoes int) StudyDataDiscrepancy {
+	return StudyDataDiscrepancy{
+		UserID:            userID,
+		Source:            source,
+		Period:            period,
+		ExpectedStudyTime: expectedStudyTime,
+		ExpectedTomatoes:  expectedTomatoes,
+		ActualStudyTime:   actualStudyTime,
+		ActualTomatoes:    actualTomatoes,
+	}
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestDiffStudyData(t *testing.T) {
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+	}
+	dailyList := []models.DailyStudyData{
+		{Date: day(1, 1), StudyTime: 30, Tomatoes: 1},
+		{Date: day(1, 2), StudyTime: 50, Tomatoes: 2},
+		{Date: day(2, 1), StudyTime: 25, Tomatoes: 1},
+	}
+	expectedMonthly, expectedTotal := aggregateDailyStudyData(1, dailyList)
+
+	tests := []struct {
+		name    string
+		monthly []models.MonthlyStudyData
+		total   *models.TotalStudyData
+		want    []string
+	}{
+		{
+			name: "数据一致",
+			monthly: []models.MonthlyStudyData{
+				{Month: day(1, 1), StudyTime: 80, Tomatoes: 3},
+				{Month: day(2, 1), StudyTime: 25, Tomatoes: 1},
+			},
+			total: &models.TotalStudyData{StudyTime: 105, Tomatoes: 4},
+			want:  []string{},
+		},
+		{
+			name: "每月数据偏大且多出月份",
+			monthly: []models.MonthlyStudyData{
+				{Month: day(1, 1), StudyTime: 90, Tomatoes: 3},
+				{Month: day(2, 1), StudyTime: 25, Tomatoes: 1},
+				{Month: day(3, 1), StudyTime: 10, Tomatoes: 0},
+			},
+			total: &models.TotalStudyData{StudyTime: 105, Tomatoes: 4},
+			want:  []string{"monthly:2026-01", "monthly:2026-03"},
+		},
+		{
+			name: "缺少每月和总数据",
+			monthly: []models.MonthlyStudyData{
+				{Month: day(1, 1), StudyTime: 80, Tomatoes: 3},
+			},
+			total: nil,
+			want:  []string{"monthly:2026-02", "total:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range diffStudyData(1, expectedMonthly, expectedTotal, tt.monthly, tt.total) {
+				got = append(got, d.Source+":"+d.Period)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
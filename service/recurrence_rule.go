@@ -0,0 +1,311 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 重复规则的频率
+const (
+	RecurrenceDaily   = "DAILY"
+	RecurrenceWeekly  = "WEEKLY"
+	RecurrenceMonthly = "MONTHLY"
+	RecurrenceYearly  = "YEARLY"
+)
+
+// 查找下一次重复时最多向后查找的天数
+const maxRecurrenceSearchDays = 366 * 5
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+var rruleWeekdayNames = []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// recurrenceRule RFC 5545 RRULE 的子集，支持 FREQ、INTERVAL、BYDAY（不带序号）、BYMONTHDAY、COUNT、UNTIL
+type recurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int // 负数表示倒数第几天，-1 为当月最后一天
+	Count      int
+	Until      *time.Time
+}
+
+// 解析 RRULE，不带时区的 UNTIL 按 loc 解释
+func parseRecurrenceRule(rrule string, loc *time.Location) (*recurrenceRule, error) {
+	rule := &recurrenceRule{Interval: 1}
+	rrule = strings.TrimPrefix(strings.TrimSpace(rrule), "RRULE:")
+	for _, part := range strings.Split(rrule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("重复规则格式有误: %s", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(value)
+			switch rule.Freq {
+			case RecurrenceDaily, RecurrenceWeekly, RecurrenceMonthly, RecurrenceYearly:
+			default:
+				return nil, fmt.Errorf("不支持的重复频率: %s", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 || interval > 366 {
+				return nil, fmt.Errorf("重复间隔有误: %s", value)
+			}
+			rule.Interval = interval
+		case "BYDAY":
+			for _, name := range strings.Split(strings.ToUpper(value), ",") {
+				weekday, ok := rruleWeekdays[name]
+				if !ok {
+					return nil, fmt.Errorf("不支持的星期: %s", name)
+				}
+				if !slices.Contains(rule.ByDay, weekday) {
+					rule.ByDay = append(rule.ByDay, weekday)
+				}
+			}
+		case "BYMONTHDAY":
+			for _, text := range strings.Split(value, ",") {
+				day, err := strconv.Atoi(text)
+				if err != nil || day == 0 || day < -31 || day > 31 {
+					return nil, fmt.Errorf("日期有误: %s", text)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, day)
+			}
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("重复次数有误: %s", value)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRRuleTime(value, loc)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+		default:
+			return nil, fmt.Errorf("不支持的重复规则: %s", key)
+		}
+	}
+	if rule.Freq == "" {
+		return nil, errors.New("重复规则缺少FREQ")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT 和 UNTIL 不能同时使用")
+	}
+	slices.Sort(rule.ByDay)
+	return rule, nil
+}
+
+// UNTIL 支持 YYYYMMDD（当天结束前都有效）和 YYYYMMDDTHHMMSSZ
+func parseRRuleTime(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("20060102", value, loc); err == nil {
+		return t.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("结束时间格式有误: %s", value)
+}
+
+// 规范化后的 RRULE 文本
+func (r *recurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		names := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			names = append(names, rruleWeekdayNames[weekday])
+		}
+		parts = append(parts, "BYDAY="+strings.Join(names, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// day（当天零点）是否符合以 start 为起点的规则
+func (r *recurrenceRule) matches(start, day time.Time) bool {
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, day.Location())
+	if day.Before(startDay) {
+		return false
+	}
+	switch r.Freq {
+	case RecurrenceDaily:
+		days := daysBetween(startDay, day)
+		return days%r.Interval == 0 && (len(r.ByDay) == 0 || slices.Contains(r.ByDay, day.Weekday()))
+	case RecurrenceWeekly:
+		weeks := daysBetween(weekMonday(startDay), weekMonday(day)) / 7
+		if weeks%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByDay) == 0 {
+			return day.Weekday() == start.Weekday()
+		}
+		return slices.Contains(r.ByDay, day.Weekday())
+	case RecurrenceMonthly:
+		months := (day.Year()-startDay.Year())*12 + int(day.Month()-startDay.Month())
+		if months%r.Interval != 0 {
+			return false
+		}
+		if len(r.ByMonthDay) > 0 {
+			return matchesMonthDay(r.ByMonthDay, day)
+		}
+		if len(r.ByDay) > 0 {
+			return slices.Contains(r.ByDay, day.Weekday())
+		}
+		return day.Day() == start.Day()
+	case RecurrenceYearly:
+		years := day.Year() - startDay.Year()
+		return years%r.Interval == 0 && day.Month() == start.Month() && day.Day() == start.Day()
+	}
+	return false
+}
+
+// 第一次出现的时间，start 本身符合规则时就是 start
+func (r *recurrenceRule) first(start time.Time) (time.Time, bool) {
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	if r.matches(start, day) {
+		return start, true
+	}
+	return r.next(start, start)
+}
+
+// after 之后的下一次出现时间，规则已结束时返回false
+// 每次出现的时刻与 start 相同
+func (r *recurrenceRule) next(start, after time.Time) (time.Time, bool) {
+	loc := start.Location()
+	after = after.In(loc)
+	// 有次数限制时需要从头数，否则直接从 after 当天开始找
+	from := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, loc)
+	if r.Count > 0 || from.Before(start) {
+		from = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	}
+	limit := time.Date(after.Year(), after.Month(), after.Day()+maxRecurrenceSearchDays, 0, 0, 0, 0, loc)
+	count := 0
+	for day := from; !day.After(limit); day = day.AddDate(0, 0, 1) {
+		if !r.matches(start, day) {
+			continue
+		}
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+		if occurrence.Before(start) {
+			continue
+		}
+		count++
+		if r.Count > 0 && count > r.Count {
+			return time.Time{}, false
+		}
+		if r.Until != nil && occurrence.After(*r.Until) {
+			return time.Time{}, false
+		}
+		if occurrence.After(after) {
+			return occurrence, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// after 之后、before 之前最晚的一次出现时间，用于跳过错过的重复
+func (r *recurrenceRule) latestBefore(start, after, before time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for {
+		occurrence, ok := r.next(start, after)
+		if !ok || !occurrence.Before(before) {
+			return latest, found
+		}
+		latest, found = occurrence, true
+		after = occurrence
+	}
+}
+
+// before 之前已经出现的次数，用于重新设置起点时扣除已用掉的 COUNT
+func (r *recurrenceRule) countBefore(start, before time.Time) int {
+	count := 0
+	for occurrence, ok := r.first(start); ok && occurrence.Before(before); occurrence, ok = r.next(start, occurrence) {
+		count++
+	}
+	return count
+}
+
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+func weekMonday(day time.Time) time.Time {
+	weekday := int(day.Weekday())
+	if weekday == 0 {
+		weekday = 7
+	}
+	return day.AddDate(0, 0, 1-weekday)
+}
+
+func matchesMonthDay(monthDays []int, day time.Time) bool {
+	lastDay := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, monthDay := range monthDays {
+		if monthDay > 0 && day.Day() == monthDay {
+			return true
+		}
+		if monthDay < 0 && day.Day() == lastDay+1+monthDay {
+			return true
+		}
+	}
+	return false
+}
+
+// 常用的重复方式转换为 RRULE，days 为周几（1为周一，7为周日）
+func recurrenceRuleFromPreset(preset string, days []int, rrule string, start time.Time) (*recurrenceRule, error) {
+	switch preset {
+	case "daily":
+		return &recurrenceRule{Freq: RecurrenceDaily, Interval: 1}, nil
+	case "weekdays":
+		return &recurrenceRule{Freq: RecurrenceWeekly, Interval: 1, ByDay: []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}}, nil
+	case "weekly":
+		rule := &recurrenceRule{Freq: RecurrenceWeekly, Interval: 1}
+		for _, day := range days {
+			if day < 1 || day > 7 {
+				return nil, errors.New("星期应为1~7")
+			}
+			weekday := time.Weekday(day % 7)
+			if !slices.Contains(rule.ByDay, weekday) {
+				rule.ByDay = append(rule.ByDay, weekday)
+			}
+		}
+		if len(rule.ByDay) == 0 {
+			rule.ByDay = []time.Weekday{start.Weekday()}
+		}
+		slices.Sort(rule.ByDay)
+		return rule, nil
+	case "monthly":
+		return &recurrenceRule{Freq: RecurrenceMonthly, Interval: 1, ByMonthDay: []int{start.Day()}}, nil
+	case "rrule":
+		return parseRecurrenceRule(rrule, start.Location())
+	default:
+		return nil, errors.New("不支持的重复方式")
+	}
+}
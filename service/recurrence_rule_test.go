@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	loc := time.UTC
+	at := func(m time.Month, d, h int) time.Time {
+		return time.Date(2026, m, d, h, 0, 0, 0, loc)
+	}
+	// 2026年10月19日为周一
+	start := at(10, 19, 20)
+	tests := []struct {
+		name  string
+		rrule string
+		after time.Time
+		want  time.Time
+		ok    bool
+	}{
+		{"每天", "FREQ=DAILY", start, at(10, 20, 20), true},
+		{"同一天的截止时刻之前", "FREQ=DAILY", at(10, 20, 8), at(10, 20, 20), true},
+		{"每两天", "FREQ=DAILY;INTERVAL=2", start, at(10, 21, 20), true},
+		{"工作日跳过周末", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", at(10, 23, 20), at(10, 26, 20), true},
+		{"隔周的周三", "FREQ=WEEKLY;INTERVAL=2;BYDAY=WE", at(10, 21, 20), at(11, 4, 20), true},
+		{"每月最后一天", "FREQ=MONTHLY;BYMONTHDAY=-1", start, at(10, 31, 20), true},
+		{"每月31号跳过没有31号的月份", "FREQ=MONTHLY;BYMONTHDAY=31", at(10, 31, 20), at(12, 31, 20), true},
+		{"次数用完", "FREQ=DAILY;COUNT=3", at(10, 21, 20), time.Time{}, false},
+		{"次数未用完", "FREQ=DAILY;COUNT=3", at(10, 20, 20), at(10, 21, 20), true},
+		{"超过结束日期", "FREQ=DAILY;UNTIL=20261020", at(10, 20, 20), time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRecurrenceRule(tt.rrule, loc)
+			assert.Equal(t, nil, err)
+			got, ok := rule.next(start, tt.after)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRecurrenceRuleCountBefore(t *testing.T) {
+	loc := time.UTC
+	at := func(m time.Month, d, h int) time.Time {
+		return time.Date(2026, m, d, h, 0, 0, 0, loc)
+	}
+	start := at(10, 19, 20)
+	tests := []struct {
+		name   string
+		rrule  string
+		before time.Time
+		want   int
+	}{
+		{"第一次之前", "FREQ=DAILY;COUNT=5", start, 0},
+		{"第三次之前", "FREQ=DAILY;COUNT=5", at(10, 21, 20), 2},
+		{"次数用完后不再增加", "FREQ=DAILY;COUNT=3", at(10, 30, 20), 3},
+		{"工作日", "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR", at(10, 27, 20), 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := parseRecurrenceRule(tt.rrule, loc)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.want, rule.countBefore(start, tt.before))
+		})
+	}
+}
+
+func TestParseRecurrenceRule(t *testing.T) {
+	rule, err := parseRecurrenceRule("RRULE:freq=weekly;byday=FR,MO;interval=1", time.UTC)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "FREQ=WEEKLY;BYDAY=MO,FR", rule.String())
+
+	_, err = parseRecurrenceRule("FREQ=HOURLY", time.UTC)
+	assert.NotEqual(t, nil, err)
+	_, err = parseRecurrenceRule("FREQ=MONTHLY;BYDAY=1MO", time.UTC)
+	assert.NotEqual(t, nil, err)
+	_, err = parseRecurrenceRule("FREQ=DAILY;COUNT=3;UNTIL=20261231", time.UTC)
+	assert.NotEqual(t, nil, err)
+}
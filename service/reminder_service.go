@@ -0,0 +1,320 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 待办提醒状态
+const (
+	ReminderStatusPending = "pending"
+	ReminderStatusSending = "sending"
+	ReminderStatusSent    = "sent"
+	ReminderStatusSkipped = "skipped"
+)
+
+type ReminderRepository interface {
+	CreateTodoReminder(reminder *models.TodoReminder) error
+	GetTodoReminderByID(id uint) (*models.TodoReminder, error)
+	GetTodoReminders(todoID uint) ([]models.TodoReminder, error)
+	DeleteTodoReminder(id uint) error
+	UpdateTodoReminder(reminder *models.TodoReminder) error
+	GetRecurrenceReminderOffsets(recurrenceID uint, before time.Time) ([]int, error)
+	GetDueReminders(status string, now time.Time, limit int) ([]models.TodoReminder, error)
+	ClaimReminder(id uint, remindAt time.Time, fromStatus, toStatus string, sentAt *time.Time) (bool, error)
+	ClaimReminderForSending(id uint, remindAt, now time.Time) (bool, error)
+	ReleaseStaleReminders(before time.Time) (int64, error)
+	DeferReminder(id uint, remindAt, until time.Time) (bool, error)
+}
+
+// 提醒时需要查询的待办
+type ReminderTodoRepository interface {
+	GetTodoByID(id uint) (*models.Todo, error)
+	GetDeletedTodoByID(id uint) (*models.Todo, error)
+}
+
+type Notifier interface {
+	Notify(userID uint, notification *models.Notification) error
+	QuietUntil(userID uint, now time.Time) (time.Time, bool, error)
+}
+
+type ReminderService struct {
+	repo     ReminderRepository
+	todos    ReminderTodoRepository
+	lists    TodoAccessRepository
+	notifier Notifier
+	cfg      *config.NotificationConfig
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewReminderService(repo ReminderRepository, todos ReminderTodoRepository, lists TodoAccessRepository, notifier Notifier, cfg *config.NotificationConfig) *ReminderService {
+	return &ReminderService{
+		repo:     repo,
+		todos:    todos,
+		lists:    lists,
+		notifier: notifier,
+		cfg:      cfg,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// 启动定时任务，发送到期的待办提醒
+func (s *ReminderService) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.cfg.ReminderInterval)
+		defer ticker.Stop()
+		for {
+			s.runScheduled(reportNow())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *ReminderService) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *ReminderService) runScheduled(now time.Time) {
+	count, err := s.SendDueReminders(now)
+	if err != nil {
+		logger.Log.Errorf("发送待办提醒失败: %v", err)
+		return
+	}
+	if count > 0 {
+		logger.Log.Infof("已发送%d条待办提醒", count)
+	}
+}
+
+// 发送到期的提醒，单条提醒失败只记录日志，返回发送的数量
+func (s *ReminderService) SendDueReminders(now time.Time) (int, error) {
+	// 进程在发送过程中退出或标记已发送失败时，提醒会停留在发送中，超时后重新发送
+	released, err := s.repo.ReleaseStaleReminders(now.Add(-s.cfg.ReminderSendTimeout))
+	if err != nil {
+		logger.Log.Errorf("恢复发送超时的提醒失败: %v", err)
+	} else if released > 0 {
+		logger.Log.Warnf("%d条提醒发送超时，已恢复为待发送", released)
+	}
+	reminders, err := s.repo.GetDueReminders(ReminderStatusPending, now, s.cfg.ReminderBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("查询到期提醒失败: %w", err)
+	}
+	count := 0
+	for _, reminder := range reminders {
+		sent, err := s.sendReminder(reminder, now)
+		if err != nil {
+			logger.Log.Errorf("发送待办提醒失败: reminder=%d err=%v", reminder.ID, err)
+			continue
+		}
+		if sent {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// 待办已完成、已删除或用户已经不能访问时不再提醒，用户处于免打扰时间时推迟到免打扰结束
+func (s *ReminderService) sendReminder(reminder models.TodoReminder, now time.Time) (bool, error) {
+	todo, err := s.todos.GetTodoByID(reminder.TodoID)
+	if err != nil {
+		if _, deletedErr := s.todos.GetDeletedTodoByID(reminder.TodoID); deletedErr != nil {
+			return false, fmt.Errorf("查询待办事项失败: %w", err)
+		}
+		todo = nil
+	}
+	if todo != nil && checkTodoAccess(s.todos, s.lists, reminder.UserID, todo, false) != nil {
+		todo = nil
+	}
+	if todo == nil || todo.Completed || todo.DueAt == nil {
+		_, err := s.repo.ClaimReminder(reminder.ID, reminder.RemindAt, ReminderStatusPending, ReminderStatusSkipped, nil)
+		return false, err
+	}
+	until, quiet, err := s.notifier.QuietUntil(reminder.UserID, now)
+	if err != nil {
+		return false, err
+	}
+	if quiet {
+		_, err := s.repo.DeferReminder(reminder.ID, reminder.RemindAt, until)
+		return false, err
+	}
+	// 先标记为发送中，多个实例同时运行时只有一个会发送
+	claimed, err := s.repo.ClaimReminderForSending(reminder.ID, reminder.RemindAt, now)
+	if err != nil || !claimed {
+		return false, err
+	}
+	// 发送失败时恢复为待发送，下次检查时重试
+	if err := s.notifier.Notify(reminder.UserID, newReminderNotification(todo, reminder.OffsetMinutes)); err != nil {
+		if _, resetErr := s.repo.ClaimReminder(reminder.ID, reminder.RemindAt, ReminderStatusSending, ReminderStatusPending, nil); resetErr != nil {
+			return false, errors.Join(err, fmt.Errorf("恢复提醒状态失败: %w", resetErr))
+		}
+		return false, err
+	}
+	if _, err := s.repo.ClaimReminder(reminder.ID, reminder.RemindAt, ReminderStatusSending, ReminderStatusSent, &now); err != nil {
+		return true, fmt.Errorf("标记提醒已发送失败: %w", err)
+	}
+	return true, nil
+}
+
+func newReminderNotification(todo *models.Todo, offsetMinutes int) *models.Notification {
+	title := "待办即将到期：" + todo.Event
+	if offsetMinutes == 0 {
+		title = "待办已到期：" + todo.Event
+	}
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	content := fmt.Sprintf("「%s」的截止时间为 %s", todo.Event, todo.DueAt.In(loc).Format("2006-01-02 15:04"))
+	if todo.Description != "" {
+		content += "\n\n" + todo.Description
+	}
+	return &models.Notification{
+		Type:    NotificationTypeTodoReminder,
+		Title:   title,
+		Content: content,
+		TodoID:  &todo.ID,
+	}
+}
+
+// 获取待办的所有提醒
+func (s *ReminderService) GetTodoReminders(userID, todoID uint) ([]TodoReminderInfo, error) {
+	if _, err := getAccessibleTodo(s.todos, s.lists, userID, todoID, false); err != nil {
+		return nil, err
+	}
+	reminders, err := s.repo.GetTodoReminders(todoID)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	infos := make([]TodoReminderInfo, 0, len(reminders))
+	for _, reminder := range reminders {
+		infos = append(infos, newTodoReminderInfo(reminder))
+	}
+	return infos, nil
+}
+
+// 为待办添加提醒，在截止时间前 offsetMinutes 分钟提醒，0表示截止时提醒
+func (s *ReminderService) AddTodoReminder(userID, todoID uint, offsetMinutes int) (*TodoReminderInfo, error) {
+	todo, err := getAccessibleTodo(s.todos, s.lists, userID, todoID, true)
+	if err != nil {
+		return nil, err
+	}
+	if todo.DueAt == nil {
+		return nil, errors.New("请先设置截止时间")
+	}
+	if offsetMinutes < 0 || offsetMinutes > s.cfg.MaxOffsetMinutes {
+		return nil, fmt.Errorf("提前提醒时间需要在0到%d分钟之间", s.cfg.MaxOffsetMinutes)
+	}
+	reminders, err := s.repo.GetTodoReminders(todoID)
+	if err != nil {
+		return nil, fmt.Errorf("查询提醒失败: %w", err)
+	}
+	if len(reminders) >= s.cfg.MaxRemindersPerTodo {
+		return nil, fmt.Errorf("每个待办最多设置%d个提醒", s.cfg.MaxRemindersPerTodo)
+	}
+	for _, reminder := range reminders {
+		if reminder.OffsetMinutes == offsetMinutes {
+			return nil, errors.New("已经设置过相同的提醒")
+		}
+	}
+	reminder := &models.TodoReminder{
+		UserID:        userID,
+		TodoID:        todoID,
+		OffsetMinutes: offsetMinutes,
+		RemindAt:      reminderTime(*todo.DueAt, offsetMinutes),
+		Status:        ReminderStatusPending,
+	}
+	if err := s.repo.CreateTodoReminder(reminder); err != nil {
+		return nil, fmt.Errorf("创建提醒失败: %w", err)
+	}
+	info := newTodoReminderInfo(*reminder)
+	return &info, nil
+}
+
+func (s *ReminderService) DeleteTodoReminder(userID, reminderID uint) error {
+	reminder, err := s.repo.GetTodoReminderByID(reminderID)
+	if err != nil {
+		return errors.New("提醒不存在")
+	}
+	if _, err := getAccessibleTodo(s.todos, s.lists, userID, reminder.TodoID, true); err != nil {
+		return errors.New("无权限删除该提醒")
+	}
+	if err := s.repo.DeleteTodoReminder(reminderID); err != nil {
+		return fmt.Errorf("删除提醒失败: %w", err)
+	}
+	return nil
+}
+
+// 待办的截止时间修改后重新计算提醒时间，新的提醒时间还没到的提醒会再次发送
+// 清除截止时间后还未发送的提醒不再发送
+func (s *ReminderService) SyncTodoReminders(todo *models.Todo, now time.Time) error {
+	reminders, err := s.repo.GetTodoReminders(todo.ID)
+	if err != nil {
+		return fmt.Errorf("查询提醒失败: %w", err)
+	}
+	for i := range reminders {
+		reminder := &reminders[i]
+		if todo.DueAt == nil {
+			if reminder.Status != ReminderStatusPending {
+				continue
+			}
+			reminder.Status = ReminderStatusSkipped
+		} else {
+			reminder.RemindAt = reminderTime(*todo.DueAt, reminder.OffsetMinutes)
+			if reminder.RemindAt.After(now) {
+				reminder.Status = ReminderStatusPending
+				reminder.SentAt = nil
+			}
+		}
+		if err := s.repo.UpdateTodoReminder(reminder); err != nil {
+			return fmt.Errorf("更新提醒失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// 为新生成的一次重复待办设置与上一次相同的提醒
+func (s *ReminderService) CopyRecurrenceReminders(todo *models.Todo) error {
+	if todo.RecurrenceID == nil || todo.OccurrenceAt == nil || todo.DueAt == nil {
+		return nil
+	}
+	offsets, err := s.repo.GetRecurrenceReminderOffsets(*todo.RecurrenceID, *todo.OccurrenceAt)
+	if err != nil {
+		return fmt.Errorf("查询上一次的提醒失败: %w", err)
+	}
+	for _, offset := range offsets {
+		reminder := &models.TodoReminder{
+			UserID:        todo.UserID,
+			TodoID:        todo.ID,
+			OffsetMinutes: offset,
+			RemindAt:      reminderTime(*todo.DueAt, offset),
+			Status:        ReminderStatusPending,
+		}
+		if err := s.repo.CreateTodoReminder(reminder); err != nil {
+			return fmt.Errorf("创建提醒失败: %w", err)
+		}
+	}
+	return nil
+}
+
+func reminderTime(dueAt time.Time, offsetMinutes int) time.Time {
+	return dueAt.Add(-time.Duration(offsetMinutes) * time.Minute)
+}
+
+func newTodoReminderInfo(reminder models.TodoReminder) TodoReminderInfo {
+	return TodoReminderInfo{
+		ID:            reminder.ID,
+		TodoID:        reminder.TodoID,
+		OffsetMinutes: reminder.OffsetMinutes,
+		RemindAt:      reminder.RemindAt,
+		Status:        reminder.Status,
+		SentAt:        reminder.SentAt,
+	}
+}
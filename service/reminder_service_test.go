@@ -0,0 +1,199 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+type memoryReminderRepository struct {
+	ReminderRepository
+	reminders []models.TodoReminder
+}
+
+func (r *memoryReminderRepository) GetDueReminders(status string, now time.Time, limit int) ([]models.TodoReminder, error) {
+	var due []models.TodoReminder
+	for _, reminder := range r.reminders {
+		if reminder.Status == status && !reminder.RemindAt.After(now) && len(due) < limit {
+			due = append(due, reminder)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryReminderRepository) ClaimReminder(id uint, remindAt time.Time, fromStatus, toStatus string, sentAt *time.Time) (bool, error) {
+	for i := range r.reminders {
+		reminder := &r.reminders[i]
+		if reminder.ID == id && reminder.Status == fromStatus && reminder.RemindAt.Equal(remindAt) {
+			reminder.Status = toStatus
+			reminder.SentAt = sentAt
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryReminderRepository) ClaimReminderForSending(id uint, remindAt, now time.Time) (bool, error) {
+	claimed, err := r.ClaimReminder(id, remindAt, ReminderStatusPending, ReminderStatusSending, nil)
+	for i := range r.reminders {
+		if claimed && r.reminders[i].ID == id {
+			r.reminders[i].ClaimedAt = &now
+		}
+	}
+	return claimed, err
+}
+
+func (r *memoryReminderRepository) ReleaseStaleReminders(before time.Time) (int64, error) {
+	var released int64
+	for i := range r.reminders {
+		reminder := &r.reminders[i]
+		if reminder.Status == ReminderStatusSending && reminder.ClaimedAt.Before(before) {
+			reminder.Status = ReminderStatusPending
+			reminder.ClaimedAt = nil
+			released++
+		}
+	}
+	return released, nil
+}
+
+func (r *memoryReminderRepository) DeferReminder(id uint, remindAt, until time.Time) (bool, error) {
+	for i := range r.reminders {
+		reminder := &r.reminders[i]
+		if reminder.ID == id && reminder.RemindAt.Equal(remindAt) {
+			reminder.RemindAt = until
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryReminderTodoRepository struct {
+	todos map[uint]*models.Todo
+}
+
+func (r *memoryReminderTodoRepository) GetTodoByID(id uint) (*models.Todo, error) {
+	if todo, ok := r.todos[id]; ok {
+		return todo, nil
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *memoryReminderTodoRepository) GetDeletedTodoByID(id uint) (*models.Todo, error) {
+	return nil, errors.New("record not found")
+}
+
+type memoryNotifier struct {
+	quietUntil time.Time
+	err        error
+	sent       []*models.Notification
+}
+
+func (n *memoryNotifier) Notify(userID uint, notification *models.Notification) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func (n *memoryNotifier) QuietUntil(userID uint, now time.Time) (time.Time, bool, error) {
+	return n.quietUntil, n.quietUntil.After(now), nil
+}
+
+func TestSendDueReminders(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+	dueAt := now.Add(30 * time.Minute)
+	newService := func(notifier *memoryNotifier) (*ReminderService, *memoryReminderRepository) {
+		repo := &memoryReminderRepository{reminders: []models.TodoReminder{
+			{ID: 1, UserID: 1, TodoID: 1, OffsetMinutes: 30, RemindAt: now, Status: ReminderStatusPending},
+			{ID: 2, UserID: 1, TodoID: 2, OffsetMinutes: 0, RemindAt: now.Add(-time.Minute), Status: ReminderStatusPending},
+			{ID: 3, UserID: 1, TodoID: 1, OffsetMinutes: 10, RemindAt: now.Add(20 * time.Minute), Status: ReminderStatusPending},
+		}}
+		todos := &memoryReminderTodoRepository{todos: map[uint]*models.Todo{
+			1: {ID: 1, UserID: 1, Event: "写报告", DueAt: &dueAt},
+			2: {ID: 2, UserID: 1, Event: "跑步", DueAt: &now, Completed: true},
+		}}
+		cfg := &config.NotificationConfig{ReminderBatchSize: 10, ReminderSendTimeout: 10 * time.Minute}
+		return NewReminderService(repo, todos, nil, notifier, cfg), repo
+	}
+
+	// 到期的提醒发送后标记为已发送，已完成的待办跳过，未到期的不处理
+	notifier := &memoryNotifier{}
+	service, repo := newService(notifier)
+	count, err := service.SendDueReminders(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 1, len(notifier.sent))
+	assert.Equal(t, "待办即将到期：写报告", notifier.sent[0].Title)
+	assert.Equal(t, ReminderStatusSent, repo.reminders[0].Status)
+	assert.Equal(t, now, *repo.reminders[0].SentAt)
+	assert.Equal(t, ReminderStatusSkipped, repo.reminders[1].Status)
+	assert.Equal(t, ReminderStatusPending, repo.reminders[2].Status)
+
+	// 已发送的提醒不会重复发送
+	count, err = service.SendDueReminders(now.Add(time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, len(notifier.sent))
+
+	// 查询之后被其它实例抢先处理的提醒不会再发送
+	notifier = &memoryNotifier{}
+	service, repo = newService(notifier)
+	due, _ := repo.GetDueReminders(ReminderStatusPending, now, 10)
+	repo.reminders[0].Status = ReminderStatusSending
+	repo.reminders[0].ClaimedAt = &now
+	sent, err := service.sendReminder(due[0], now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, sent)
+	assert.Equal(t, 0, len(notifier.sent))
+
+	// 发送中的提醒未超时时不重新发送，超时后恢复为待发送并重新发送
+	count, err = service.SendDueReminders(now.Add(5 * time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, ReminderStatusSending, repo.reminders[0].Status)
+	count, err = service.SendDueReminders(now.Add(11 * time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, ReminderStatusSent, repo.reminders[0].Status)
+	assert.Equal(t, 1, len(notifier.sent))
+
+	// 发送失败时恢复为待发送，下次检查时重试
+	notifier = &memoryNotifier{err: errors.New("保存站内信失败")}
+	service, repo = newService(notifier)
+	count, err = service.SendDueReminders(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, ReminderStatusPending, repo.reminders[0].Status)
+	assert.Equal(t, (*time.Time)(nil), repo.reminders[0].SentAt)
+
+	notifier.err = nil
+	count, err = service.SendDueReminders(now.Add(time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, ReminderStatusSent, repo.reminders[0].Status)
+
+	// 免打扰时间内推迟到免打扰结束
+	quietEnd := now.Add(2 * time.Hour)
+	notifier = &memoryNotifier{quietUntil: quietEnd}
+	service, repo = newService(notifier)
+	count, err = service.SendDueReminders(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 0, len(notifier.sent))
+	assert.Equal(t, ReminderStatusPending, repo.reminders[0].Status)
+	assert.Equal(t, quietEnd, repo.reminders[0].RemindAt)
+
+	count, err = service.SendDueReminders(quietEnd)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, ReminderStatusSent, repo.reminders[0].Status)
+	assert.Equal(t, ReminderStatusSent, repo.reminders[2].Status)
+}
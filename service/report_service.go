@@ -0,0 +1,284 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// 报告类型
+const (
+	ReportTypeWeekly  = "weekly"
+	ReportTypeMonthly = "monthly"
+)
+
+// 计算连续学习天数时往前查询的天数
+const reportStreakLookback = 366
+
+// 报告中展示的待办数量
+const reportTodoLimit = 10
+
+// 定时检查是否有待生成报告的间隔
+const reportCheckInterval = time.Hour
+
+type ReportRepository interface {
+	SaveReport(report *models.StudyReport) error
+	GetReports(userID uint, reportType string, page, pageSize int) ([]models.StudyReport, int64, error)
+	GetActiveUserIDs(startDate, endDate time.Time) ([]uint, error)
+	GetUserByID(userID uint) (*models.User, error)
+	ClaimReportRun(reportType string, periodStart time.Time) (bool, error)
+	ReleaseReportRun(reportType string, periodStart time.Time) error
+	GetTodoStudySummary(userID uint, startDate, endDate time.Time, limit int) ([]models.TodoStudySummary, error)
+}
+
+type ReportService struct {
+	repo      ReportRepository
+	studyRepo StudyDataRepository
+	flusher   StudyDataFlush
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+func NewReportService(repo ReportRepository, studyRepo StudyDataRepository, flusher StudyDataFlush) *ReportService {
+	return &ReportService{
+		repo:      repo,
+		studyRepo: studyRepo,
+		flusher:   flusher,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// 最近一个已结束的周期：周报为上周一到上周日，月报为上个月
+func lastCompletedPeriod(reportType string, now time.Time) (time.Time, time.Time, error) {
+	switch reportType {
+	case ReportTypeWeekly:
+		return studyPeriodRange("week", now.AddDate(0, 0, -7))
+	case ReportTypeMonthly:
+		firstDay := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return studyPeriodRange("month", firstDay.AddDate(0, 0, -1))
+	default:
+		return time.Time{}, time.Time{}, errors.New("不支持的报告类型")
+	}
+}
+
+// 上一个周期，用于环比
+func previousPeriod(reportType string, startDate time.Time) (time.Time, time.Time) {
+	if reportType == ReportTypeWeekly {
+		return startDate.AddDate(0, 0, -7), startDate.AddDate(0, 0, -1)
+	}
+	return startDate.AddDate(0, -1, 0), startDate.AddDate(0, 0, -1)
+}
+
+// 启动定时任务，每周一生成上周的周报，每月1日生成上个月的月报
+// 每小时检查一次，服务停机错过的周期会在启动后补上
+func (s *ReportService) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(reportCheckInterval)
+		defer ticker.Stop()
+		for {
+			s.runScheduled(reportNow())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *ReportService) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func reportNow() time.Time {
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	return time.Now().In(loc)
+}
+
+func (s *ReportService) runScheduled(now time.Time) {
+	for _, reportType := range []string{ReportTypeWeekly, ReportTypeMonthly} {
+		startDate, _, err := lastCompletedPeriod(reportType, now)
+		if err != nil {
+			continue
+		}
+		claimed, err := s.repo.ClaimReportRun(reportType, startDate)
+		if err != nil {
+			logger.Log.Errorf("检查报告生成任务失败: type=%s err=%v", reportType, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		count, err := s.GenerateReports(reportType, now)
+		if err != nil {
+			logger.Log.Errorf("生成报告失败: type=%s err=%v", reportType, err)
+			if err := s.repo.ReleaseReportRun(reportType, startDate); err != nil {
+				logger.Log.Errorf("释放报告生成任务失败: type=%s err=%v", reportType, err)
+			}
+			continue
+		}
+		logger.Log.Infof("已生成%d份%s报告，周期开始于%s", count, reportType, startDate.Format("2006-01-02"))
+	}
+}
+
+// 为最近一个已结束周期内有学习记录的所有用户生成报告，返回生成的数量
+// 单个用户失败只记录日志，不影响其他用户
+func (s *ReportService) GenerateReports(reportType string, now time.Time) (int, error) {
+	startDate, endDate, err := lastCompletedPeriod(reportType, now)
+	if err != nil {
+		return 0, err
+	}
+	// 活跃用户只从mysql查询，先把redis中未落库的数据写入mysql
+	if err := s.flusher.Flush(); err != nil {
+		return 0, fmt.Errorf("学习数据落库失败: %w", err)
+	}
+	userIDs, err := s.repo.GetActiveUserIDs(startDate, endDate)
+	if err != nil {
+		return 0, fmt.Errorf("查询活跃用户失败: %w", err)
+	}
+	count := 0
+	for _, userID := range userIDs {
+		if _, err := s.GenerateReport(userID, reportType, startDate, endDate); err != nil {
+			logger.Log.Errorf("生成报告失败: user=%d type=%s err=%v", userID, reportType, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// 生成并保存某个用户某个周期的报告
+func (s *ReportService) GenerateReport(userID uint, reportType string, startDate, endDate time.Time) (*models.StudyReport, error) {
+	user, err := s.repo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	previousStart, _ := previousPeriod(reportType, startDate)
+	lookback := endDate.AddDate(0, 0, -reportStreakLookback)
+	if previousStart.Before(lookback) {
+		lookback = previousStart
+	}
+	dataList, err := s.studyRepo.GetStudyDataSummary(userID, lookback, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	report := buildStudyReport(userID, reportType, startDate, endDate, dataList, user.DailyGoal)
+	summaries, err := s.repo.GetTodoStudySummary(userID, startDate, endDate, reportTodoLimit)
+	if err != nil {
+		return nil, fmt.Errorf("查询待办学习数据失败: %w", err)
+	}
+	todos := make([]TodoStudyInfo, 0, len(summaries))
+	for _, summary := range summaries {
+		todos = append(todos, TodoStudyInfo{
+			TodoID:            summary.TodoID,
+			Event:             summary.Event,
+			StudyTime:         summary.StudyTime,
+			Tomatoes:          summary.Tomatoes,
+			EstimatedTomatoes: summary.EstimatedTomatoes,
+			TomatoAttainment:  tomatoAttainment(summary.Tomatoes, summary.EstimatedTomatoes),
+		})
+	}
+	breakdown, err := json.Marshal(todos)
+	if err != nil {
+		return nil, fmt.Errorf("序列化待办学习数据失败: %w", err)
+	}
+	report.TodoBreakdown = string(breakdown)
+	if err := s.repo.SaveReport(&report); err != nil {
+		return nil, fmt.Errorf("保存报告失败: %w", err)
+	}
+	return &report, nil
+}
+
+// 根据包含本周期、上一周期以及往前若干天的每日数据计算报告
+func buildStudyReport(userID uint, reportType string, startDate, endDate time.Time, dataList []models.DailyStudyData, dailyGoal int) models.StudyReport {
+	report := models.StudyReport{
+		UserID:      userID,
+		Type:        reportType,
+		PeriodStart: startDate,
+		PeriodEnd:   endDate,
+		DailyGoal:   dailyGoal,
+	}
+	previousStart, previousEnd := previousPeriod(reportType, startDate)
+	for _, data := range dataList {
+		if !data.Date.Before(previousStart) && !data.Date.After(previousEnd) {
+			report.PreviousStudyTime += data.StudyTime
+		}
+		if data.Date.Before(startDate) || data.Date.After(endDate) {
+			continue
+		}
+		report.StudyTime += data.StudyTime
+		report.Tomatoes += data.Tomatoes
+		if data.StudyTime > 0 {
+			report.ActiveDays++
+		}
+		if dailyGoal > 0 && data.StudyTime >= dailyGoal {
+			report.GoalDays++
+		}
+		if data.StudyTime > report.BestDayStudyTime {
+			bestDay := data.Date
+			report.BestDay = &bestDay
+			report.BestDayStudyTime = data.StudyTime
+		}
+	}
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	report.DailyAverage = math.Round(float64(report.StudyTime)/float64(days)*10) / 10
+	report.Streak = countStreak(dataList, endDate, func(data models.DailyStudyData) bool {
+		return data.StudyTime > 0
+	})
+	return report
+}
+
+// 分页查询报告历史
+func (s *ReportService) GetReports(userID uint, reportType string, page, pageSize int) ([]StudyReportInfo, int64, error) {
+	reports, total, err := s.repo.GetReports(userID, reportType, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询报告失败: %w", err)
+	}
+	infos := make([]StudyReportInfo, 0, len(reports))
+	for _, report := range reports {
+		infos = append(infos, newStudyReportInfo(report))
+	}
+	return infos, total, nil
+}
+
+func newStudyReportInfo(report models.StudyReport) StudyReportInfo {
+	info := StudyReportInfo{
+		ID:                report.ID,
+		Type:              report.Type,
+		PeriodStart:       report.PeriodStart,
+		PeriodEnd:         report.PeriodEnd,
+		StudyTime:         report.StudyTime,
+		Tomatoes:          report.Tomatoes,
+		ActiveDays:        report.ActiveDays,
+		DailyAverage:      report.DailyAverage,
+		BestDay:           report.BestDay,
+		BestDayStudyTime:  report.BestDayStudyTime,
+		PreviousStudyTime: report.PreviousStudyTime,
+		DailyGoal:         report.DailyGoal,
+		GoalDays:          report.GoalDays,
+		Streak:            report.Streak,
+		Todos:             []TodoStudyInfo{},
+		CreatedAt:         report.CreatedAt,
+	}
+	// 旧报告没有待办数据
+	if report.TodoBreakdown != "" {
+		if err := json.Unmarshal([]byte(report.TodoBreakdown), &info.Todos); err != nil {
+			logger.Log.Errorf("解析报告待办数据失败: report=%d err=%v", report.ID, err)
+		}
+	}
+	// 上一周期没有学习时不计算变化比例
+	if report.PreviousStudyTime > 0 {
+		change := math.Round(float64(report.StudyTime-report.PreviousStudyTime)/float64(report.PreviousStudyTime)*1000) / 10
+		info.ChangePercent = &change
+	}
+	days := int(report.PeriodEnd.Sub(report.PeriodStart).Hours()/24) + 1
+	info.GoalAttainment = math.Round(float64(report.GoalDays)/float64(days)*1000) / 10
+	return info
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestBuildStudyReport(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+	// 10月12日为周一，上一周为10月5日~11日
+	dataList := []models.DailyStudyData{
+		{Date: day(9), StudyTime: 30},
+		{Date: day(10), StudyTime: 90},
+		{Date: day(11), StudyTime: 60},
+		{Date: day(12), StudyTime: 120, Tomatoes: 4},
+		{Date: day(13), StudyTime: 150, Tomatoes: 5},
+		{Date: day(17), StudyTime: 30, Tomatoes: 1},
+		{Date: day(18), StudyTime: 120, Tomatoes: 4},
+	}
+
+	report := buildStudyReport(1, ReportTypeWeekly, day(12), day(18), dataList, 120)
+
+	assert.Equal(t, 420, report.StudyTime)
+	assert.Equal(t, 14, report.Tomatoes)
+	assert.Equal(t, 4, report.ActiveDays)
+	assert.Equal(t, 60.0, report.DailyAverage)
+	assert.Equal(t, day(13), *report.BestDay)
+	assert.Equal(t, 150, report.BestDayStudyTime)
+	assert.Equal(t, 180, report.PreviousStudyTime)
+	assert.Equal(t, 3, report.GoalDays)
+	assert.Equal(t, 2, report.Streak)
+}
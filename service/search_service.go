@@ -0,0 +1,165 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// 搜索结果的类型
+const (
+	SearchTypeNote = "note"
+	SearchTypeTodo = "todo"
+)
+
+const (
+	maxSearchKeywords   = 5  // 最多使用的关键词数量，多余的忽略
+	searchSnippetLength = 80 // 摘要最多保留的字数
+
+	searchHighlightPre  = "<em>"
+	searchHighlightPost = "</em>"
+)
+
+// SearchRepository 全文搜索的实现，MySQL全文索引或内存实现
+type SearchRepository interface {
+	Search(userID uint, filter models.SearchFilter, page, pageSize int) ([]models.SearchHit, int64, error)
+}
+
+type SearchService struct {
+	repo SearchRepository
+}
+
+func NewSearchService(repo SearchRepository) *SearchService {
+	return &SearchService{repo: repo}
+}
+
+// 搜索备忘录的标题和内容、待办的事件和备注，按空格分隔的关键词都出现才匹配
+// types 为空时搜索全部类型，返回的标题和摘要中关键词用 <em> 标出，其余内容已做HTML转义
+func (s *SearchService) Search(userID uint, query string, types []string, page, pageSize int) ([]SearchResultInfo, int64, error) {
+	keywords := parseSearchKeywords(query)
+	if len(keywords) == 0 {
+		return nil, 0, errors.New("搜索内容不能为空")
+	}
+	hits, total, err := s.repo.Search(userID, models.SearchFilter{Keywords: keywords, Types: types}, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("搜索失败: %w", err)
+	}
+	infos := make([]SearchResultInfo, 0, len(hits))
+	for _, hit := range hits {
+		infos = append(infos, SearchResultInfo{
+			Type:      hit.Type,
+			ID:        hit.ID,
+			Title:     highlightText(hit.Title, keywords),
+			Snippet:   highlightSnippet(hit.Content, keywords, searchSnippetLength),
+			UpdatedAt: hit.UpdatedAt,
+		})
+	}
+	return infos, total, nil
+}
+
+// 按空白拆分关键词，去掉双引号和重复的关键词（不区分大小写）
+func parseSearchKeywords(query string) []string {
+	keywords := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, field := range strings.Fields(strings.ReplaceAll(query, `"`, " ")) {
+		key := strings.ToLower(field)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keywords = append(keywords, field)
+		if len(keywords) == maxSearchKeywords {
+			break
+		}
+	}
+	return keywords
+}
+
+// 高亮整段文本中的关键词
+func highlightText(text string, keywords []string) string {
+	runes := []rune(text)
+	return highlightRange(runes, lowerKeywords(keywords), 0, len(runes))
+}
+
+// 截取第一个关键词附近最多 length 个字作为摘要并高亮关键词，空白合并为一个空格，截断处用省略号表示
+func highlightSnippet(text string, keywords []string, length int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	lowered := lowerKeywords(keywords)
+	start := 0
+	if len(runes) > length {
+		for i := range runes {
+			if matchKeywordAt(runes, lowered, i, len(runes)) > 0 {
+				// 关键词前保留一小段上下文
+				start = max(i-length/4, 0)
+				break
+			}
+		}
+		start = min(start, len(runes)-length)
+	}
+	end := min(start+length, len(runes))
+	snippet := highlightRange(runes, lowered, start, end)
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+// 高亮 [start, end) 范围内的关键词，同一位置优先匹配最长的关键词，其余内容做HTML转义
+func highlightRange(runes []rune, keywords [][]rune, start, end int) string {
+	var b strings.Builder
+	plainStart := start
+	for i := start; i < end; {
+		n := matchKeywordAt(runes, keywords, i, end)
+		if n == 0 {
+			i++
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[plainStart:i])))
+		b.WriteString(searchHighlightPre)
+		b.WriteString(html.EscapeString(string(runes[i : i+n])))
+		b.WriteString(searchHighlightPost)
+		i += n
+		plainStart = i
+	}
+	b.WriteString(html.EscapeString(string(runes[plainStart:end])))
+	return b.String()
+}
+
+// 位置 i 处匹配的最长关键词的字数，不能超过 end，没有匹配时返回0
+func matchKeywordAt(runes []rune, keywords [][]rune, i, end int) int {
+	best := 0
+	for _, keyword := range keywords {
+		if len(keyword) <= best || i+len(keyword) > end {
+			continue
+		}
+		matched := true
+		for j, r := range keyword {
+			if unicode.ToLower(runes[i+j]) != r {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			best = len(keyword)
+		}
+	}
+	return best
+}
+
+func lowerKeywords(keywords []string) [][]rune {
+	lowered := make([][]rune, 0, len(keywords))
+	for _, keyword := range keywords {
+		runes := []rune(keyword)
+		for i, r := range runes {
+			runes[i] = unicode.ToLower(r)
+		}
+		lowered = append(lowered, runes)
+	}
+	return lowered
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	repository "2026-FM247-BackEnd/repositories"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+func TestParseSearchKeywords(t *testing.T) {
+	assert.Equal(t, []string{"高数", "Go"}, parseSearchKeywords(`  "高数"  Go go 高数 `))
+	assert.Equal(t, []string{}, parseSearchKeywords(` " `))
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, parseSearchKeywords("a b c d e f"))
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	// 关键词不区分大小写，优先匹配最长的关键词，其余内容做HTML转义
+	assert.Equal(t, "复习<em>Go</em>语言<em>并发</em> &lt;b&gt;", highlightText("复习Go语言并发 <b>", []string{"go", "并发"}))
+	assert.Equal(t, "<em>高等数学</em>", highlightText("高等数学", []string{"高等", "高等数学"}))
+
+	// 空白合并，短文本不截断
+	assert.Equal(t, "第一章 <em>极限</em>", highlightSnippet("第一章\n\n  极限", []string{"极限"}, 10))
+
+	// 长文本截取关键词附近的内容
+	text := "一二三四五六七八九十甲乙丙丁戊己庚辛壬癸"
+	assert.Equal(t, "…七八<em>九十</em>甲乙丙丁…", highlightSnippet(text, []string{"九十"}, 8))
+	assert.Equal(t, "…丙丁戊己庚辛<em>壬癸</em>", highlightSnippet(text, []string{"壬癸"}, 8))
+	assert.Equal(t, "一二三四五六七八…", highlightSnippet(text, []string{"没有"}, 8))
+}
+
+func TestSearchWithMemoryRepository(t *testing.T) {
+	repo := repository.NewMemorySearchRepository()
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	repo.SaveNote(models.Note{ID: 1, UserID: 1, Title: "高数笔记", Content: "# 极限\n\n高数第一章", UpdatedAt: now})
+	repo.SaveNote(models.Note{ID: 2, UserID: 1, Title: "英语", Content: "单词", UpdatedAt: now})
+	repo.SaveNote(models.Note{ID: 3, UserID: 2, Title: "高数", UpdatedAt: now})
+	repo.SaveNote(models.Note{ID: 4, UserID: 1, Title: "高数", UpdatedAt: now, DeletedAt: gorm.DeletedAt{Time: now, Valid: true}})
+	repo.SaveTodo(models.Todo{ID: 1, UserID: 1, Event: "复习高数", UpdatedAt: now.Add(time.Hour)})
+	service := NewSearchService(repo)
+
+	// 相关度高的在前，其他用户和已删除的不返回
+	results, total, err := service.Search(1, "高数", nil, 1, 10)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, SearchTypeNote, results[0].Type)
+	assert.Equal(t, "<em>高数</em>笔记", results[0].Title)
+	assert.Equal(t, "# 极限 <em>高数</em>第一章", results[0].Snippet)
+	assert.Equal(t, SearchTypeTodo, results[1].Type)
+
+	// 按类型筛选和分页
+	results, total, err = service.Search(1, "高数", []string{SearchTypeTodo}, 1, 10)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, uint(1), results[0].ID)
+
+	results, total, err = service.Search(1, "高数", nil, 2, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, SearchTypeTodo, results[0].Type)
+
+	// 所有关键词都出现才匹配
+	_, total, _ = service.Search(1, "高数 极限", nil, 1, 10)
+	assert.Equal(t, int64(1), total)
+
+	_, _, err = service.Search(1, "  ", nil, 1, 10)
+	assert.NotEqual(t, nil, err)
+}
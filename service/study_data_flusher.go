@@ -0,0 +1,86 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"fmt"
+	"time"
+)
+
+type StudyDataFlushRepository interface {
+	FlushDirtyStudyData(batchSize int) (int, error)
+	CountDirtyStudyData() (int64, error)
+}
+
+// StudyDataFlusher 后台把redis中的学习数据批量写入mysql
+type StudyDataFlusher struct {
+	repo StudyDataFlushRepository
+	cfg  *config.StudyDataConfig
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewStudyDataFlusher(repo StudyDataFlushRepository, cfg *config.StudyDataConfig) *StudyDataFlusher {
+	return &StudyDataFlusher{
+		repo: repo,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// 启动后台落库，失败时按指数退避重试
+func (f *StudyDataFlusher) Start() {
+	go func() {
+		defer close(f.done)
+		delay := f.cfg.FlushInterval
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-timer.C:
+				if err := f.Flush(); err != nil {
+					delay = min(delay*2, f.cfg.FlushMaxBackoff)
+					logger.Log.Errorf("学习数据落库失败，%v后重试: %v", delay, err)
+				} else {
+					delay = f.cfg.FlushInterval
+				}
+				timer.Reset(delay)
+			}
+		}
+	}()
+}
+
+// 落库当前所有待写入的数据
+func (f *StudyDataFlusher) Flush() error {
+	for {
+		n, err := f.repo.FlushDirtyStudyData(f.cfg.FlushBatchSize)
+		if err != nil {
+			return err
+		}
+		if n < f.cfg.FlushBatchSize {
+			return nil
+		}
+	}
+}
+
+// 停止后台落库，并在超时前尽量把剩余数据写入mysql
+func (f *StudyDataFlusher) Stop() error {
+	close(f.stop)
+	<-f.done
+
+	deadline := time.Now().Add(f.cfg.ShutdownFlushTimeout)
+	for {
+		err := f.Flush()
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			remaining, _ := f.repo.CountDirtyStudyData()
+			return fmt.Errorf("仍有%d条学习数据未落库: %w", remaining, err)
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -1,50 +1,303 @@
 package service
 
 import (
+	"2026-FM247-BackEnd/logger"
 	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"sort"
 	"time"
 )
 
 type StudyDataRepository interface {
 	GenerateDailyKey(userID uint, date time.Time) string
 	GenerateMonthlyKey(userID uint, date time.Time) string
-	IncrementDailyStudyTime(userID uint, date time.Time, studyTime int) error
-	IncrementDailyTomatoes(userID uint, date time.Time, tomatoes int) error
-	SyncDailyDataToMySQL(userID uint, date time.Time, addStudyTime int, addTomatoes int) error
+	IncrementStudyData(userID uint, date time.Time, studyTime int, tomatoes int, maxDayStudyTime int) (int, bool, error)
 	GetDailyStudyData(userID uint, date time.Time) (*models.DailyStudyData, error, bool)
 	GetMonthlyStudyData(userID uint, date time.Time) (*models.MonthlyStudyData, error, bool)
 	GetTotalStudyData(userID uint) (*models.TotalStudyData, error, bool)
 	GetStudyDataSummary(userID uint, startDate, endDate time.Time) ([]models.DailyStudyData, error)
+	ClaimSubmission(userID uint, idempotencyKey string, ttl time.Duration) (bool, error)
+	ReleaseSubmission(userID uint, idempotencyKey string) error
+	GetHeatmapCache(userID uint, year int) (map[string]int, bool, error)
+	SetHeatmapCache(userID uint, year int, days map[string]int, ttl time.Duration) error
+	InvalidateHeatmapCache(userID uint, year int) error
+	IncrementHourlyStudyData(userID uint, year, hour, studyTime int) error
+	GetHourlyStudyData(userID uint, year int) ([]models.HourlyStudyData, error)
+	CreateStudySession(session *models.StudySession) error
+	GetStudySessions(userID uint, start, end time.Time) ([]models.StudySession, error)
+}
+
+const (
+	// 离线记录最多补交7天内的数据，幂等key保留时间需覆盖这个窗口
+	OfflineSubmissionWindow = 7 * 24 * time.Hour
+	submissionKeyTTL        = OfflineSubmissionWindow + 24*time.Hour
+	MaxBatchSubmissions     = 100 // 一次批量补交的最大条数
+)
+
+// 提交结果状态
+const (
+	SubmissionApplied   = "applied"
+	SubmissionDuplicate = "duplicate"
+	SubmissionFailed    = "failed"
+)
+
+// StudySubmission 一次学习数据提交，IdempotencyKey 由客户端生成，重试时保持不变
+type StudySubmission struct {
+	IdempotencyKey string
+	Date           time.Time
+	StudyTime      int
+	Tomatoes       int
+	SubjectID      uint
+	TodoID         uint       // 学习所针对的待办，0表示没有
+	StartedAt      *time.Time // 可选，有开始时间时同时保存为一次学习记录，Date 即结束时间
+	Abandoned      bool       // 最后一个番茄钟是否中途放弃
+}
+
+// 学习时长变化时同步到排行榜
+type LeaderboardRecorder interface {
+	RecordStudyTime(userID uint, date time.Time, studyTime int) error
+}
+
+// 学习数据归属到科目
+type SubjectStudyRecorder interface {
+	ValidateSubject(userID, subjectID uint) error
+	RecordSubjectStudy(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error
+}
+
+// 学习数据归属到待办
+type TodoStudyRecorder interface {
+	ValidateTodo(userID, todoID uint) error
+	RecordTodoStudy(userID, todoID uint, date time.Time, studyTime, tomatoes int) error
+}
+
+// 提交频率限制、数据校验与可疑行为检测
+type StudyDataGuard interface {
+	CheckRateLimit(userID uint, now time.Time) error
+	ValidateSubmission(studyTime, tomatoes, dayStudyTime int) error
+	MaxDayStudyTime() int
+	InspectSubmission(userID, subjectID, todoID uint, date time.Time, studyTime, tomatoes, dayStudyTime int)
 }
 
 type StudyDataService struct {
-	repo StudyDataRepository
+	repo        StudyDataRepository
+	leaderboard LeaderboardRecorder
+	subjects    SubjectStudyRecorder
+	todos       TodoStudyRecorder
+	guard       StudyDataGuard
 }
 
-func NewStudyDataService(repo StudyDataRepository) *StudyDataService {
-	return &StudyDataService{repo: repo}
+func NewStudyDataService(repo StudyDataRepository, leaderboard LeaderboardRecorder, subjects SubjectStudyRecorder, todos TodoStudyRecorder, guard StudyDataGuard) *StudyDataService {
+	return &StudyDataService{
+		repo:        repo,
+		leaderboard: leaderboard,
+		subjects:    subjects,
+		todos:       todos,
+		guard:       guard,
+	}
 }
 
-// 增加学习时长、番茄钟次数
-func (s *StudyDataService) AddStudyData(userID uint, date time.Time, studyTime int, tomatoes int) (bool, string) {
-	err := s.repo.IncrementDailyStudyTime(userID, date, studyTime)
-	if err != nil {
-		return false, "记录学习时长失败" + err.Error()
+// 检查提交频率，每个请求调用一次
+func (s *StudyDataService) CheckRateLimit(userID uint, now time.Time) error {
+	return s.guard.CheckRateLimit(userID, now)
+}
+
+// 增加学习时长、番茄钟次数，subjectID、todoID 为0表示不归属任何科目、待办
+// subjectID、todoID 随可疑记录保存，作废时一并扣除
+func (s *StudyDataService) addStudyData(userID uint, date time.Time, studyTime int, tomatoes int, subjectID, todoID uint) (bool, string) {
+	dayStudyTime := 0
+	daily, err, notFound := s.repo.GetDailyStudyData(userID, date)
+	if err != nil && !notFound {
+		return false, "查询每日学习数据失败" + err.Error()
+	}
+	if daily != nil {
+		dayStudyTime = daily.StudyTime
+	}
+	// 先按已记录的数据快速校验，每日上限在写入redis时再原子地检查一次
+	if err := s.guard.ValidateSubmission(studyTime, tomatoes, dayStudyTime); err != nil {
+		return false, err.Error()
 	}
 
-	err = s.repo.IncrementDailyTomatoes(userID, date, tomatoes)
-	if err != nil {
-		return false, "记录番茄钟次数失败" + err.Error()
+	if subjectID != 0 {
+		if err := s.subjects.ValidateSubject(userID, subjectID); err != nil {
+			return false, err.Error()
+		}
 	}
 
-	err = s.repo.SyncDailyDataToMySQL(userID, date, studyTime, tomatoes)
+	// 数据先写入redis，由 StudyDataFlusher 异步落库
+	maxDayStudyTime := s.guard.MaxDayStudyTime()
+	dayStudyTime, applied, err := s.repo.IncrementStudyData(userID, date, studyTime, tomatoes, maxDayStudyTime)
 	if err != nil {
-		return false, "同步数据到MySQL失败" + err.Error()
+		return false, "记录学习数据失败" + err.Error()
+	}
+	if !applied {
+		return false, fmt.Sprintf("每日学习时长不能超过%d分钟", maxDayStudyTime)
+	}
+	s.guard.InspectSubmission(userID, subjectID, todoID, date, studyTime, tomatoes, dayStudyTime)
+
+	// 热力图缓存不包含今天，补交历史数据时需要清除
+	now := time.Now().In(date.Location())
+	if date.Format("2006-01-02") != now.Format("2006-01-02") {
+		if err := s.repo.InvalidateHeatmapCache(userID, date.Year()); err != nil {
+			logger.Log.Errorf("清除热力图缓存失败: user=%d err=%v", userID, err)
+		}
+	}
+
+	if subjectID != 0 {
+		if err := s.subjects.RecordSubjectStudy(userID, subjectID, date, studyTime, tomatoes); err != nil {
+			logger.Log.Errorf("记录科目学习数据失败: user=%d subject=%d err=%v", userID, subjectID, err)
+		}
+	}
+
+	// 按小时的统计只用于分析，失败不影响本次记录
+	for _, slice := range splitStudyTimeByHour(date, studyTime) {
+		if err := s.repo.IncrementHourlyStudyData(userID, slice.Start.Year(), slice.Start.Hour(), slice.StudyTime); err != nil {
+			logger.Log.Errorf("记录分时段学习数据失败: user=%d err=%v", userID, err)
+		}
+	}
+
+	// 排行榜可通过重建恢复，更新失败不影响本次记录
+	if err := s.leaderboard.RecordStudyTime(userID, date, studyTime); err != nil {
+		logger.Log.Errorf("更新排行榜失败: user=%d err=%v", userID, err)
 	}
 
 	return true, "学习数据记录成功"
 }
 
+// 带幂等校验的提交，同一个 IdempotencyKey 只会记录一次
+// 没有 IdempotencyKey 时退化为普通记录
+func (s *StudyDataService) SubmitStudyData(userID uint, submission StudySubmission) StudySubmissionResult {
+	result := StudySubmissionResult{
+		IdempotencyKey: submission.IdempotencyKey,
+		Date:           submission.Date,
+	}
+	if submission.StartedAt != nil {
+		if err := validateStudySessionSpan(*submission.StartedAt, submission.Date, submission.StudyTime); err != nil {
+			result.Status = SubmissionFailed
+			result.Message = err.Error()
+			return result
+		}
+	}
+	if submission.TodoID != 0 {
+		if err := s.todos.ValidateTodo(userID, submission.TodoID); err != nil {
+			result.Status = SubmissionFailed
+			result.Message = err.Error()
+			return result
+		}
+	}
+	if submission.IdempotencyKey != "" {
+		claimed, err := s.repo.ClaimSubmission(userID, submission.IdempotencyKey, submissionKeyTTL)
+		if err != nil {
+			result.Status = SubmissionFailed
+			result.Message = "幂等校验失败" + err.Error()
+			return result
+		}
+		if !claimed {
+			result.Status = SubmissionDuplicate
+			result.Message = "重复提交，已忽略"
+			return result
+		}
+	}
+
+	ok, msg := s.addStudyData(userID, submission.Date, submission.StudyTime, submission.Tomatoes, submission.SubjectID, submission.TodoID)
+	if !ok {
+		if submission.IdempotencyKey != "" {
+			if err := s.repo.ReleaseSubmission(userID, submission.IdempotencyKey); err != nil {
+				logger.Log.Errorf("释放幂等key失败: user=%d key=%s err=%v", userID, submission.IdempotencyKey, err)
+			}
+		}
+		result.Status = SubmissionFailed
+		result.Message = msg
+		return result
+	}
+	if submission.TodoID != 0 {
+		if err := s.todos.RecordTodoStudy(userID, submission.TodoID, submission.Date, submission.StudyTime, submission.Tomatoes); err != nil {
+			logger.Log.Errorf("记录待办学习数据失败: user=%d todo=%d err=%v", userID, submission.TodoID, err)
+		}
+	}
+	// 学习记录只用于分析和导出，保存失败不影响本次记录
+	if submission.StartedAt != nil {
+		session := &models.StudySession{
+			UserID:    userID,
+			SubjectID: submission.SubjectID,
+			TodoID:    submission.TodoID,
+			StartedAt: *submission.StartedAt,
+			EndedAt:   submission.Date,
+			StudyTime: submission.StudyTime,
+			Tomatoes:  submission.Tomatoes,
+			Abandoned: submission.Abandoned,
+		}
+		if err := s.repo.CreateStudySession(session); err != nil {
+			logger.Log.Errorf("保存学习记录失败: user=%d err=%v", userID, err)
+		}
+	}
+	result.Status = SubmissionApplied
+	result.Message = msg
+	return result
+}
+
+// 学习时长不能超过开始到结束的时间跨度，允许1分钟的取整误差
+func validateStudySessionSpan(startedAt, endedAt time.Time, studyTime int) error {
+	if !startedAt.Before(endedAt) {
+		return errors.New("开始时间必须早于结束时间")
+	}
+	if float64(studyTime) > endedAt.Sub(startedAt).Minutes()+1 {
+		return errors.New("学习时长超过了开始到结束的时间")
+	}
+	return nil
+}
+
+// 提交一次带起止时间的学习，学习时长由起止时间计算，按结束时间所在的日期入账
+func (s *StudyDataService) SubmitStudySession(userID uint, submission StudySubmission, now time.Time) StudySubmissionResult {
+	if submission.StartedAt == nil {
+		return StudySubmissionResult{
+			IdempotencyKey: submission.IdempotencyKey,
+			Date:           submission.Date,
+			Status:         SubmissionFailed,
+			Message:        "缺少开始时间",
+		}
+	}
+	if submission.Date.After(now.Add(5*time.Minute)) || submission.Date.Before(now.Add(-OfflineSubmissionWindow)) {
+		return StudySubmissionResult{
+			IdempotencyKey: submission.IdempotencyKey,
+			Date:           submission.Date,
+			Status:         SubmissionFailed,
+			Message:        "记录时间超出可补交范围",
+		}
+	}
+	submission.StudyTime = int(submission.Date.Sub(*submission.StartedAt).Minutes())
+	return s.SubmitStudyData(userID, submission)
+}
+
+// 批量提交离线记录的学习数据，每条按自己的日期记录并单独去重
+func (s *StudyDataService) SubmitStudyDataBatch(userID uint, submissions []StudySubmission, now time.Time) ([]StudySubmissionResult, error) {
+	if len(submissions) > MaxBatchSubmissions {
+		return nil, fmt.Errorf("一次最多补交%d条记录", MaxBatchSubmissions)
+	}
+	results := make([]StudySubmissionResult, 0, len(submissions))
+	for _, submission := range submissions {
+		if submission.IdempotencyKey == "" {
+			results = append(results, StudySubmissionResult{
+				Date:    submission.Date,
+				Status:  SubmissionFailed,
+				Message: "离线记录必须携带幂等key",
+			})
+			continue
+		}
+		if submission.Date.After(now.Add(5*time.Minute)) || submission.Date.Before(now.Add(-OfflineSubmissionWindow)) {
+			results = append(results, StudySubmissionResult{
+				IdempotencyKey: submission.IdempotencyKey,
+				Date:           submission.Date,
+				Status:         SubmissionFailed,
+				Message:        "记录时间超出可补交范围",
+			})
+			continue
+		}
+		results = append(results, s.SubmitStudyData(userID, submission))
+	}
+	return results, nil
+}
+
 // 获取每日学习数据
 func (s *StudyDataService) GetDailyStudyData(userID uint, date time.Time) (DailyStudyDataInfo, string) {
 	data, err, notFound := s.repo.GetDailyStudyData(userID, date)
@@ -166,3 +419,143 @@ func (s *StudyDataService) GetYearStudyData(userID uint, date time.Time) ([]Mont
 	}
 	return result, ""
 }
+
+// 获取某一年每天的学习时长及热力等级
+// 今天之前的数据来自一次范围查询并按用户、年份缓存，今天的数据实时从redis读取
+func (s *StudyDataService) GetHeatmap(userID uint, year int, now time.Time) (*HeatmapInfo, error) {
+	if year > now.Year() {
+		return nil, errors.New("年份不能晚于今年")
+	}
+	firstDay := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+	lastDay := time.Date(year, 12, 31, 0, 0, 0, 0, now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	days, found, err := s.repo.GetHeatmapCache(userID, year)
+	if err != nil {
+		logger.Log.Errorf("读取热力图缓存失败: user=%d err=%v", userID, err)
+	}
+	if !found {
+		endDate := lastDay
+		ttl := 7 * 24 * time.Hour
+		if year == now.Year() {
+			// 今年的缓存到零点失效，第二天重新计入昨天的数据
+			endDate = today.AddDate(0, 0, -1)
+			ttl = today.AddDate(0, 0, 1).Sub(now)
+		}
+		days = make(map[string]int)
+		if !endDate.Before(firstDay) {
+			dataList, err := s.repo.GetStudyDataSummary(userID, firstDay, endDate)
+			if err != nil {
+				return nil, fmt.Errorf("查询学习数据失败: %w", err)
+			}
+			for _, data := range dataList {
+				if data.StudyTime > 0 {
+					days[data.Date.Format("2006-01-02")] = data.StudyTime
+				}
+			}
+		}
+		if err := s.repo.SetHeatmapCache(userID, year, days, ttl); err != nil {
+			logger.Log.Errorf("写入热力图缓存失败: user=%d err=%v", userID, err)
+		}
+	}
+	if year == now.Year() {
+		data, msg := s.GetDailyStudyData(userID, today)
+		if msg == "" {
+			days[today.Format("2006-01-02")] = data.StudyTime
+		}
+	}
+
+	info := &HeatmapInfo{Year: year}
+	values := make([]int, 0, 366)
+	for day := firstDay; !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		studyTime := days[day.Format("2006-01-02")]
+		values = append(values, studyTime)
+		info.Days = append(info.Days, HeatmapDay{
+			Date:      day.Format("2006-01-02"),
+			StudyTime: studyTime,
+		})
+		info.TotalStudyTime += studyTime
+		if studyTime > 0 {
+			info.ActiveDays++
+		}
+	}
+	for i, level := range heatmapLevels(values) {
+		info.Days[i].Level = level
+	}
+	return info, nil
+}
+
+// 按用户自己的分布计算热力等级：0 表示没有学习，其余按有学习的天数的四分位数分为 1~4 级
+func heatmapLevels(values []int) []int {
+	active := make([]int, 0, len(values))
+	for _, value := range values {
+		if value > 0 {
+			active = append(active, value)
+		}
+	}
+	sort.Ints(active)
+
+	levels := make([]int, len(values))
+	if len(active) == 0 {
+		return levels
+	}
+	quartile := func(p int) int {
+		return active[(len(active)-1)*p/4]
+	}
+	q1, q2, q3 := quartile(1), quartile(2), quartile(3)
+	for i, value := range values {
+		switch {
+		case value <= 0:
+			levels[i] = 0
+		case value <= q1:
+			levels[i] = 1
+		case value <= q2:
+			levels[i] = 2
+		case value <= q3:
+			levels[i] = 3
+		default:
+			levels[i] = 4
+		}
+	}
+	return levels
+}
+
+type hourSlice struct {
+	Start     time.Time // 所在小时的整点
+	StudyTime int
+}
+
+// 把结束于 end 的一段学习按小时拆分，从结束时间往前分摊
+func splitStudyTimeByHour(end time.Time, studyTime int) []hourSlice {
+	slices := make([]hourSlice, 0)
+	remaining := studyTime
+	cursor := end
+	for remaining > 0 {
+		hourStart := cursor.Truncate(time.Hour)
+		available := int(cursor.Sub(hourStart).Minutes())
+		if available == 0 {
+			available = 60
+			hourStart = hourStart.Add(-time.Hour)
+		}
+		minutes := min(available, remaining)
+		slices = append(slices, hourSlice{Start: hourStart, StudyTime: minutes})
+		remaining -= minutes
+		cursor = hourStart
+	}
+	return slices
+}
+
+// 从 day 往前数，统计连续满足条件的天数
+func countStreak(dataList []models.DailyStudyData, day time.Time, ok func(data models.DailyStudyData) bool) int {
+	matched := make(map[string]bool, len(dataList))
+	for _, data := range dataList {
+		if ok(data) {
+			matched[data.Date.Format("2006-01-02")] = true
+		}
+	}
+	streak := 0
+	for matched[day.AddDate(0, 0, -streak).Format("2006-01-02")] {
+		streak++
+	}
+	return streak
+}
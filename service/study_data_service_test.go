@@ -0,0 +1,26 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestHeatmapLevels(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []int
+		want   []int
+	}{
+		{"没有学习", []int{0, 0, 0}, []int{0, 0, 0}},
+		{"只有一天", []int{0, 30, 0}, []int{0, 1, 0}},
+		{"按四分位分级", []int{10, 0, 20, 30, 40, 50}, []int{1, 0, 1, 2, 3, 4}},
+		{"时长相同", []int{60, 60, 0, 60}, []int{1, 1, 0, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, heatmapLevels(tt.values))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"2026-FM247-BackEnd/utils"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 未指定科目的学习时长在拆分中显示为“未分类”
+const unassignedSubjectName = "未分类"
+
+type SubjectRepository interface {
+	CreateSubject(subject *models.Subject) error
+	GetSubjectByID(id uint) (*models.Subject, error)
+	GetSubjectByName(userID uint, name string) (*models.Subject, error)
+	GetSubjectsByUserID(userID uint) ([]models.Subject, error)
+	GetSubjectsByIDs(ids []uint) ([]models.Subject, error)
+	UpdateSubject(subject *models.Subject) error
+	DeleteSubject(id uint) error
+	IncrementSubjectStudyData(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error
+	DecrementSubjectStudyData(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error
+	GetSubjectStudyData(userID uint, startDate, endDate time.Time) ([]models.SubjectDailyStudyData, error)
+}
+
+type SubjectService struct {
+	repo      SubjectRepository
+	studyRepo StudyDataRepository
+}
+
+func NewSubjectService(repo SubjectRepository, studyRepo StudyDataRepository) *SubjectService {
+	return &SubjectService{
+		repo:      repo,
+		studyRepo: studyRepo,
+	}
+}
+
+// 创建科目
+func (s *SubjectService) CreateSubject(userID uint, name, color string) (*SubjectInfo, error) {
+	if name == "" {
+		return nil, errors.New("科目名称不能为空")
+	}
+	if color != "" && !utils.ValidateHexColor(color) {
+		return nil, errors.New("颜色格式不正确，应为#RRGGBB")
+	}
+	if _, err := s.repo.GetSubjectByName(userID, name); err == nil {
+		return nil, errors.New("科目名称已存在")
+	}
+	subject := &models.Subject{
+		UserID: userID,
+		Name:   name,
+		Color:  color,
+	}
+	if err := s.repo.CreateSubject(subject); err != nil {
+		return nil, fmt.Errorf("创建科目失败: %w", err)
+	}
+	info := newSubjectInfo(*subject)
+	return &info, nil
+}
+
+// 更新科目名称或颜色
+func (s *SubjectService) UpdateSubject(userID, subjectID uint, name, color string) error {
+	subject, err := s.getOwnedSubject(userID, subjectID)
+	if err != nil {
+		return err
+	}
+	if color != "" && !utils.ValidateHexColor(color) {
+		return errors.New("颜色格式不正确，应为#RRGGBB")
+	}
+	if name != "" && name != subject.Name {
+		if _, err := s.repo.GetSubjectByName(userID, name); err == nil {
+			return errors.New("科目名称已存在")
+		}
+		subject.Name = name
+	}
+	if color != "" {
+		subject.Color = color
+	}
+	if err := s.repo.UpdateSubject(subject); err != nil {
+		return fmt.Errorf("更新科目失败: %w", err)
+	}
+	return nil
+}
+
+// 删除科目，已记录的学习数据仍保留在统计中
+func (s *SubjectService) DeleteSubject(userID, subjectID uint) error {
+	if _, err := s.getOwnedSubject(userID, subjectID); err != nil {
+		return err
+	}
+	return s.repo.DeleteSubject(subjectID)
+}
+
+func (s *SubjectService) GetSubjects(userID uint) ([]SubjectInfo, error) {
+	subjects, err := s.repo.GetSubjectsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询科目失败: %w", err)
+	}
+	infos := make([]SubjectInfo, 0, len(subjects))
+	for _, subject := range subjects {
+		infos = append(infos, newSubjectInfo(subject))
+	}
+	return infos, nil
+}
+
+// 校验科目归属，供记录学习数据前调用
+func (s *SubjectService) ValidateSubject(userID, subjectID uint) error {
+	_, err := s.getOwnedSubject(userID, subjectID)
+	return err
+}
+
+// 记录科目学习数据
+func (s *SubjectService) RecordSubjectStudy(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error {
+	return s.repo.IncrementSubjectStudyData(userID, subjectID, date, studyTime, tomatoes)
+}
+
+// 扣除作废提交在科目上记录的学习数据
+func (s *SubjectService) RevokeSubjectStudy(userID, subjectID uint, date time.Time, studyTime, tomatoes int) error {
+	return s.repo.DecrementSubjectStudyData(userID, subjectID, date, studyTime, tomatoes)
+}
+
+// 按日/周/月/年统计各科目学习时长
+func (s *SubjectService) GetSubjectStats(userID uint, rangeType string, date time.Time) (*SubjectStatsInfo, error) {
+	startDate, endDate, err := studyPeriodRange(rangeType, date)
+	if err != nil {
+		return nil, err
+	}
+	dailyList, err := s.studyRepo.GetStudyDataSummary(userID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	subjectList, err := s.repo.GetSubjectStudyData(userID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询科目学习数据失败: %w", err)
+	}
+
+	stats := &SubjectStatsInfo{
+		Range:     rangeType,
+		StartDate: startDate,
+		EndDate:   endDate,
+	}
+	for _, data := range dailyList {
+		stats.StudyTime += data.StudyTime
+		stats.Tomatoes += data.Tomatoes
+	}
+	stats.Subjects, err = s.buildBreakdown(subjectList, stats.StudyTime, stats.Tomatoes)
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// 为每日数据补充科目拆分
+func (s *SubjectService) AttachDailyBreakdown(userID uint, days []DailyStudyDataInfo) error {
+	if len(days) == 0 {
+		return nil
+	}
+	subjectList, err := s.repo.GetSubjectStudyData(userID, days[0].Date, days[len(days)-1].Date)
+	if err != nil {
+		return fmt.Errorf("查询科目学习数据失败: %w", err)
+	}
+	grouped := make(map[string][]models.SubjectDailyStudyData)
+	for _, data := range subjectList {
+		key := data.Date.Format("2006-01-02")
+		grouped[key] = append(grouped[key], data)
+	}
+	for i := range days {
+		breakdown, err := s.buildBreakdown(grouped[days[i].Date.Format("2006-01-02")], days[i].StudyTime, days[i].Tomatoes)
+		if err != nil {
+			return err
+		}
+		days[i].Breakdown = breakdown
+	}
+	return nil
+}
+
+// 为每月数据补充科目拆分
+func (s *SubjectService) AttachMonthlyBreakdown(userID uint, months []MonthlyStudyDataInfo) error {
+	if len(months) == 0 {
+		return nil
+	}
+	first, last := months[0].Date, months[len(months)-1].Date
+	endDate := time.Date(last.Year(), last.Month()+1, 0, 0, 0, 0, 0, last.Location())
+	subjectList, err := s.repo.GetSubjectStudyData(userID, first, endDate)
+	if err != nil {
+		return fmt.Errorf("查询科目学习数据失败: %w", err)
+	}
+	grouped := make(map[string][]models.SubjectDailyStudyData)
+	for _, data := range subjectList {
+		key := data.Date.Format("2006-01")
+		grouped[key] = append(grouped[key], data)
+	}
+	for i := range months {
+		breakdown, err := s.buildBreakdown(grouped[months[i].Date.Format("2006-01")], months[i].StudyTime, months[i].Tomatoes)
+		if err != nil {
+			return err
+		}
+		months[i].Breakdown = breakdown
+	}
+	return nil
+}
+
+// 按科目汇总，总量中未归属任何科目的部分记为“未分类”
+func (s *SubjectService) buildBreakdown(dataList []models.SubjectDailyStudyData, totalStudyTime, totalTomatoes int) ([]SubjectStudyInfo, error) {
+	sums := make(map[uint]*SubjectStudyInfo)
+	ids := make([]uint, 0)
+	for _, data := range dataList {
+		info, ok := sums[data.SubjectID]
+		if !ok {
+			info = &SubjectStudyInfo{SubjectID: data.SubjectID}
+			sums[data.SubjectID] = info
+			ids = append(ids, data.SubjectID)
+		}
+		info.StudyTime += data.StudyTime
+		info.Tomatoes += data.Tomatoes
+	}
+
+	subjects, err := s.repo.GetSubjectsByIDs(ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询科目失败: %w", err)
+	}
+	for _, subject := range subjects {
+		sums[subject.ID].Name = subject.Name
+		sums[subject.ID].Color = subject.Color
+	}
+
+	breakdown := make([]SubjectStudyInfo, 0, len(ids)+1)
+	assignedTime, assignedTomatoes := 0, 0
+	for _, id := range ids {
+		breakdown = append(breakdown, *sums[id])
+		assignedTime += sums[id].StudyTime
+		assignedTomatoes += sums[id].Tomatoes
+	}
+	sort.SliceStable(breakdown, func(i, j int) bool {
+		return breakdown[i].StudyTime > breakdown[j].StudyTime
+	})
+	if totalStudyTime > assignedTime || totalTomatoes > assignedTomatoes {
+		breakdown = append(breakdown, SubjectStudyInfo{
+			Name:      unassignedSubjectName,
+			StudyTime: max(totalStudyTime-assignedTime, 0),
+			Tomatoes:  max(totalTomatoes-assignedTomatoes, 0),
+		})
+	}
+	return breakdown, nil
+}
+
+func (s *SubjectService) getOwnedSubject(userID, subjectID uint) (*models.Subject, error) {
+	subject, err := s.repo.GetSubjectByID(subjectID)
+	if err != nil {
+		return nil, errors.New("科目不存在")
+	}
+	if subject.UserID != userID {
+		return nil, errors.New("无权限操作该科目")
+	}
+	return subject, nil
+}
+
+// 根据统计范围计算起止日期（包含两端）
+func studyPeriodRange(rangeType string, date time.Time) (time.Time, time.Time, error) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	switch rangeType {
+	case "day":
+		return day, day, nil
+	case "week":
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		monday := day.AddDate(0, 0, -weekday+1)
+		return monday, monday.AddDate(0, 0, 6), nil
+	case "month":
+		first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location())
+		return first, first.AddDate(0, 1, -1), nil
+	case "year":
+		first := time.Date(day.Year(), 1, 1, 0, 0, 0, 0, day.Location())
+		return first, first.AddDate(1, 0, -1), nil
+	default:
+		return time.Time{}, time.Time{}, errors.New("不支持的统计范围")
+	}
+}
+
+func newSubjectInfo(subject models.Subject) SubjectInfo {
+	return SubjectInfo{
+		ID:    subject.ID,
+		Name:  subject.Name,
+		Color: subject.Color,
+	}
+}
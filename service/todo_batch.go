@@ -0,0 +1,275 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 批量操作的类型
+const (
+	TodoBatchComplete = "complete"
+	TodoBatchDelete   = "delete"
+	TodoBatchMove     = "move"
+	TodoBatchTag      = "tag"
+)
+
+// 批量设置标签的方式
+const (
+	TodoTagModeAdd    = "add"
+	TodoTagModeRemove = "remove"
+	TodoTagModeSet    = "set"
+)
+
+// 一次批量操作：逐个校验后，通过校验的待办在一个事务中统一修改
+type todoBatch struct {
+	results []TodoBatchResult
+	index   map[uint]int
+	todos   []*models.Todo
+}
+
+// 逐个校验待办，check 返回错误的待办不参与本次操作
+func (s *TodoService) prepareBatch(userID uint, ids []uint, check func(todo *models.Todo) error) (*todoBatch, error) {
+	if len(ids) == 0 {
+		return nil, errors.New("请选择待办事项")
+	}
+	if len(ids) > s.cfg.BatchLimit {
+		return nil, fmt.Errorf("一次最多操作%d个待办事项", s.cfg.BatchLimit)
+	}
+	batch := &todoBatch{
+		results: make([]TodoBatchResult, 0, len(ids)),
+		index:   make(map[uint]int, len(ids)),
+	}
+	for _, id := range ids {
+		if _, ok := batch.index[id]; ok {
+			continue
+		}
+		batch.index[id] = len(batch.results)
+		batch.results = append(batch.results, TodoBatchResult{ID: id})
+		todo, err := s.getAccessibleTodo(userID, id, true)
+		if err == nil && check != nil {
+			err = check(todo)
+		}
+		if err != nil {
+			batch.results[batch.index[id]].Message = err.Error()
+			continue
+		}
+		batch.todos = append(batch.todos, todo)
+	}
+	return batch, nil
+}
+
+func (b *todoBatch) ids() []uint {
+	ids := make([]uint, 0, len(b.todos))
+	for _, todo := range b.todos {
+		ids = append(ids, todo.ID)
+	}
+	return ids
+}
+
+// 根据事务的结果填写通过校验的待办的结果
+func (b *todoBatch) finish(err error, message string) []TodoBatchResult {
+	for _, todo := range b.todos {
+		result := &b.results[b.index[todo.ID]]
+		if err != nil {
+			result.Message = err.Error()
+			continue
+		}
+		result.Success = true
+		if result.Message == "" {
+			result.Message = message
+		}
+	}
+	return b.results
+}
+
+// 批量完成待办，子任务一并完成，返回每个待办的结果以及本次由未完成变为完成的待办
+func (s *TodoService) BatchCompleteTodos(userID uint, ids []uint, now time.Time) ([]TodoBatchResult, []uint, error) {
+	batch, err := s.prepareBatch(userID, ids, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(batch.todos) == 0 {
+		return batch.results, nil, nil
+	}
+	all, err := s.withDescendants(batch.ids())
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.todoRepository.CompleteTodos(all, userID, now); err != nil {
+		return batch.finish(fmt.Errorf("完成待办事项失败: %w", err), ""), nil, nil
+	}
+	changed := make([]uint, 0, len(batch.todos))
+	for _, todo := range batch.todos {
+		if todo.Completed {
+			batch.results[batch.index[todo.ID]].Message = "已经是完成状态"
+			continue
+		}
+		changed = append(changed, todo.ID)
+		todo.Completed = true
+		todo.CompletedAt = &now
+		todo.UpdatedBy = userID
+		s.recordActivity(userID, todo, TodoActionComplete)
+		if err := s.onOccurrenceCompleted(todo, now); err != nil {
+			logger.Log.Errorf("生成下一次重复待办失败: todo=%d err=%v", todo.ID, err)
+		}
+	}
+	return batch.finish(nil, "已完成"), changed, nil
+}
+
+// 批量把待办及其子任务移到回收站
+func (s *TodoService) BatchDeleteTodos(userID uint, ids []uint) ([]TodoBatchResult, error) {
+	batch, err := s.prepareBatch(userID, ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(batch.todos) == 0 {
+		return batch.results, nil
+	}
+	all, err := s.withDescendants(batch.ids())
+	if err != nil {
+		return nil, err
+	}
+	// 上级和子任务一起删除后无法再查到子任务所在的清单，需要在删除前查询
+	listIDs := make([]*uint, len(batch.todos))
+	for i, todo := range batch.todos {
+		if listIDs[i], err = s.todoListID(todo); err != nil {
+			logger.Log.Errorf("查询待办所在清单失败: todo=%d err=%v", todo.ID, err)
+		}
+	}
+	if err := s.todoRepository.DeleteTodos(all); err != nil {
+		return batch.finish(fmt.Errorf("删除待办事项失败: %w", err), ""), nil
+	}
+	for i, todo := range batch.todos {
+		s.recordListActivity(userID, listIDs[i], todo, TodoActionDelete)
+	}
+	return batch.finish(nil, "已移到回收站"), nil
+}
+
+// 批量把顶层待办移到清单末尾，listID 为0时移到收集箱
+func (s *TodoService) BatchMoveTodos(userID uint, ids []uint, listID uint) ([]TodoBatchResult, error) {
+	// 移到的清单或收集箱属于 owner，其中的待办也都属于 owner
+	var target *uint
+	owner := userID
+	if listID != 0 {
+		list, err := s.getAccessibleTodoList(userID, listID, true)
+		if err != nil {
+			return nil, err
+		}
+		target = &listID
+		owner = list.UserID
+	}
+	batch, err := s.prepareBatch(userID, ids, func(todo *models.Todo) error {
+		if todo.ParentID != nil {
+			return errors.New("子任务跟随上级待办所在的清单")
+		}
+		return s.checkMoveTarget(userID, todo, target)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batch.todos) == 0 {
+		return batch.results, nil
+	}
+	start, err := s.todoRepository.NextPosition(owner, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询待办顺序失败: %w", err)
+	}
+	if err := s.todoRepository.MoveTodos(batch.ids(), target, start); err != nil {
+		return batch.finish(fmt.Errorf("移动待办事项失败: %w", err), ""), nil
+	}
+	for _, todo := range batch.todos {
+		s.moveRecurrence(todo, target)
+		s.recordMoveActivity(userID, todo, target)
+	}
+	return batch.finish(nil, "移动成功"), nil
+}
+
+// 批量添加、移除或替换待办的标签，添加和替换时不存在的标签自动创建
+func (s *TodoService) BatchTagTodos(userID uint, ids []uint, names []string, mode string) ([]TodoBatchResult, error) {
+	names, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	var tags []models.TodoTag
+	if mode == TodoTagModeRemove {
+		existing, err := s.listRepository.GetTodoTagsByUserID(userID)
+		if err != nil {
+			return nil, fmt.Errorf("查询标签失败: %w", err)
+		}
+		wanted := make(map[string]bool, len(names))
+		for _, name := range names {
+			wanted[name] = true
+		}
+		for _, tag := range existing {
+			if wanted[tag.Name] {
+				tags = append(tags, tag)
+			}
+		}
+	} else if tags, err = s.listRepository.GetOrCreateTodoTags(userID, names); err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+	tagIDs := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+
+	// 添加后标签数量不能超过上限
+	var current map[uint]map[uint]bool
+	if mode == TodoTagModeAdd {
+		names, err := s.listRepository.GetTodoTagNames(ids)
+		if err != nil {
+			return nil, fmt.Errorf("查询标签失败: %w", err)
+		}
+		current = make(map[uint]map[uint]bool)
+		for _, name := range names {
+			if current[name.TodoID] == nil {
+				current[name.TodoID] = make(map[uint]bool)
+			}
+			current[name.TodoID][name.TagID] = true
+		}
+	}
+	batch, err := s.prepareBatch(userID, ids, func(todo *models.Todo) error {
+		if current == nil {
+			return nil
+		}
+		count := len(current[todo.ID])
+		for _, tagID := range tagIDs {
+			if !current[todo.ID][tagID] {
+				count++
+			}
+		}
+		if count > MaxTodoTags {
+			return fmt.Errorf("每个待办最多%d个标签", MaxTodoTags)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(batch.todos) == 0 {
+		return batch.results, nil
+	}
+	if err := s.listRepository.BatchUpdateTodoTags(userID, batch.ids(), tagIDs, mode); err != nil {
+		return batch.finish(fmt.Errorf("设置标签失败: %w", err), ""), nil
+	}
+	for _, todo := range batch.todos {
+		s.recordActivity(userID, todo, TodoActionTag)
+	}
+	return batch.finish(nil, "设置成功"), nil
+}
+
+// 待办及其所有下级子任务的ID
+func (s *TodoService) withDescendants(ids []uint) ([]uint, error) {
+	descendants, err := s.todoRepository.GetDescendants(ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询子任务失败: %w", err)
+	}
+	all := append([]uint{}, ids...)
+	for _, descendant := range descendants {
+		all = append(all, descendant.ID)
+	}
+	return all, nil
+}
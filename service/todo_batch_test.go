@@ -0,0 +1,403 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/models"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+// 内存中的待办仓库，只实现回收站、批量操作和共享清单用到的方法
+type memoryTodoRepository struct {
+	TodoRepository
+	todos       map[uint]*models.Todo
+	tags        map[uint][]uint
+	recurrences map[uint]*models.TodoRecurrence
+	now         time.Time
+	purged      []uint
+}
+
+func newMemoryTodoRepository(now time.Time, todos ...models.Todo) *memoryTodoRepository {
+	repo := &memoryTodoRepository{
+		todos: make(map[uint]*models.Todo),
+		tags:  make(map[uint][]uint),
+		now:   now,
+	}
+	for i := range todos {
+		todo := todos[i]
+		repo.todos[todo.ID] = &todo
+	}
+	return repo
+}
+
+func (r *memoryTodoRepository) find(deleted bool, match func(todo *models.Todo) bool) []models.Todo {
+	result := make([]models.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.DeletedAt.Valid == deleted && match(todo) {
+			result = append(result, *todo)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+func (r *memoryTodoRepository) GetTodoByID(id uint) (*models.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *memoryTodoRepository) UpdateTodo(todo *models.Todo) error {
+	copied := *todo
+	r.todos[todo.ID] = &copied
+	return nil
+}
+
+func (r *memoryTodoRepository) GetDeletedTodoByID(id uint) (*models.Todo, error) {
+	todo, ok := r.todos[id]
+	if !ok || !todo.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *todo
+	return &copied, nil
+}
+
+func (r *memoryTodoRepository) GetDescendants(ids []uint) ([]models.Todo, error) {
+	return r.descendants(ids, false, func(todo *models.Todo) bool { return true }), nil
+}
+
+func (r *memoryTodoRepository) GetDeletedDescendants(id uint, deletedAt time.Time) ([]models.Todo, error) {
+	return r.descendants([]uint{id}, true, func(todo *models.Todo) bool {
+		return todo.DeletedAt.Time.Equal(deletedAt)
+	}), nil
+}
+
+func (r *memoryTodoRepository) descendants(ids []uint, deleted bool, match func(todo *models.Todo) bool) []models.Todo {
+	result := make([]models.Todo, 0)
+	parents := make(map[uint]bool)
+	for _, id := range ids {
+		parents[id] = true
+	}
+	for len(parents) > 0 {
+		children := r.find(deleted, func(todo *models.Todo) bool {
+			return todo.ParentID != nil && parents[*todo.ParentID] && match(todo)
+		})
+		parents = make(map[uint]bool)
+		for _, child := range children {
+			parents[child.ID] = true
+		}
+		result = append(result, children...)
+	}
+	return result
+}
+
+func (r *memoryTodoRepository) DeleteTodos(ids []uint) error {
+	for _, id := range ids {
+		r.todos[id].DeletedAt = gorm.DeletedAt{Time: r.now, Valid: true}
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) RestoreTodos(ids []uint) error {
+	for _, id := range ids {
+		r.todos[id].DeletedAt = gorm.DeletedAt{}
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) GetExpiredTodoIDs(before time.Time, limit int) ([]uint, error) {
+	ids := make([]uint, 0)
+	for _, todo := range r.find(true, func(todo *models.Todo) bool { return todo.DeletedAt.Time.Before(before) }) {
+		if len(ids) < limit {
+			ids = append(ids, todo.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memoryTodoRepository) PurgeTodos(ids []uint) error {
+	for _, id := range ids {
+		delete(r.todos, id)
+		delete(r.tags, id)
+	}
+	r.purged = append(r.purged, ids...)
+	return nil
+}
+
+func (r *memoryTodoRepository) CompleteTodos(ids []uint, userID uint, at time.Time) error {
+	for _, id := range ids {
+		if todo := r.todos[id]; !todo.Completed {
+			todo.Completed = true
+			todo.CompletedAt = &at
+			todo.UpdatedBy = userID
+		}
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) ReopenTodos(ids []uint) error {
+	for _, id := range ids {
+		r.todos[id].Completed = false
+		r.todos[id].CompletedAt = nil
+	}
+	return nil
+}
+
+func (r *memoryTodoRepository) NextPosition(userID uint, parentID *uint) (int, error) {
+	position := 0
+	for _, todo := range r.todos {
+		if todo.UserID == userID && sameTodoList(todo.ParentID, parentID) {
+			position = max(position, todo.Position)
+		}
+	}
+	return position + models.TodoPositionGap, nil
+}
+
+func (r *memoryTodoRepository) MoveTodos(ids []uint, listID *uint, start int) error {
+	for i, id := range ids {
+		r.todos[id].ListID = listID
+		r.todos[id].Position = start + i*models.TodoPositionGap
+	}
+	return nil
+}
+
+// 内存中的清单仓库，标签和待办的关联保存在 memoryTodoRepository 中
+type memoryTodoListRepository struct {
+	TodoListRepository
+	todos      *memoryTodoRepository
+	lists      map[uint]*models.TodoList
+	members    []models.TodoListMember
+	tags       []models.TodoTag
+	activities []models.TodoActivity
+}
+
+func newMemoryTodoListRepository(todos *memoryTodoRepository, lists ...models.TodoList) *memoryTodoListRepository {
+	repo := &memoryTodoListRepository{
+		todos: todos,
+		lists: make(map[uint]*models.TodoList),
+	}
+	for i := range lists {
+		list := lists[i]
+		repo.lists[list.ID] = &list
+	}
+	return repo
+}
+
+func (r *memoryTodoListRepository) GetTodoListByID(id uint) (*models.TodoList, error) {
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *list
+	return &copied, nil
+}
+
+func (r *memoryTodoListRepository) GetTodoListMember(listID, userID uint) (*models.TodoListMember, error) {
+	for _, member := range r.members {
+		if member.ListID == listID && member.UserID == userID {
+			return &member, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryTodoListRepository) CreateTodoActivity(activity *models.TodoActivity) error {
+	r.activities = append(r.activities, *activity)
+	return nil
+}
+
+func (r *memoryTodoListRepository) GetTodoTagsByUserID(userID uint) ([]models.TodoTag, error) {
+	tags := make([]models.TodoTag, 0)
+	for _, tag := range r.tags {
+		if tag.UserID == userID {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (r *memoryTodoListRepository) GetOrCreateTodoTags(userID uint, names []string) ([]models.TodoTag, error) {
+	tags := make([]models.TodoTag, 0, len(names))
+	for _, name := range names {
+		found := false
+		for _, tag := range r.tags {
+			if tag.UserID == userID && tag.Name == name {
+				tags = append(tags, tag)
+				found = true
+			}
+		}
+		if !found {
+			tag := models.TodoTag{ID: uint(len(r.tags) + 1), UserID: userID, Name: name}
+			r.tags = append(r.tags, tag)
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (r *memoryTodoListRepository) GetTodoTagNames(todoIDs []uint) ([]models.TodoTagName, error) {
+	names := make([]models.TodoTagName, 0)
+	for _, todoID := range todoIDs {
+		for _, tagID := range r.todos.tags[todoID] {
+			names = append(names, models.TodoTagName{TodoID: todoID, TagID: tagID, Name: r.tags[tagID-1].Name})
+		}
+	}
+	return names, nil
+}
+
+func (r *memoryTodoListRepository) BatchUpdateTodoTags(userID uint, todoIDs, tagIDs []uint, mode string) error {
+	for _, todoID := range todoIDs {
+		current := make(map[uint]bool)
+		if mode != TodoTagModeSet {
+			for _, tagID := range r.todos.tags[todoID] {
+				current[tagID] = true
+			}
+		}
+		for _, tagID := range tagIDs {
+			current[tagID] = mode != TodoTagModeRemove
+		}
+		ids := make([]uint, 0, len(current))
+		for tagID, ok := range current {
+			if ok {
+				ids = append(ids, tagID)
+			}
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		r.todos.tags[todoID] = ids
+	}
+	return nil
+}
+
+func newMemoryTodoService(now time.Time, lists []models.TodoList, todos ...models.Todo) (*TodoService, *memoryTodoRepository, *memoryTodoListRepository) {
+	todoRepo := newMemoryTodoRepository(now, todos...)
+	listRepo := newMemoryTodoListRepository(todoRepo, lists...)
+	cfg := &config.TodoConfig{TrashRetentionDays: 30, BatchLimit: 4}
+	return NewTodoService(todoRepo, listRepo, nil, cfg), todoRepo, listRepo
+}
+
+func TestRestoreTodo(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	id := func(v uint) *uint { return &v }
+	service, repo, _ := newMemoryTodoService(now, nil,
+		models.Todo{ID: 1, UserID: 1, Event: "期末复习", Completed: true},
+		models.Todo{ID: 2, UserID: 1, ParentID: id(1), Event: "数学"},
+		models.Todo{ID: 3, UserID: 1, ParentID: id(2), Event: "做题"},
+		models.Todo{ID: 4, UserID: 1, ParentID: id(2), Event: "背公式"},
+	)
+
+	// 先单独删除子任务4，再删除子任务2及其余子任务
+	assert.Equal(t, nil, repo.DeleteTodos([]uint{4}))
+	repo.now = now.Add(time.Minute)
+	assert.Equal(t, nil, repo.DeleteTodos([]uint{2, 3}))
+
+	// 其他用户不能恢复
+	_, err := service.RestoreTodo(2, 2)
+	assert.NotEqual(t, nil, err)
+
+	// 只恢复同一批删除的子任务，已完成的上级恢复为未完成
+	info, err := service.RestoreTodo(1, 2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(info.Subtasks))
+	assert.Equal(t, uint(3), info.Subtasks[0].ID)
+	assert.Equal(t, false, repo.todos[2].DeletedAt.Valid)
+	assert.Equal(t, false, repo.todos[3].DeletedAt.Valid)
+	assert.Equal(t, true, repo.todos[4].DeletedAt.Valid)
+	assert.Equal(t, false, repo.todos[1].Completed)
+
+	// 不在回收站中的待办不能恢复
+	_, err = service.RestoreTodo(1, 2)
+	assert.NotEqual(t, nil, err)
+
+	// 上级还在回收站时不能恢复
+	assert.Equal(t, nil, repo.DeleteTodos([]uint{2, 3}))
+	_, err = service.RestoreTodo(1, 3)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	service, repo, _ := newMemoryTodoService(now, nil,
+		models.Todo{ID: 1, UserID: 1, Event: "很久以前删除"},
+		models.Todo{ID: 2, UserID: 2, Event: "也是很久以前删除"},
+		models.Todo{ID: 3, UserID: 1, Event: "最近删除"},
+		models.Todo{ID: 4, UserID: 1, Event: "没有删除"},
+	)
+	repo.now = now.AddDate(0, 0, -31)
+	assert.Equal(t, nil, repo.DeleteTodos([]uint{1, 2}))
+	repo.now = now.AddDate(0, 0, -1)
+	assert.Equal(t, nil, repo.DeleteTodos([]uint{3}))
+
+	// 只彻底删除超过保留天数的待办，不区分用户
+	count, err := service.PurgeTrash(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []uint{1, 2}, repo.purged)
+	assert.Equal(t, 2, len(repo.todos))
+
+	count, err = service.PurgeTrash(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestBatchTodos(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	id := func(v uint) *uint { return &v }
+	lists := []models.TodoList{{ID: 1, UserID: 1, Name: "学习"}, {ID: 2, UserID: 2, Name: "别人的清单"}}
+	service, repo, _ := newMemoryTodoService(now, lists,
+		models.Todo{ID: 1, UserID: 1, Event: "数学", Position: 1024},
+		models.Todo{ID: 2, UserID: 1, Event: "英语", Position: 2048, Completed: true, CompletedAt: &now},
+		models.Todo{ID: 3, UserID: 1, ParentID: id(1), Event: "做题"},
+		models.Todo{ID: 4, UserID: 2, Event: "别人的待办"},
+	)
+
+	// 超过上限时整体拒绝
+	_, _, err := service.BatchCompleteTodos(1, []uint{1, 2, 3, 4, 5}, now)
+	assert.NotEqual(t, nil, err)
+
+	// 逐个返回结果，子任务一并完成，重复的ID只处理一次
+	results, changed, err := service.BatchCompleteTodos(1, []uint{1, 2, 1, 4}, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(results))
+	assert.Equal(t, []uint{1}, changed)
+	assert.Equal(t, TodoBatchResult{ID: 1, Success: true, Message: "已完成"}, results[0])
+	assert.Equal(t, TodoBatchResult{ID: 2, Success: true, Message: "已经是完成状态"}, results[1])
+	assert.Equal(t, false, results[2].Success)
+	assert.Equal(t, true, repo.todos[3].Completed)
+	assert.Equal(t, false, repo.todos[4].Completed)
+
+	// 子任务不能单独移动，不能移到别人的清单
+	results, err = service.BatchMoveTodos(1, []uint{1, 2, 3}, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []bool{true, true, false}, []bool{results[0].Success, results[1].Success, results[2].Success})
+	assert.Equal(t, uint(1), *repo.todos[1].ListID)
+	assert.Equal(t, repo.todos[1].Position+models.TodoPositionGap, repo.todos[2].Position)
+	_, err = service.BatchMoveTodos(1, []uint{1}, 2)
+	assert.NotEqual(t, nil, err)
+
+	// 添加、移除标签
+	results, err = service.BatchTagTodos(1, []uint{1, 2}, []string{"数学", "考试"}, TodoTagModeAdd)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success && results[1].Success)
+	assert.Equal(t, []uint{1, 2}, repo.tags[1])
+	_, err = service.BatchTagTodos(1, []uint{1, 2}, []string{"考试"}, TodoTagModeRemove)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []uint{1}, repo.tags[2])
+
+	// 待办及其子任务一起移到回收站
+	results, err = service.BatchDeleteTodos(1, []uint{1, 4})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success)
+	assert.Equal(t, false, results[1].Success)
+	assert.Equal(t, true, repo.todos[1].DeletedAt.Valid)
+	assert.Equal(t, true, repo.todos[3].DeletedAt.Valid)
+	assert.Equal(t, false, repo.todos[4].DeletedAt.Valid)
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"2026-FM247-BackEnd/utils"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 单个待办最多的标签数量
+const MaxTodoTags = 10
+
+// 标签名称的最大长度
+const maxTodoTagNameLength = 32
+
+type TodoListRepository interface {
+	CreateTodoList(list *models.TodoList) error
+	GetTodoListByID(id uint) (*models.TodoList, error)
+	GetTodoListByName(userID uint, name string) (*models.TodoList, error)
+	GetTodoListsByUserID(userID uint) ([]models.TodoList, error)
+	UpdateTodoList(list *models.TodoList) error
+	DeleteTodoList(id uint) error
+	NextTodoListPosition(userID uint) (int, error)
+	TodoListPositionAfter(userID uint, after *int, excludeID uint) (*int, error)
+	RebalanceTodoLists(userID uint) error
+	GetTodoTagByID(id uint) (*models.TodoTag, error)
+	GetTodoTagByName(userID uint, name string) (*models.TodoTag, error)
+	GetTodoTagsByUserID(userID uint) ([]models.TodoTag, error)
+	GetOrCreateTodoTags(userID uint, names []string) ([]models.TodoTag, error)
+	UpdateTodoTag(tag *models.TodoTag) error
+	DeleteTodoTag(id uint) error
+	SetTodoTags(userID, todoID uint, tagIDs []uint) error
+	BatchUpdateTodoTags(userID uint, todoIDs, tagIDs []uint, mode string) error
+	GetTodoTagNames(todoIDs []uint) ([]models.TodoTagName, error)
+	GetTodoListMember(listID, userID uint) (*models.TodoListMember, error)
+	GetTodoListMembers(listID uint) ([]models.TodoListMember, error)
+	CreateTodoListMember(member *models.TodoListMember) error
+	UpdateTodoListMember(member *models.TodoListMember) error
+	DeleteTodoListMember(id uint) error
+	GetSharedTodoLists(userID uint) ([]models.TodoList, []models.TodoListMember, error)
+	GetPendingTodoListInvites(userID uint) ([]models.TodoListMember, error)
+	CreateTodoActivity(activity *models.TodoActivity) error
+	GetTodoActivities(listID uint, page, pageSize int) ([]models.TodoActivity, int64, error)
+	GetUsernames(ids []uint) (map[uint]string, error)
+}
+
+type TodoListService struct {
+	repo     TodoListRepository
+	friends  TodoListFriendRepository
+	notifier TodoListNotifier
+}
+
+func NewTodoListService(repo TodoListRepository, friends TodoListFriendRepository, notifier TodoListNotifier) *TodoListService {
+	return &TodoListService{repo: repo, friends: friends, notifier: notifier}
+}
+
+// 创建清单，新清单排在最后
+func (s *TodoListService) CreateTodoList(userID uint, name, color string) (*TodoListInfo, error) {
+	if name == "" {
+		return nil, errors.New("清单名称不能为空")
+	}
+	if color != "" && !utils.ValidateHexColor(color) {
+		return nil, errors.New("颜色格式不正确，应为#RRGGBB")
+	}
+	if _, err := s.repo.GetTodoListByName(userID, name); err == nil {
+		return nil, errors.New("清单名称已存在")
+	}
+	position, err := s.repo.NextTodoListPosition(userID)
+	if err != nil {
+		return nil, fmt.Errorf("创建清单失败: %w", err)
+	}
+	list := &models.TodoList{
+		UserID:   userID,
+		Name:     name,
+		Color:    color,
+		Position: position,
+	}
+	if err := s.repo.CreateTodoList(list); err != nil {
+		return nil, fmt.Errorf("创建清单失败: %w", err)
+	}
+	info := newTodoListInfo(*list, TodoListRoleOwner)
+	return &info, nil
+}
+
+// 自己的清单在前，之后是已加入的共享清单
+func (s *TodoListService) GetTodoLists(userID uint) ([]TodoListInfo, error) {
+	lists, err := s.repo.GetTodoListsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询清单失败: %w", err)
+	}
+	shared, members, err := s.repo.GetSharedTodoLists(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询共享清单失败: %w", err)
+	}
+	infos := make([]TodoListInfo, 0, len(lists)+len(shared))
+	for _, list := range lists {
+		infos = append(infos, newTodoListInfo(list, TodoListRoleOwner))
+	}
+	roles := make(map[uint]string, len(members))
+	for _, member := range members {
+		roles[member.ListID] = member.Role
+	}
+	for _, list := range shared {
+		infos = append(infos, newTodoListInfo(list, roles[list.ID]))
+	}
+	return infos, nil
+}
+
+// 更新清单名称或颜色
+func (s *TodoListService) UpdateTodoList(userID, listID uint, name, color string) error {
+	list, err := getOwnedTodoList(s.repo, userID, listID)
+	if err != nil {
+		return err
+	}
+	if color != "" && !utils.ValidateHexColor(color) {
+		return errors.New("颜色格式不正确，应为#RRGGBB")
+	}
+	if name != "" && name != list.Name {
+		if _, err := s.repo.GetTodoListByName(userID, name); err == nil {
+			return errors.New("清单名称已存在")
+		}
+		list.Name = name
+	}
+	if color != "" {
+		list.Color = color
+	}
+	if err := s.repo.UpdateTodoList(list); err != nil {
+		return fmt.Errorf("更新清单失败: %w", err)
+	}
+	return nil
+}
+
+// 删除清单，清单中的待办移到收集箱
+func (s *TodoListService) DeleteTodoList(userID, listID uint) error {
+	if _, err := getOwnedTodoList(s.repo, userID, listID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTodoList(listID); err != nil {
+		return fmt.Errorf("删除清单失败: %w", err)
+	}
+	return nil
+}
+
+// 把清单移到 afterID 之后，afterID 为空时移到最前
+func (s *TodoListService) MoveTodoList(userID, listID uint, afterID *uint) error {
+	if _, err := getOwnedTodoList(s.repo, userID, listID); err != nil {
+		return err
+	}
+	if afterID != nil && *afterID == listID {
+		return errors.New("不能移到自己之后")
+	}
+	// 间隔用完时重新分配位置后再试一次
+	for range 2 {
+		var after *int
+		if afterID != nil {
+			previous, err := getOwnedTodoList(s.repo, userID, *afterID)
+			if err != nil {
+				return err
+			}
+			after = &previous.Position
+		}
+		next, err := s.repo.TodoListPositionAfter(userID, after, listID)
+		if err != nil {
+			return fmt.Errorf("查询清单顺序失败: %w", err)
+		}
+		if position, ok := positionBetween(after, next); ok {
+			list, err := s.repo.GetTodoListByID(listID)
+			if err != nil {
+				return errors.New("清单不存在")
+			}
+			list.Position = position
+			if err := s.repo.UpdateTodoList(list); err != nil {
+				return fmt.Errorf("调整清单顺序失败: %w", err)
+			}
+			return nil
+		}
+		if err := s.repo.RebalanceTodoLists(userID); err != nil {
+			return fmt.Errorf("调整清单顺序失败: %w", err)
+		}
+	}
+	return errors.New("调整清单顺序失败")
+}
+
+// 查询清单并校验是否为清单创建者，TodoService 和 TodoListService 共用
+func getOwnedTodoList(repo TodoListRepository, userID, listID uint) (*models.TodoList, error) {
+	list, err := repo.GetTodoListByID(listID)
+	if err != nil {
+		return nil, errors.New("清单不存在")
+	}
+	if list.UserID != userID {
+		return nil, errors.New("无权限操作该清单")
+	}
+	return list, nil
+}
+
+// 创建标签，已存在时直接返回
+func (s *TodoListService) CreateTodoTag(userID uint, name string) (*TodoTagInfo, error) {
+	names, err := normalizeTagNames([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("标签名称不能为空")
+	}
+	tags, err := s.repo.GetOrCreateTodoTags(userID, names)
+	if err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+	if len(tags) == 0 {
+		return nil, errors.New("创建标签失败")
+	}
+	return &TodoTagInfo{ID: tags[0].ID, Name: tags[0].Name}, nil
+}
+
+func (s *TodoListService) GetTodoTags(userID uint) ([]TodoTagInfo, error) {
+	tags, err := s.repo.GetTodoTagsByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询标签失败: %w", err)
+	}
+	infos := make([]TodoTagInfo, 0, len(tags))
+	for _, tag := range tags {
+		infos = append(infos, TodoTagInfo{ID: tag.ID, Name: tag.Name})
+	}
+	return infos, nil
+}
+
+// 重命名标签，所有待办上的标签一起改变
+func (s *TodoListService) RenameTodoTag(userID, tagID uint, name string) error {
+	tag, err := s.getOwnedTodoTag(userID, tagID)
+	if err != nil {
+		return err
+	}
+	names, err := normalizeTagNames([]string{name})
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return errors.New("标签名称不能为空")
+	}
+	if names[0] == tag.Name {
+		return nil
+	}
+	if _, err := s.repo.GetTodoTagByName(userID, names[0]); err == nil {
+		return errors.New("标签名称已存在")
+	}
+	tag.Name = names[0]
+	if err := s.repo.UpdateTodoTag(tag); err != nil {
+		return fmt.Errorf("重命名标签失败: %w", err)
+	}
+	return nil
+}
+
+// 删除标签，同时从所有待办上移除
+func (s *TodoListService) DeleteTodoTag(userID, tagID uint) error {
+	if _, err := s.getOwnedTodoTag(userID, tagID); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteTodoTag(tagID); err != nil {
+		return fmt.Errorf("删除标签失败: %w", err)
+	}
+	return nil
+}
+
+func (s *TodoListService) getOwnedTodoTag(userID, tagID uint) (*models.TodoTag, error) {
+	tag, err := s.repo.GetTodoTagByID(tagID)
+	if err != nil {
+		return nil, errors.New("标签不存在")
+	}
+	if tag.UserID != userID {
+		return nil, errors.New("无权限操作该标签")
+	}
+	return tag, nil
+}
+
+// 去掉首尾空白、空名称和重复的名称，保持原有顺序
+func normalizeTagNames(names []string) ([]string, error) {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if utf8.RuneCountInString(name) > maxTodoTagNameLength {
+			return nil, fmt.Errorf("标签名称不能超过%d个字", maxTodoTagNameLength)
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	if len(result) > MaxTodoTags {
+		return nil, fmt.Errorf("每个待办最多%d个标签", MaxTodoTags)
+	}
+	return result, nil
+}
+
+func newTodoListInfo(list models.TodoList, role string) TodoListInfo {
+	return TodoListInfo{
+		ID:      list.ID,
+		Name:    list.Name,
+		Color:   list.Color,
+		OwnerID: list.UserID,
+		Role:    role,
+	}
+}
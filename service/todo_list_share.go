@@ -0,0 +1,303 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// 用户在清单中的角色
+const (
+	TodoListRoleOwner  = "owner"  // 创建者
+	TodoListRoleEditor = "editor" // 可以添加、修改、完成和删除待办
+	TodoListRoleViewer = "viewer" // 只能查看
+)
+
+// 共享清单成员的状态
+const (
+	TodoListMemberPending  = "pending"
+	TodoListMemberAccepted = "accepted"
+)
+
+// 通知类型
+const NotificationTypeTodoListInvite = "todo_list_invite"
+
+// 发送邀请通知
+type TodoListNotifier interface {
+	Notify(userID uint, notification *models.Notification) error
+}
+
+// 邀请前确认好友关系
+type TodoListFriendRepository interface {
+	GetFriendIDs(userID uint) ([]uint, error)
+}
+
+// 邀请用户加入清单，只有创建者可以邀请，已经邀请过时修改角色
+// 只能邀请已经把创建者加为好友的用户，避免向任意用户发送邀请
+func (s *TodoListService) InviteTodoListMember(ownerID, listID, inviteeID uint, role string) (*TodoListMemberInfo, error) {
+	list, err := getOwnedTodoList(s.repo, ownerID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if !validTodoListRole(role) {
+		return nil, errors.New("角色只能是 editor 或 viewer")
+	}
+	if inviteeID == ownerID {
+		return nil, errors.New("不能邀请自己")
+	}
+	usernames, err := s.repo.GetUsernames([]uint{ownerID, inviteeID})
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	if _, ok := usernames[inviteeID]; !ok {
+		return nil, errors.New("用户不存在")
+	}
+	friendIDs, err := s.friends.GetFriendIDs(inviteeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询好友失败: %w", err)
+	}
+	if !slices.Contains(friendIDs, ownerID) {
+		return nil, errors.New("只能邀请已经把你加为好友的用户")
+	}
+	member, err := s.repo.GetTodoListMember(listID, inviteeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询清单成员失败: %w", err)
+	}
+	if member != nil {
+		member.Role = role
+		if err := s.repo.UpdateTodoListMember(member); err != nil {
+			return nil, fmt.Errorf("修改成员角色失败: %w", err)
+		}
+	} else {
+		member = &models.TodoListMember{
+			ListID:    listID,
+			UserID:    inviteeID,
+			Role:      role,
+			Status:    TodoListMemberPending,
+			InvitedBy: ownerID,
+		}
+		if err := s.repo.CreateTodoListMember(member); err != nil {
+			return nil, fmt.Errorf("邀请失败: %w", err)
+		}
+		// 通知被邀请的用户，失败只记录日志
+		notification := &models.Notification{
+			Type:    NotificationTypeTodoListInvite,
+			Title:   "收到清单邀请：" + list.Name,
+			Content: fmt.Sprintf("%s 邀请你加入清单「%s」", usernames[ownerID], list.Name),
+		}
+		if err := s.notifier.Notify(inviteeID, notification); err != nil {
+			logger.Log.Errorf("发送清单邀请通知失败: list=%d user=%d err=%v", listID, inviteeID, err)
+		}
+	}
+	return &TodoListMemberInfo{
+		UserID:    member.UserID,
+		Username:  usernames[inviteeID],
+		Role:      member.Role,
+		Status:    member.Status,
+		CreatedAt: member.CreatedAt,
+	}, nil
+}
+
+// 收到的还未处理的邀请
+func (s *TodoListService) GetTodoListInvites(userID uint) ([]TodoListInviteInfo, error) {
+	members, err := s.repo.GetPendingTodoListInvites(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询邀请失败: %w", err)
+	}
+	inviterIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		inviterIDs = append(inviterIDs, member.InvitedBy)
+	}
+	usernames, err := s.repo.GetUsernames(inviterIDs)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	infos := make([]TodoListInviteInfo, 0, len(members))
+	for _, member := range members {
+		list, err := s.repo.GetTodoListByID(member.ListID)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, TodoListInviteInfo{
+			ListID:      list.ID,
+			ListName:    list.Name,
+			Role:        member.Role,
+			InvitedBy:   member.InvitedBy,
+			InviterName: usernames[member.InvitedBy],
+			InvitedAt:   member.CreatedAt,
+		})
+	}
+	return infos, nil
+}
+
+// 接受或拒绝邀请，拒绝后创建者可以再次邀请
+func (s *TodoListService) RespondTodoListInvite(userID, listID uint, accept bool) error {
+	member, err := s.repo.GetTodoListMember(listID, userID)
+	if err != nil {
+		return fmt.Errorf("查询邀请失败: %w", err)
+	}
+	if member == nil || member.Status != TodoListMemberPending {
+		return errors.New("邀请不存在")
+	}
+	if !accept {
+		if err := s.repo.DeleteTodoListMember(member.ID); err != nil {
+			return fmt.Errorf("拒绝邀请失败: %w", err)
+		}
+		return nil
+	}
+	member.Status = TodoListMemberAccepted
+	if err := s.repo.UpdateTodoListMember(member); err != nil {
+		return fmt.Errorf("接受邀请失败: %w", err)
+	}
+	return nil
+}
+
+// 清单的创建者和所有成员，创建者和成员都可以查看
+func (s *TodoListService) GetTodoListMembers(userID, listID uint) ([]TodoListMemberInfo, error) {
+	list, err := s.getAccessibleTodoList(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.repo.GetTodoListMembers(listID)
+	if err != nil {
+		return nil, fmt.Errorf("查询清单成员失败: %w", err)
+	}
+	ids := []uint{list.UserID}
+	for _, member := range members {
+		ids = append(ids, member.UserID)
+	}
+	usernames, err := s.repo.GetUsernames(ids)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	infos := []TodoListMemberInfo{{
+		UserID:    list.UserID,
+		Username:  usernames[list.UserID],
+		Role:      TodoListRoleOwner,
+		Status:    TodoListMemberAccepted,
+		CreatedAt: list.CreatedAt,
+	}}
+	for _, member := range members {
+		infos = append(infos, TodoListMemberInfo{
+			UserID:    member.UserID,
+			Username:  usernames[member.UserID],
+			Role:      member.Role,
+			Status:    member.Status,
+			CreatedAt: member.CreatedAt,
+		})
+	}
+	return infos, nil
+}
+
+// 修改成员的角色，只有创建者可以修改
+func (s *TodoListService) UpdateTodoListMemberRole(ownerID, listID, memberID uint, role string) error {
+	if _, err := getOwnedTodoList(s.repo, ownerID, listID); err != nil {
+		return err
+	}
+	if !validTodoListRole(role) {
+		return errors.New("角色只能是 editor 或 viewer")
+	}
+	member, err := s.repo.GetTodoListMember(listID, memberID)
+	if err != nil {
+		return fmt.Errorf("查询清单成员失败: %w", err)
+	}
+	if member == nil {
+		return errors.New("该用户不是清单成员")
+	}
+	member.Role = role
+	if err := s.repo.UpdateTodoListMember(member); err != nil {
+		return fmt.Errorf("修改成员角色失败: %w", err)
+	}
+	return nil
+}
+
+// 移除成员或取消邀请，创建者可以移除任何成员，成员可以退出清单
+func (s *TodoListService) RemoveTodoListMember(userID, listID, memberID uint) error {
+	list, err := s.repo.GetTodoListByID(listID)
+	if err != nil {
+		return errors.New("清单不存在")
+	}
+	if list.UserID != userID && memberID != userID {
+		return errors.New("只有创建者可以移除其他成员")
+	}
+	member, err := s.repo.GetTodoListMember(listID, memberID)
+	if err != nil {
+		return fmt.Errorf("查询清单成员失败: %w", err)
+	}
+	if member == nil {
+		return errors.New("该用户不是清单成员")
+	}
+	if err := s.repo.DeleteTodoListMember(member.ID); err != nil {
+		return fmt.Errorf("移除成员失败: %w", err)
+	}
+	return nil
+}
+
+// 分页查询清单中待办的修改记录，创建者和成员都可以查看
+func (s *TodoListService) GetTodoActivities(userID, listID uint, page, pageSize int) ([]TodoActivityInfo, int64, error) {
+	if _, err := s.getAccessibleTodoList(userID, listID); err != nil {
+		return nil, 0, err
+	}
+	activities, total, err := s.repo.GetTodoActivities(listID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询修改记录失败: %w", err)
+	}
+	ids := make([]uint, 0, len(activities))
+	for _, activity := range activities {
+		ids = append(ids, activity.UserID)
+	}
+	usernames, err := s.repo.GetUsernames(ids)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %w", err)
+	}
+	infos := make([]TodoActivityInfo, 0, len(activities))
+	for _, activity := range activities {
+		infos = append(infos, TodoActivityInfo{
+			ID:        activity.ID,
+			TodoID:    activity.TodoID,
+			UserID:    activity.UserID,
+			Username:  usernames[activity.UserID],
+			Action:    activity.Action,
+			Event:     activity.Event,
+			CreatedAt: activity.CreatedAt,
+		})
+	}
+	return infos, total, nil
+}
+
+// 创建者或已加入的成员可以访问的清单
+func (s *TodoListService) getAccessibleTodoList(userID, listID uint) (*models.TodoList, error) {
+	list, err := s.repo.GetTodoListByID(listID)
+	if err != nil {
+		return nil, errors.New("清单不存在")
+	}
+	role, err := todoListRole(s.repo, userID, list)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("无权限访问该清单")
+	}
+	return list, nil
+}
+
+// 用户在清单中的角色，不是创建者也不是已加入的成员时返回空字符串
+func todoListRole(repo TodoAccessRepository, userID uint, list *models.TodoList) (string, error) {
+	if list.UserID == userID {
+		return TodoListRoleOwner, nil
+	}
+	member, err := repo.GetTodoListMember(list.ID, userID)
+	if err != nil {
+		return "", fmt.Errorf("查询清单成员失败: %w", err)
+	}
+	if member == nil || member.Status != TodoListMemberAccepted {
+		return "", nil
+	}
+	return member.Role, nil
+}
+
+func validTodoListRole(role string) bool {
+	return role == TodoListRoleEditor || role == TodoListRoleViewer
+}
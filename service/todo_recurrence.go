@@ -0,0 +1,289 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 修改或删除重复待办时的范围
+const (
+	TodoScopeThis   = "this"   // 只影响这一次
+	TodoScopeFuture = "future" // 影响这一次及以后
+)
+
+// RecurrenceInput 重复方式，Preset 为 daily / weekdays / weekly / monthly / rrule
+type RecurrenceInput struct {
+	Preset string
+	Days   []int  // weekly 时重复的星期，1为周一，7为周日，为空时取截止时间的星期
+	RRule  string // rrule 时使用
+}
+
+// 创建重复待办，todo 为第一次的待办，没有截止时间时默认当天23:59截止
+func (s *TodoService) createRecurringTodo(todo *models.Todo, input *RecurrenceInput, tagNames []string, now time.Time) (TodoInfo, string) {
+	start := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, now.Location())
+	if todo.DueAt != nil {
+		start = todo.DueAt.In(now.Location()).Truncate(time.Minute)
+	}
+	rule, err := recurrenceRuleFromPreset(input.Preset, input.Days, input.RRule, start)
+	if err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	first, ok := rule.first(start)
+	if !ok {
+		return TodoInfo{}, "重复规则没有可用的日期"
+	}
+	recurrence := &models.TodoRecurrence{
+		UserID:            todo.UserID,
+		RRule:             rule.String(),
+		StartAt:           start,
+		Event:             todo.Event,
+		Description:       todo.Description,
+		Priority:          todo.Priority,
+		EstimatedTomatoes: todo.EstimatedTomatoes,
+		ListID:            todo.ListID,
+		LastOccurrenceAt:  first,
+		Active:            true,
+	}
+	todo.DueAt = &first
+	todo.OccurrenceAt = &first
+	tags, err := s.todoRepository.CreateRecurringTodo(recurrence, todo, tagNames)
+	if err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	info := newTodoInfo(*todo)
+	info.Tags = newTodoTagInfos(tags)
+	return info, "创建成功"
+}
+
+// 完成最近一次的重复待办后，提前生成下一次
+func (s *TodoService) onOccurrenceCompleted(todo *models.Todo, now time.Time) error {
+	if todo.RecurrenceID == nil || todo.OccurrenceAt == nil {
+		return nil
+	}
+	recurrence, err := s.todoRepository.GetRecurrenceByID(*todo.RecurrenceID)
+	if err != nil {
+		return fmt.Errorf("查询重复规则失败: %w", err)
+	}
+	if !recurrence.Active || todo.OccurrenceAt.Before(recurrence.LastOccurrenceAt) {
+		return nil
+	}
+	rule, err := parseRecurrenceRule(recurrence.RRule, now.Location())
+	if err != nil {
+		return err
+	}
+	next, ok := rule.next(recurrence.StartAt.In(now.Location()), recurrence.LastOccurrenceAt)
+	if !ok {
+		recurrence.Active = false
+		return s.todoRepository.UpdateRecurrence(recurrence)
+	}
+	_, err = s.createOccurrence(recurrence, next)
+	return err
+}
+
+// 为所有重复规则生成到今天为止最近的一次待办，错过的重复直接跳过，返回生成的数量
+func (s *TodoService) GenerateDueOccurrences(now time.Time) (int, error) {
+	recurrences, err := s.todoRepository.GetActiveRecurrences()
+	if err != nil {
+		return 0, fmt.Errorf("查询重复规则失败: %w", err)
+	}
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	count := 0
+	for i := range recurrences {
+		recurrence := &recurrences[i]
+		rule, err := parseRecurrenceRule(recurrence.RRule, now.Location())
+		if err != nil {
+			logger.Log.Errorf("解析重复规则失败: recurrence=%d err=%v", recurrence.ID, err)
+			continue
+		}
+		start := recurrence.StartAt.In(now.Location())
+		if _, ok := rule.next(start, recurrence.LastOccurrenceAt); !ok {
+			recurrence.Active = false
+			if err := s.todoRepository.UpdateRecurrence(recurrence); err != nil {
+				logger.Log.Errorf("结束重复规则失败: recurrence=%d err=%v", recurrence.ID, err)
+			}
+			continue
+		}
+		occurrence, ok := rule.latestBefore(start, recurrence.LastOccurrenceAt, tomorrow)
+		if !ok {
+			continue
+		}
+		created, err := s.createOccurrence(recurrence, occurrence)
+		if err != nil {
+			logger.Log.Errorf("生成重复待办失败: recurrence=%d err=%v", recurrence.ID, err)
+			continue
+		}
+		if created {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// 按模板生成某一次的待办
+func (s *TodoService) createOccurrence(recurrence *models.TodoRecurrence, at time.Time) (bool, error) {
+	position, err := s.todoRepository.NextPosition(recurrence.UserID, nil)
+	if err != nil {
+		return false, err
+	}
+	todo := &models.Todo{
+		UserID:            recurrence.UserID,
+		CreatedBy:         recurrence.UserID,
+		UpdatedBy:         recurrence.UserID,
+		ListID:            recurrence.ListID,
+		Position:          position,
+		Event:             recurrence.Event,
+		Description:       recurrence.Description,
+		Priority:          recurrence.Priority,
+		EstimatedTomatoes: recurrence.EstimatedTomatoes,
+		DueAt:             &at,
+		RecurrenceID:      &recurrence.ID,
+		OccurrenceAt:      &at,
+	}
+	created, err := s.todoRepository.CreateOccurrence(recurrence.LastOccurrenceAt, todo)
+	if err != nil || !created {
+		return created, err
+	}
+	// 沿用上一次的提醒，失败只记录日志
+	if err := s.reminders.CopyRecurrenceReminders(todo); err != nil {
+		logger.Log.Errorf("设置重复待办提醒失败: todo=%d err=%v", todo.ID, err)
+	}
+	return true, nil
+}
+
+// 把这一次的修改应用到以后：更新模板，修改了重复规则或截止时间时从这一次重新开始计算
+func (s *TodoService) updateFutureOccurrences(todo *models.Todo, input TodoInput, now time.Time) error {
+	recurrence, err := s.todoRepository.GetRecurrenceByID(*todo.RecurrenceID)
+	if err != nil {
+		return errors.New("重复规则不存在")
+	}
+	recurrence.Event = todo.Event
+	recurrence.Description = todo.Description
+	recurrence.Priority = todo.Priority
+	recurrence.EstimatedTomatoes = todo.EstimatedTomatoes
+
+	pending, err := s.todoRepository.GetPendingOccurrences(recurrence.ID, *todo.OccurrenceAt)
+	if err != nil {
+		return fmt.Errorf("查询以后的待办失败: %w", err)
+	}
+	later := make([]models.Todo, 0, len(pending))
+	for _, occurrence := range pending {
+		if occurrence.ID != todo.ID {
+			later = append(later, occurrence)
+		}
+	}
+
+	var purgeIDs []uint
+	if input.Recurrence != nil || input.DueAt != nil {
+		// 从这一次重新开始计算，已按旧规则生成的以后的待办删除
+		start := todo.OccurrenceAt.In(now.Location())
+		if todo.DueAt != nil {
+			start = todo.DueAt.In(now.Location()).Truncate(time.Minute)
+		}
+		rule, err := parseRecurrenceRule(recurrence.RRule, now.Location())
+		if err != nil {
+			return err
+		}
+		if input.Recurrence != nil {
+			// 新的重复规则的 COUNT 从这一次开始计算
+			if rule, err = recurrenceRuleFromPreset(input.Recurrence.Preset, input.Recurrence.Days, input.Recurrence.RRule, start); err != nil {
+				return err
+			}
+		} else if rule.Count > 0 {
+			// 沿用原规则时扣除这一次之前已经出现的次数，这一次算作新起点的第一次
+			used := rule.countBefore(recurrence.StartAt.In(now.Location()), todo.OccurrenceAt.In(now.Location()))
+			rule.Count = max(rule.Count-used, 1)
+		}
+		recurrence.RRule = rule.String()
+		recurrence.StartAt = start
+		recurrence.LastOccurrenceAt = start
+		recurrence.Active = true
+		todo.DueAt = &start
+		todo.OccurrenceAt = &start
+		if len(later) > 0 {
+			ids := make([]uint, 0, len(later))
+			for _, occurrence := range later {
+				ids = append(ids, occurrence.ID)
+			}
+			descendants, err := s.todoRepository.GetDescendants(ids)
+			if err != nil {
+				return fmt.Errorf("查询子任务失败: %w", err)
+			}
+			for _, descendant := range descendants {
+				ids = append(ids, descendant.ID)
+			}
+			// 按旧规则自动生成的待办直接彻底删除，不放入回收站
+			purgeIDs = ids
+		}
+		later = nil
+	} else {
+		for i := range later {
+			later[i].Event = recurrence.Event
+			later[i].Description = recurrence.Description
+			later[i].Priority = recurrence.Priority
+			later[i].EstimatedTomatoes = recurrence.EstimatedTomatoes
+		}
+	}
+	todos := append([]models.Todo{*todo}, later...)
+	if err := s.todoRepository.UpdateFutureOccurrences(recurrence, todos, purgeIDs); err != nil {
+		return fmt.Errorf("更新以后的待办失败: %w", err)
+	}
+	return nil
+}
+
+// 停止重复规则，并返回这一次及以后还未完成的待办，由调用方删除
+func (s *TodoService) stopRecurrence(todo *models.Todo) ([]uint, error) {
+	recurrence, err := s.todoRepository.GetRecurrenceByID(*todo.RecurrenceID)
+	if err != nil {
+		return nil, errors.New("重复规则不存在")
+	}
+	recurrence.Active = false
+	if err := s.todoRepository.UpdateRecurrence(recurrence); err != nil {
+		return nil, fmt.Errorf("停止重复规则失败: %w", err)
+	}
+	pending, err := s.todoRepository.GetPendingOccurrences(recurrence.ID, *todo.OccurrenceAt)
+	if err != nil {
+		return nil, fmt.Errorf("查询以后的待办失败: %w", err)
+	}
+	ids := []uint{todo.ID}
+	for _, occurrence := range pending {
+		if occurrence.ID != todo.ID {
+			ids = append(ids, occurrence.ID)
+		}
+	}
+	return ids, nil
+}
+
+// 分页查询重复待办每一次的完成情况
+func (s *TodoService) GetRecurrenceHistory(userID, recurrenceID uint, page, pageSize int) (*TodoRecurrenceInfo, []TodoInfo, int64, error) {
+	recurrence, err := s.todoRepository.GetRecurrenceByID(recurrenceID)
+	if err != nil {
+		return nil, nil, 0, errors.New("重复规则不存在")
+	}
+	// 与待办一致，共享清单中的重复待办成员也可以查看
+	if recurrence.UserID != userID {
+		if recurrence.ListID == nil {
+			return nil, nil, 0, errors.New("无权限访问该重复规则")
+		}
+		if _, err := s.getAccessibleTodoList(userID, *recurrence.ListID, false); err != nil {
+			return nil, nil, 0, err
+		}
+	}
+	todos, total, err := s.todoRepository.GetOccurrences(recurrenceID, page, pageSize)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("查询重复待办失败: %w", err)
+	}
+	infos := make([]TodoInfo, 0, len(todos))
+	for _, todo := range todos {
+		infos = append(infos, newTodoInfo(todo))
+	}
+	return &TodoRecurrenceInfo{
+		ID:               recurrence.ID,
+		RRule:            recurrence.RRule,
+		StartAt:          recurrence.StartAt,
+		LastOccurrenceAt: recurrence.LastOccurrenceAt,
+		Active:           recurrence.Active,
+	}, infos, total, nil
+}
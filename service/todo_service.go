@@ -1,76 +1,338 @@
 package service
 
 import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
 	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 )
 
 type TodoRepository interface {
-	CreateTodo(todo *models.Todo) error
-	GetTodosByUserID(userID uint) ([]models.Todo, error)
+	CreateTodo(todo *models.Todo, tagNames []string) ([]models.TodoTag, error)
+	GetTodosByUserID(userID uint, filter models.TodoFilter) ([]models.Todo, error)
+	GetAssignedTodos(assigneeID uint, status string) ([]models.Todo, error)
 	GetTodoByID(id uint) (*models.Todo, error)
 	UpdateTodo(todo *models.Todo) error
 	DeleteTodo(id uint) error
+	NextPosition(userID uint, parentID *uint) (int, error)
+	GetDescendants(ids []uint) ([]models.Todo, error)
+	DeleteTodos(ids []uint) error
+	PurgeTodos(ids []uint) error
+	GetDeletedTodos(userID uint, page, pageSize int) ([]models.Todo, int64, error)
+	GetDeletedTodoByID(id uint) (*models.Todo, error)
+	GetDeletedDescendants(id uint, deletedAt time.Time) ([]models.Todo, error)
+	RestoreTodos(ids []uint) error
+	GetExpiredTodoIDs(before time.Time, limit int) ([]uint, error)
+	MoveTodos(ids []uint, listID *uint, start int) error
+	CompleteTodos(ids []uint, userID uint, at time.Time) error
+	ReopenTodos(ids []uint) error
+	ReorderSubtasks(parentID uint, orderedIDs []uint) error
+	TodoPositionAfter(userID uint, listID *uint, after *int, excludeID uint) (*int, error)
+	RebalanceTodoPositions(userID uint, listID *uint) error
+	MoveTodo(id uint, listID *uint, position int) error
+	IncrementTodoStudyData(userID, todoID uint, date time.Time, studyTime, tomatoes int) error
+	DecrementTodoStudyData(userID, todoID uint, date time.Time, studyTime, tomatoes int) error
+	CreateRecurringTodo(recurrence *models.TodoRecurrence, todo *models.Todo, tagNames []string) ([]models.TodoTag, error)
+	GetRecurrenceByID(id uint) (*models.TodoRecurrence, error)
+	UpdateRecurrence(recurrence *models.TodoRecurrence) error
+	UpdateFutureOccurrences(recurrence *models.TodoRecurrence, todos []models.Todo, purgeIDs []uint) error
+	GetActiveRecurrences() ([]models.TodoRecurrence, error)
+	CreateOccurrence(previous time.Time, todo *models.Todo) (bool, error)
+	GetOccurrences(recurrenceID uint, page, pageSize int) ([]models.Todo, int64, error)
+	GetPendingOccurrences(recurrenceID uint, from time.Time) ([]models.Todo, error)
+}
+
+// 待办截止时间变化或生成重复待办时同步提醒
+type TodoReminderScheduler interface {
+	SyncTodoReminders(todo *models.Todo, now time.Time) error
+	CopyRecurrenceReminders(todo *models.Todo) error
+}
+
+// 子任务最多嵌套的层数（顶层待办为第1层）
+const MaxTodoDepth = 5
+
+// 定时生成重复待办、清理回收站的间隔
+const todoCheckInterval = 10 * time.Minute
+
+// 待办事项优先级
+const (
+	TodoPriorityNone   = 0
+	TodoPriorityLow    = 1
+	TodoPriorityMedium = 2
+	TodoPriorityHigh   = 3
+)
+
+// TodoInput 创建或更新待办事项的字段，更新时为nil的字段保持不变
+type TodoInput struct {
+	ParentID          *uint    // 只在创建时有效，创建为该待办的子任务
+	ListID            *uint    // 只在创建顶层待办时有效，放入该清单
+	Tags              []string // 只在创建时有效，不存在的标签自动创建
+	Event             *string
+	Description       *string
+	Priority          *int
+	DueAt             *time.Time
+	ClearDueAt        bool // 清除截止时间
+	EstimatedTomatoes *int
+	Recurrence        *RecurrenceInput // 重复方式，创建时或修改以后的重复时有效
 }
 
 type TodoService struct {
 	todoRepository TodoRepository
+	listRepository TodoListRepository
+	reminders      TodoReminderScheduler
+	cfg            *config.TodoConfig
+	stop           chan struct{}
+	done           chan struct{}
+}
+
+func NewTodoService(todoRepository TodoRepository, listRepository TodoListRepository, reminders TodoReminderScheduler, cfg *config.TodoConfig) *TodoService {
+	return &TodoService{
+		todoRepository: todoRepository,
+		listRepository: listRepository,
+		reminders:      reminders,
+		cfg:            cfg,
+		stop:           make(chan struct{}),
+		done:           make(chan struct{}),
+	}
 }
 
-func NewTodoService(todoRepository TodoRepository) *TodoService {
-	return &TodoService{todoRepository: todoRepository}
+// 启动定时任务，为到期的重复规则生成当天的待办，并彻底删除回收站中过期的待办
+func (s *TodoService) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(todoCheckInterval)
+		defer ticker.Stop()
+		for {
+			s.runScheduled(reportNow())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
 }
 
-// CreateTodo 创建待办事项
-func (s *TodoService) CreateTodo(userID uint, event string) string {
+func (s *TodoService) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *TodoService) runScheduled(now time.Time) {
+	if count, err := s.GenerateDueOccurrences(now); err != nil {
+		logger.Log.Errorf("生成重复待办失败: %v", err)
+	} else if count > 0 {
+		logger.Log.Infof("已生成%d条重复待办", count)
+	}
+	if count, err := s.PurgeTrash(now); err != nil {
+		logger.Log.Errorf("清理回收站失败: %v", err)
+	} else if count > 0 {
+		logger.Log.Infof("已彻底删除回收站中%d条过期的待办", count)
+	}
+}
+
+// 把输入的字段应用到待办事项上
+func applyTodoInput(todo *models.Todo, input TodoInput) string {
+	if input.Event != nil {
+		if *input.Event == "" {
+			return "事件不能为空"
+		}
+		todo.Event = *input.Event
+	}
+	if input.Description != nil {
+		todo.Description = *input.Description
+	}
+	if input.Priority != nil {
+		if *input.Priority < TodoPriorityNone || *input.Priority > TodoPriorityHigh {
+			return "优先级不合法"
+		}
+		todo.Priority = *input.Priority
+	}
+	if input.ClearDueAt {
+		todo.DueAt = nil
+	} else if input.DueAt != nil {
+		todo.DueAt = input.DueAt
+	}
+	if input.EstimatedTomatoes != nil {
+		if *input.EstimatedTomatoes < 0 {
+			return "预计番茄钟数量不能为负数"
+		}
+		todo.EstimatedTomatoes = *input.EstimatedTomatoes
+	}
+	return ""
+}
+
+// CreateTodo 创建待办事项，设置了重复方式时同时创建重复规则
+func (s *TodoService) CreateTodo(userID uint, input TodoInput, now time.Time) (TodoInfo, string) {
+	// 共享清单中的待办属于清单创建者，CreatedBy 记录实际创建的用户
 	todo := &models.Todo{
-		UserID: userID,
-		Event:  event,
+		UserID:    userID,
+		CreatedBy: userID,
+		UpdatedBy: userID,
+	}
+	if input.Event == nil {
+		return TodoInfo{}, "事件不能为空"
+	}
+	if msg := applyTodoInput(todo, input); msg != "" {
+		return TodoInfo{}, msg
 	}
 
-	if err := s.todoRepository.CreateTodo(todo); err != nil {
-		return err.Error()
+	// 子任务需要校验上级待办
+	var ancestors []models.Todo
+	if input.ParentID != nil {
+		if input.Recurrence != nil {
+			return TodoInfo{}, "子任务不能设置重复"
+		}
+		if input.ListID != nil {
+			return TodoInfo{}, "子任务跟随上级待办所在的清单"
+		}
+		parent, err := s.todoRepository.GetTodoByID(*input.ParentID)
+		if err != nil {
+			return TodoInfo{}, "上级待办事项不存在"
+		}
+		if err := s.checkTodoAccess(userID, parent, true); err != nil {
+			return TodoInfo{}, "无权限在该待办事项下添加子任务"
+		}
+		todo.UserID = parent.UserID
+		ancestors, err = s.getAncestors(parent)
+		if err != nil {
+			return TodoInfo{}, err.Error()
+		}
+		ancestors = append([]models.Todo{*parent}, ancestors...)
+		if len(ancestors)+1 > MaxTodoDepth {
+			return TodoInfo{}, fmt.Sprintf("子任务最多嵌套%d层", MaxTodoDepth)
+		}
+		todo.ParentID = input.ParentID
+	}
+	if input.ListID != nil {
+		list, err := s.getAccessibleTodoList(userID, *input.ListID, true)
+		if err != nil {
+			return TodoInfo{}, err.Error()
+		}
+		todo.UserID = list.UserID
+		todo.ListID = input.ListID
 	}
+	tagNames, err := normalizeTagNames(input.Tags)
+	if err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	position, err := s.todoRepository.NextPosition(todo.UserID, todo.ParentID)
+	if err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	todo.Position = position
+
+	// 待办和标签在同一个事务中创建
+	var info TodoInfo
+	if input.Recurrence != nil {
+		var msg string
+		if info, msg = s.createRecurringTodo(todo, input.Recurrence, tagNames, now); msg != "创建成功" {
+			return TodoInfo{}, msg
+		}
+	} else {
+		tags, err := s.todoRepository.CreateTodo(todo, tagNames)
+		if err != nil {
+			return TodoInfo{}, err.Error()
+		}
+		// 新增未完成的子任务后，已完成的上级恢复为未完成
+		if err := s.reopenTodos(ancestors); err != nil {
+			return TodoInfo{}, err.Error()
+		}
+		info = newTodoInfo(*todo)
+		info.Tags = newTodoTagInfos(tags)
+	}
+	s.recordActivity(userID, todo, TodoActionCreate)
 
-	return "创建成功"
+	return info, "创建成功"
 }
 
-// UpdateTodo 更新待办事项
-func (s *TodoService) UpdateTodo(userID, todoID uint, event string) string {
+// UpdateTodo 更新待办事项，scope 为 future 时同时修改重复待办以后的每一次
+func (s *TodoService) UpdateTodo(userID, todoID uint, input TodoInput, scope string, now time.Time) string {
 	todo, err := s.todoRepository.GetTodoByID(todoID)
 	if err != nil {
 		return "待办事项不存在"
 	}
 
-	if todo.UserID != userID {
+	if err := s.checkTodoAccess(userID, todo, true); err != nil {
 		return "无权限更新该待办事项"
 	}
 
-	// 更新字段
-	if event != "" {
-		todo.Event = event
+	if scope == TodoScopeFuture && todo.RecurrenceID == nil {
+		return "该待办事项不是重复待办"
+	}
+	if input.Recurrence != nil && scope != TodoScopeFuture {
+		return "修改重复方式需要同时修改以后的待办"
 	}
 
-	if err := s.todoRepository.UpdateTodo(todo); err != nil {
+	// 更新字段
+	previousDueAt := todo.DueAt
+	if msg := applyTodoInput(todo, input); msg != "" {
+		return msg
+	}
+	todo.UpdatedBy = userID
+	if scope == TodoScopeFuture {
+		// 这一次和以后的待办在同一个事务中保存
+		if err := s.updateFutureOccurrences(todo, input, now); err != nil {
+			return err.Error()
+		}
+	} else if err := s.todoRepository.UpdateTodo(todo); err != nil {
 		return err.Error()
 	}
+	// 截止时间变化后重新计算提醒时间，失败只记录日志
+	if !sameTime(previousDueAt, todo.DueAt) {
+		if err := s.reminders.SyncTodoReminders(todo, now); err != nil {
+			logger.Log.Errorf("同步待办提醒失败: todo=%d err=%v", todo.ID, err)
+		}
+	}
+	s.recordActivity(userID, todo, TodoActionUpdate)
 
 	return "更新成功"
 }
 
-func (s *TodoService) GetTodosByUserID(userID uint) ([]TodoInfo, string, bool) {
-	todos, err := s.todoRepository.GetTodosByUserID(userID)
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equal(*b)
+}
+
+// 获取待办列表，查询共享给自己的清单时返回清单创建者在该清单中的待办
+func (s *TodoService) GetTodosByUserID(userID uint, filter models.TodoFilter) ([]TodoInfo, string, bool) {
+	ownerID := userID
+	if filter.ListID != nil && *filter.ListID != 0 {
+		list, err := s.getAccessibleTodoList(userID, *filter.ListID, false)
+		if err != nil {
+			return nil, err.Error(), false
+		}
+		ownerID = list.UserID
+	}
+	todos, err := s.todoRepository.GetTodosByUserID(ownerID, filter)
 	if err != nil {
 		return nil, err.Error(), false
 	}
 	if len(todos) == 0 {
 		return nil, "", false
 	}
+	ids := make([]uint, 0, len(todos))
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	descendants, err := s.todoRepository.GetDescendants(ids)
+	if err != nil {
+		return nil, err.Error(), false
+	}
 	var todoInfos []TodoInfo
 	for _, todo := range todos {
-		todoInfos = append(todoInfos, TodoInfo{
-			ID:    todo.ID,
-			Event: todo.Event,
-		})
+		info := buildTodoTree(todo, descendants)
+		info.Subtasks = nil
+		todoInfos = append(todoInfos, info)
+	}
+	if err := s.attachTags(todoInfos); err != nil {
+		return nil, err.Error(), false
 	}
 	return todoInfos, "", true
 }
@@ -80,27 +342,482 @@ func (s *TodoService) GetTodoByID(userID, id uint) (TodoInfo, string) {
 	if err != nil {
 		return TodoInfo{}, err.Error()
 	}
-	if todo.UserID != userID {
+	if err := s.checkTodoAccess(userID, todo, false); err != nil {
 		return TodoInfo{}, "无权限访问该待办事项"
 	}
-	todoinfo := TodoInfo{
-		ID:    todo.ID,
-		Event: todo.Event,
+	descendants, err := s.todoRepository.GetDescendants([]uint{todo.ID})
+	if err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	infos := []TodoInfo{buildTodoTree(*todo, descendants)}
+	if err := s.attachTags(infos); err != nil {
+		return TodoInfo{}, err.Error()
+	}
+	return infos[0], ""
+}
+
+// 标记待办事项为已完成，所有子任务一并完成，changed 表示本次是否由未完成变为完成
+func (s *TodoService) CompleteTodo(userID, todoID uint, now time.Time) (info *TodoInfo, changed bool, err error) {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return nil, false, err
+	}
+	descendants, err := s.todoRepository.GetDescendants([]uint{todo.ID})
+	if err != nil {
+		return nil, false, fmt.Errorf("查询子任务失败: %w", err)
+	}
+	if len(descendants) > 0 {
+		ids := make([]uint, 0, len(descendants))
+		for _, descendant := range descendants {
+			ids = append(ids, descendant.ID)
+		}
+		if err := s.todoRepository.CompleteTodos(ids, userID, now); err != nil {
+			return nil, false, fmt.Errorf("完成子任务失败: %w", err)
+		}
+	}
+	if !todo.Completed {
+		todo.Completed = true
+		todo.CompletedAt = &now
+		todo.UpdatedBy = userID
+		if err := s.todoRepository.UpdateTodo(todo); err != nil {
+			return nil, false, fmt.Errorf("更新待办事项失败: %w", err)
+		}
+		changed = true
+		s.recordActivity(userID, todo, TodoActionComplete)
+		// 完成最近一次的重复待办时生成下一次
+		if err := s.onOccurrenceCompleted(todo, now); err != nil {
+			logger.Log.Errorf("生成下一次重复待办失败: todo=%d err=%v", todo.ID, err)
+		}
 	}
-	return todoinfo, ""
+	result := newTodoInfo(*todo)
+	return &result, changed, nil
 }
 
-// DeleteTodo 删除待办事项
-func (s *TodoService) DeleteTodo(userID, todoID uint) string {
+// 把已完成的待办事项恢复为未完成，已完成的上级一并恢复
+func (s *TodoService) ReopenTodo(userID, todoID uint) (*TodoInfo, error) {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := s.getAncestors(todo)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.reopenTodos(ancestors); err != nil {
+		return nil, err
+	}
+	if todo.Completed {
+		todo.Completed = false
+		todo.CompletedAt = nil
+		todo.UpdatedBy = userID
+		if err := s.todoRepository.UpdateTodo(todo); err != nil {
+			return nil, fmt.Errorf("更新待办事项失败: %w", err)
+		}
+		s.recordActivity(userID, todo, TodoActionReopen)
+	}
+	result := newTodoInfo(*todo)
+	return &result, nil
+}
+
+// DeleteTodo 把待办事项移到回收站，scope 为 future 时停止重复并删除以后还未完成的待办
+func (s *TodoService) DeleteTodo(userID, todoID uint, scope string) string {
 	todo, err := s.todoRepository.GetTodoByID(todoID)
 	if err != nil {
 		return "待办事项不存在"
 	}
-	if todo.UserID != userID {
+	if err := s.checkTodoAccess(userID, todo, true); err != nil {
 		return "无权限删除该待办事项"
 	}
-	if err := s.todoRepository.DeleteTodo(todoID); err != nil {
+	ids := []uint{todoID}
+	if scope == TodoScopeFuture {
+		if todo.RecurrenceID == nil {
+			return "该待办事项不是重复待办"
+		}
+		if ids, err = s.stopRecurrence(todo); err != nil {
+			return err.Error()
+		}
+	}
+	// 子任务随上级一起删除
+	descendants, err := s.todoRepository.GetDescendants(ids)
+	if err != nil {
 		return err.Error()
 	}
+	if len(ids) == 1 && len(descendants) == 0 {
+		err = s.todoRepository.DeleteTodo(todoID)
+	} else {
+		for _, descendant := range descendants {
+			ids = append(ids, descendant.ID)
+		}
+		err = s.todoRepository.DeleteTodos(ids)
+	}
+	if err != nil {
+		return err.Error()
+	}
+	s.recordActivity(userID, todo, TodoActionDelete)
 	return "删除成功"
 }
+
+// 按给定顺序重排子任务，orderedIDs 必须恰好包含该待办的所有直接子任务
+func (s *TodoService) ReorderSubtasks(userID, todoID uint, orderedIDs []uint) error {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return err
+	}
+	descendants, err := s.todoRepository.GetDescendants([]uint{todo.ID})
+	if err != nil {
+		return fmt.Errorf("查询子任务失败: %w", err)
+	}
+	children := make(map[uint]bool)
+	for _, descendant := range descendants {
+		if descendant.ParentID != nil && *descendant.ParentID == todo.ID {
+			children[descendant.ID] = true
+		}
+	}
+	if len(orderedIDs) != len(children) {
+		return errors.New("子任务列表与现有子任务不一致")
+	}
+	seen := make(map[uint]bool, len(orderedIDs))
+	for _, id := range orderedIDs {
+		if !children[id] || seen[id] {
+			return errors.New("子任务列表与现有子任务不一致")
+		}
+		seen[id] = true
+	}
+	if err := s.todoRepository.ReorderSubtasks(todo.ID, orderedIDs); err != nil {
+		return fmt.Errorf("调整子任务顺序失败: %w", err)
+	}
+	return nil
+}
+
+// 校验用户可以编辑该待办，供记录学习数据前调用
+func (s *TodoService) ValidateTodo(userID, todoID uint) error {
+	_, err := s.getAccessibleTodo(userID, todoID, true)
+	return err
+}
+
+// 记录在待办上的学习时长和番茄钟
+func (s *TodoService) RecordTodoStudy(userID, todoID uint, date time.Time, studyTime, tomatoes int) error {
+	return s.todoRepository.IncrementTodoStudyData(userID, todoID, date, studyTime, tomatoes)
+}
+
+// 扣除作废提交在待办上记录的学习时长和番茄钟
+func (s *TodoService) RevokeTodoStudy(userID, todoID uint, date time.Time, studyTime, tomatoes int) error {
+	return s.todoRepository.DecrementTodoStudyData(userID, todoID, date, studyTime, tomatoes)
+}
+
+// 把顶层待办移到清单中 afterID 之后，afterID 为空时移到最前
+// listID 为空时留在原清单，为0时移到收集箱
+func (s *TodoService) MoveTodo(userID, todoID uint, listID, afterID *uint) error {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return err
+	}
+	if todo.ParentID != nil {
+		return errors.New("子任务请在上级待办中调整顺序")
+	}
+	target := todo.ListID
+	if listID != nil {
+		target = nil
+		if *listID != 0 {
+			target = listID
+		}
+		if err := s.checkMoveTarget(userID, todo, target); err != nil {
+			return err
+		}
+	}
+	if afterID != nil && *afterID == todo.ID {
+		return errors.New("不能移到自己之后")
+	}
+	// 间隔用完时重新分配位置后再试一次
+	for range 2 {
+		var after *int
+		if afterID != nil {
+			previous, err := s.getAccessibleTodo(userID, *afterID, false)
+			if err != nil {
+				return err
+			}
+			if previous.ParentID != nil || !sameTodoList(previous.ListID, target) {
+				return errors.New("只能移到同一清单中的顶层待办之后")
+			}
+			after = &previous.Position
+		}
+		next, err := s.todoRepository.TodoPositionAfter(todo.UserID, target, after, todo.ID)
+		if err != nil {
+			return fmt.Errorf("查询待办顺序失败: %w", err)
+		}
+		if position, ok := positionBetween(after, next); ok {
+			if err := s.todoRepository.MoveTodo(todo.ID, target, position); err != nil {
+				return fmt.Errorf("移动待办事项失败: %w", err)
+			}
+			s.moveRecurrence(todo, target)
+			s.recordMoveActivity(userID, todo, target)
+			return nil
+		}
+		if err := s.todoRepository.RebalanceTodoPositions(todo.UserID, target); err != nil {
+			return fmt.Errorf("调整待办顺序失败: %w", err)
+		}
+	}
+	return errors.New("调整待办顺序失败")
+}
+
+// 清单中的待办都属于清单的创建者，所以只能移到待办创建者的清单中
+// 收集箱是待办创建者自己的，只能把自己的待办移到收集箱
+func (s *TodoService) checkMoveTarget(userID uint, todo *models.Todo, target *uint) error {
+	if target == nil {
+		if todo.UserID != userID {
+			return errors.New("只能把自己的待办移到收集箱")
+		}
+		return nil
+	}
+	list, err := s.getAccessibleTodoList(userID, *target, true)
+	if err != nil {
+		return err
+	}
+	if list.UserID != todo.UserID {
+		return errors.New("只能移到待办创建者的清单中")
+	}
+	return nil
+}
+
+// 待办移到其它清单时，原清单和新清单都记录，在同一清单中调整顺序不记录
+func (s *TodoService) recordMoveActivity(userID uint, todo *models.Todo, target *uint) {
+	if sameTodoList(todo.ListID, target) {
+		return
+	}
+	s.recordListActivity(userID, todo.ListID, todo, TodoActionMove)
+	s.recordListActivity(userID, target, todo, TodoActionMove)
+}
+
+// 重复待办移到其它清单后，以后的每一次也放入该清单，失败只记录日志
+func (s *TodoService) moveRecurrence(todo *models.Todo, target *uint) {
+	if todo.RecurrenceID == nil || sameTodoList(todo.ListID, target) {
+		return
+	}
+	recurrence, err := s.todoRepository.GetRecurrenceByID(*todo.RecurrenceID)
+	if err == nil {
+		recurrence.ListID = target
+		err = s.todoRepository.UpdateRecurrence(recurrence)
+	}
+	if err != nil {
+		logger.Log.Errorf("更新重复规则的清单失败: todo=%d err=%v", todo.ID, err)
+	}
+}
+
+// 用给定的标签替换待办原有的标签，不存在的标签自动创建
+func (s *TodoService) SetTodoTags(userID, todoID uint, names []string) ([]TodoTagInfo, error) {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return nil, err
+	}
+	names, err = normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+	infos, err := s.setTodoTags(userID, todoID, names)
+	if err != nil {
+		return nil, err
+	}
+	s.recordActivity(userID, todo, TodoActionTag)
+	return infos, nil
+}
+
+func (s *TodoService) setTodoTags(userID, todoID uint, names []string) ([]TodoTagInfo, error) {
+	tags, err := s.listRepository.GetOrCreateTodoTags(userID, names)
+	if err != nil {
+		return nil, fmt.Errorf("创建标签失败: %w", err)
+	}
+	ids := make([]uint, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	if err := s.listRepository.SetTodoTags(userID, todoID, ids); err != nil {
+		return nil, fmt.Errorf("设置标签失败: %w", err)
+	}
+	return newTodoTagInfos(tags), nil
+}
+
+// 按名称排序的标签信息
+func newTodoTagInfos(tags []models.TodoTag) []TodoTagInfo {
+	infos := make([]TodoTagInfo, 0, len(tags))
+	for _, tag := range tags {
+		infos = append(infos, TodoTagInfo{ID: tag.ID, Name: tag.Name})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// 为待办及其所有子任务补充标签
+func (s *TodoService) attachTags(infos []TodoInfo) error {
+	ids := make([]uint, 0, len(infos))
+	var collect func(list []TodoInfo)
+	collect = func(list []TodoInfo) {
+		for _, info := range list {
+			ids = append(ids, info.ID)
+			collect(info.Subtasks)
+		}
+	}
+	collect(infos)
+	names, err := s.listRepository.GetTodoTagNames(ids)
+	if err != nil {
+		return fmt.Errorf("查询标签失败: %w", err)
+	}
+	tags := make(map[uint][]TodoTagInfo)
+	for _, name := range names {
+		tags[name.TodoID] = append(tags[name.TodoID], TodoTagInfo{ID: name.TagID, Name: name.Name})
+	}
+	var attach func(list []TodoInfo)
+	attach = func(list []TodoInfo) {
+		for i := range list {
+			if len(tags[list[i].ID]) > 0 {
+				list[i].Tags = tags[list[i].ID]
+			}
+			attach(list[i].Subtasks)
+		}
+	}
+	attach(infos)
+	return nil
+}
+
+func sameTodoList(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// 计算放在 after 和 next 之间的位置，为空表示没有前一个/后一个
+// 两者之间已经没有空位时返回false，需要先重新分配位置
+func positionBetween(after, next *int) (int, bool) {
+	switch {
+	case after == nil && next == nil:
+		return models.TodoPositionGap, true
+	case after == nil:
+		return *next - models.TodoPositionGap, true
+	case next == nil:
+		return *after + models.TodoPositionGap, true
+	case *next-*after < 2:
+		return 0, false
+	default:
+		return *after + (*next-*after)/2, true
+	}
+}
+
+// 从直接上级到顶层待办的所有上级
+func (s *TodoService) getAncestors(todo *models.Todo) ([]models.Todo, error) {
+	return getAncestors(s.todoRepository, todo)
+}
+
+func getAncestors(todos todoFinder, todo *models.Todo) ([]models.Todo, error) {
+	ancestors := make([]models.Todo, 0)
+	parentID := todo.ParentID
+	for parentID != nil && len(ancestors) < MaxTodoDepth {
+		parent, err := todos.GetTodoByID(*parentID)
+		if err != nil {
+			return nil, errors.New("上级待办事项不存在")
+		}
+		ancestors = append(ancestors, *parent)
+		parentID = parent.ParentID
+	}
+	return ancestors, nil
+}
+
+// 恢复其中已完成的待办
+func (s *TodoService) reopenTodos(todos []models.Todo) error {
+	ids := make([]uint, 0)
+	for _, todo := range todos {
+		if todo.Completed {
+			ids = append(ids, todo.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.todoRepository.ReopenTodos(ids); err != nil {
+		return fmt.Errorf("恢复上级待办失败: %w", err)
+	}
+	return nil
+}
+
+// 组装待办及其所有层级的子任务，descendants 中与该待办无关的记录会被忽略
+func buildTodoTree(root models.Todo, descendants []models.Todo) TodoInfo {
+	children := make(map[uint][]models.Todo)
+	for _, todo := range descendants {
+		if todo.ParentID != nil {
+			children[*todo.ParentID] = append(children[*todo.ParentID], todo)
+		}
+	}
+	var build func(todo models.Todo) (TodoInfo, int, int)
+	build = func(todo models.Todo) (TodoInfo, int, int) {
+		info := newTodoInfo(todo)
+		list := children[todo.ID]
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].Position != list[j].Position {
+				return list[i].Position < list[j].Position
+			}
+			return list[i].ID < list[j].ID
+		})
+		completed, total := 0, 0
+		for _, child := range list {
+			childInfo, childCompleted, childTotal := build(child)
+			info.Subtasks = append(info.Subtasks, childInfo)
+			completed += childCompleted
+			total += childTotal + 1
+			if child.Completed {
+				completed++
+			}
+		}
+		if total > 0 {
+			info.Progress = &TodoProgress{
+				Completed: completed,
+				Total:     total,
+				Percent:   math.Round(float64(completed)/float64(total)*1000) / 10,
+			}
+		}
+		return info, completed, total
+	}
+	info, _, _ := build(root)
+	return info
+}
+
+// 实际完成的番茄钟占预计数量的百分比，没有预计数量时返回nil
+func tomatoAttainment(actual, estimated int) *float64 {
+	if estimated <= 0 {
+		return nil
+	}
+	attainment := math.Round(float64(actual)/float64(estimated)*1000) / 10
+	return &attainment
+}
+
+func newTodoInfo(todo models.Todo) TodoInfo {
+	// 添加共享清单之前创建的待办没有记录创建者
+	createdBy := todo.CreatedBy
+	if createdBy == 0 {
+		createdBy = todo.UserID
+	}
+	return TodoInfo{
+		ID:                todo.ID,
+		Event:             todo.Event,
+		Description:       todo.Description,
+		Priority:          todo.Priority,
+		DueAt:             todo.DueAt,
+		EstimatedTomatoes: todo.EstimatedTomatoes,
+		ActualStudyTime:   todo.ActualStudyTime,
+		ActualTomatoes:    todo.ActualTomatoes,
+		TomatoAttainment:  tomatoAttainment(todo.ActualTomatoes, todo.EstimatedTomatoes),
+		Completed:         todo.Completed,
+		CompletedAt:       todo.CompletedAt,
+		CreatedAt:         todo.CreatedAt,
+		DeletedAt:         deletedAt(todo),
+		ParentID:          todo.ParentID,
+		ListID:            todo.ListID,
+		Position:          todo.Position,
+		Tags:              []TodoTagInfo{},
+		RecurrenceID:      todo.RecurrenceID,
+		OccurrenceAt:      todo.OccurrenceAt,
+		AssigneeID:        todo.AssigneeID,
+		CreatedBy:         createdBy,
+		UpdatedBy:         todo.UpdatedBy,
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestApplyTodoInput(t *testing.T) {
+	due := time.Date(2026, 10, 20, 18, 0, 0, 0, time.UTC)
+	event := "数学作业"
+	empty := ""
+	high := TodoPriorityHigh
+	invalid := 5
+	negative := -1
+
+	todo := models.Todo{Event: "旧事件", DueAt: &due}
+	assert.Equal(t, "", applyTodoInput(&todo, TodoInput{Event: &event, Priority: &high}))
+	assert.Equal(t, "数学作业", todo.Event)
+	assert.Equal(t, TodoPriorityHigh, todo.Priority)
+	assert.Equal(t, &due, todo.DueAt)
+
+	assert.Equal(t, "", applyTodoInput(&todo, TodoInput{ClearDueAt: true}))
+	assert.Equal(t, true, todo.DueAt == nil)
+
+	assert.Equal(t, "事件不能为空", applyTodoInput(&todo, TodoInput{Event: &empty}))
+	assert.Equal(t, "优先级不合法", applyTodoInput(&todo, TodoInput{Priority: &invalid}))
+	assert.Equal(t, "预计番茄钟数量不能为负数", applyTodoInput(&todo, TodoInput{EstimatedTomatoes: &negative}))
+}
+
+func TestBuildTodoTree(t *testing.T) {
+	id := func(v uint) *uint { return &v }
+	root := models.Todo{ID: 1, Event: "期末复习"}
+	descendants := []models.Todo{
+		{ID: 3, ParentID: id(1), Position: 2048, Event: "英语"},
+		{ID: 2, ParentID: id(1), Position: 1024, Event: "数学", Completed: true},
+		{ID: 4, ParentID: id(3), Position: 1024, Event: "背单词", Completed: true},
+		{ID: 5, ParentID: id(3), Position: 2048, Event: "做阅读"},
+		{ID: 9, ParentID: id(8), Event: "无关的子任务"},
+	}
+
+	info := buildTodoTree(root, descendants)
+
+	assert.Equal(t, 2, len(info.Subtasks))
+	assert.Equal(t, uint(2), info.Subtasks[0].ID)
+	assert.Equal(t, uint(3), info.Subtasks[1].ID)
+	assert.Equal(t, TodoProgress{Completed: 2, Total: 4, Percent: 50}, *info.Progress)
+	assert.Equal(t, TodoProgress{Completed: 1, Total: 2, Percent: 50}, *info.Subtasks[1].Progress)
+	assert.Equal(t, true, info.Subtasks[0].Progress == nil)
+}
+
+func TestPositionBetween(t *testing.T) {
+	pos := func(v int) *int { return &v }
+	tests := []struct {
+		name     string
+		after    *int
+		next     *int
+		expected int
+		ok       bool
+	}{
+		{"空清单", nil, nil, models.TodoPositionGap, true},
+		{"移到最前", nil, pos(1024), 0, true},
+		{"移到最后", pos(2048), nil, 3072, true},
+		{"两者之间", pos(1024), pos(2048), 1536, true},
+		{"间隔用完", pos(1024), pos(1025), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			position, ok := positionBetween(tt.after, tt.next)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, position)
+		})
+	}
+}
+
+func TestNormalizeTagNames(t *testing.T) {
+	names, err := normalizeTagNames([]string{" 数学 ", "", "英语", "数学"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"数学", "英语"}, names)
+
+	_, err = normalizeTagNames([]string{strings.Repeat("长", maxTodoTagNameLength+1)})
+	assert.NotEqual(t, nil, err)
+
+	tooMany := make([]string, 0, MaxTodoTags+1)
+	for i := 0; i <= MaxTodoTags; i++ {
+		tooMany = append(tooMany, fmt.Sprintf("标签%d", i))
+	}
+	_, err = normalizeTagNames(tooMany)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestTomatoAttainment(t *testing.T) {
+	assert.Equal(t, true, tomatoAttainment(3, 0) == nil)
+	assert.Equal(t, 75.0, *tomatoAttainment(3, 4))
+	assert.Equal(t, 133.3, *tomatoAttainment(4, 3))
+}
+
+func TestTodoBatchFinish(t *testing.T) {
+	batch := &todoBatch{
+		results: []TodoBatchResult{{ID: 1}, {ID: 2, Message: "待办事项不存在"}, {ID: 3, Message: "已经是完成状态"}},
+		index:   map[uint]int{1: 0, 2: 1, 3: 2},
+		todos:   []*models.Todo{{ID: 1}, {ID: 3}},
+	}
+	results := batch.finish(nil, "已完成")
+	assert.Equal(t, []TodoBatchResult{
+		{ID: 1, Success: true, Message: "已完成"},
+		{ID: 2, Message: "待办事项不存在"},
+		{ID: 3, Success: true, Message: "已经是完成状态"},
+	}, results)
+}
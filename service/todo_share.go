@@ -0,0 +1,191 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+)
+
+// 清单中待办的修改类型
+const (
+	TodoActionCreate   = "create"
+	TodoActionUpdate   = "update"
+	TodoActionComplete = "complete"
+	TodoActionReopen   = "reopen"
+	TodoActionDelete   = "delete"
+	TodoActionRestore  = "restore"
+	TodoActionMove     = "move"
+	TodoActionTag      = "tag"
+	TodoActionAssign   = "assign"
+)
+
+// 判断访问权限时需要查询的待办
+type todoFinder interface {
+	GetTodoByID(id uint) (*models.Todo, error)
+}
+
+// 判断访问权限时需要查询的清单和成员
+type TodoAccessRepository interface {
+	GetTodoListByID(id uint) (*models.TodoList, error)
+	GetTodoListMember(listID, userID uint) (*models.TodoListMember, error)
+}
+
+// 查询当前用户可以访问的待办，edit 为true时要求可以编辑
+func (s *TodoService) getAccessibleTodo(userID, todoID uint, edit bool) (*models.Todo, error) {
+	return getAccessibleTodo(s.todoRepository, s.listRepository, userID, todoID, edit)
+}
+
+func (s *TodoService) checkTodoAccess(userID uint, todo *models.Todo, edit bool) error {
+	return checkTodoAccess(s.todoRepository, s.listRepository, userID, todo, edit)
+}
+
+func (s *TodoService) getAccessibleTodoList(userID, listID uint, edit bool) (*models.TodoList, error) {
+	return getAccessibleTodoList(s.listRepository, userID, listID, edit)
+}
+
+func (s *TodoService) todoListID(todo *models.Todo) (*uint, error) {
+	return todoListID(s.todoRepository, todo)
+}
+
+// 以下函数供 TodoService 和 ReminderService 共用
+
+func getAccessibleTodo(todos todoFinder, lists TodoAccessRepository, userID, todoID uint, edit bool) (*models.Todo, error) {
+	todo, err := todos.GetTodoByID(todoID)
+	if err != nil {
+		return nil, errors.New("待办事项不存在")
+	}
+	if err := checkTodoAccess(todos, lists, userID, todo, edit); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// 自己的待办都可以访问，其他人的待办只有在共享给自己的清单中时才可以访问
+func checkTodoAccess(todos todoFinder, lists TodoAccessRepository, userID uint, todo *models.Todo, edit bool) error {
+	if todo.UserID == userID {
+		return nil
+	}
+	listID, err := todoListID(todos, todo)
+	if err != nil {
+		return err
+	}
+	if listID == nil {
+		return errors.New("无权限操作该待办事项")
+	}
+	_, err = getAccessibleTodoList(lists, userID, *listID, edit)
+	return err
+}
+
+// 创建者或已加入的成员可以访问的清单，edit 为true时要求是创建者或编辑者
+func getAccessibleTodoList(lists TodoAccessRepository, userID, listID uint, edit bool) (*models.TodoList, error) {
+	list, err := lists.GetTodoListByID(listID)
+	if err != nil {
+		return nil, errors.New("清单不存在")
+	}
+	role, err := todoListRole(lists, userID, list)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, errors.New("无权限操作该清单")
+	}
+	if edit && role == TodoListRoleViewer {
+		return nil, errors.New("只能查看该清单，不能修改")
+	}
+	return list, nil
+}
+
+// 待办所在的清单，子任务取顶层待办所在的清单
+func todoListID(todos todoFinder, todo *models.Todo) (*uint, error) {
+	if todo.ParentID == nil {
+		return todo.ListID, nil
+	}
+	ancestors, err := getAncestors(todos, todo)
+	if err != nil {
+		return nil, err
+	}
+	return ancestors[len(ancestors)-1].ListID, nil
+}
+
+// 记录清单中待办的修改，不在清单中的待办不记录，失败只记录日志
+func (s *TodoService) recordActivity(userID uint, todo *models.Todo, action string) {
+	listID, err := s.todoListID(todo)
+	if err != nil {
+		logger.Log.Errorf("记录待办修改失败: todo=%d action=%s err=%v", todo.ID, action, err)
+		return
+	}
+	s.recordListActivity(userID, listID, todo, action)
+}
+
+// 记录到指定的清单，供待办所在清单需要提前查询的场景使用，如删除子任务前
+func (s *TodoService) recordListActivity(userID uint, listID *uint, todo *models.Todo, action string) {
+	if listID == nil {
+		return
+	}
+	err := s.listRepository.CreateTodoActivity(&models.TodoActivity{
+		ListID: *listID,
+		TodoID: todo.ID,
+		UserID: userID,
+		Action: action,
+		Event:  todo.Event,
+	})
+	if err != nil {
+		logger.Log.Errorf("记录待办修改失败: todo=%d action=%s err=%v", todo.ID, action, err)
+	}
+}
+
+// 把待办分配给清单创建者或已加入的成员，assigneeID 为空时取消分配
+func (s *TodoService) AssignTodo(userID, todoID uint, assigneeID *uint) (*TodoInfo, error) {
+	todo, err := s.getAccessibleTodo(userID, todoID, true)
+	if err != nil {
+		return nil, err
+	}
+	if assigneeID != nil && *assigneeID != todo.UserID {
+		listID, err := s.todoListID(todo)
+		if err != nil {
+			return nil, err
+		}
+		if listID == nil {
+			return nil, errors.New("只能分配给清单的成员")
+		}
+		list, err := s.listRepository.GetTodoListByID(*listID)
+		if err != nil {
+			return nil, errors.New("清单不存在")
+		}
+		role, err := todoListRole(s.listRepository, *assigneeID, list)
+		if err != nil {
+			return nil, err
+		}
+		if role == "" {
+			return nil, errors.New("只能分配给清单的成员")
+		}
+	}
+	todo.AssigneeID = assigneeID
+	todo.UpdatedBy = userID
+	if err := s.todoRepository.UpdateTodo(todo); err != nil {
+		return nil, fmt.Errorf("分配待办事项失败: %w", err)
+	}
+	s.recordActivity(userID, todo, TodoActionAssign)
+	info := newTodoInfo(*todo)
+	return &info, nil
+}
+
+// 分配给自己的待办，包括子任务，已经不能访问的待办不返回
+func (s *TodoService) GetAssignedTodos(userID uint, status string) ([]TodoInfo, error) {
+	todos, err := s.todoRepository.GetAssignedTodos(userID, status)
+	if err != nil {
+		return nil, fmt.Errorf("查询分配给自己的待办失败: %w", err)
+	}
+	infos := make([]TodoInfo, 0, len(todos))
+	for i := range todos {
+		if err := s.checkTodoAccess(userID, &todos[i], false); err != nil {
+			continue
+		}
+		infos = append(infos, newTodoInfo(todos[i]))
+	}
+	if err := s.attachTags(infos); err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
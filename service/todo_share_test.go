@@ -0,0 +1,285 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/config"
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+func (r *memoryReminderRepository) GetTodoReminders(todoID uint) ([]models.TodoReminder, error) {
+	reminders := make([]models.TodoReminder, 0)
+	for _, reminder := range r.reminders {
+		if reminder.TodoID == todoID {
+			reminders = append(reminders, reminder)
+		}
+	}
+	return reminders, nil
+}
+
+func (r *memoryReminderRepository) CreateTodoReminder(reminder *models.TodoReminder) error {
+	reminder.ID = uint(len(r.reminders) + 1)
+	r.reminders = append(r.reminders, *reminder)
+	return nil
+}
+
+func (r *memoryReminderRepository) GetTodoReminderByID(id uint) (*models.TodoReminder, error) {
+	for _, reminder := range r.reminders {
+		if reminder.ID == id {
+			return &reminder, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryReminderRepository) DeleteTodoReminder(id uint) error {
+	r.reminders = slices.DeleteFunc(r.reminders, func(reminder models.TodoReminder) bool {
+		return reminder.ID == id
+	})
+	return nil
+}
+
+func (r *memoryTodoListRepository) GetUsernames(ids []uint) (map[uint]string, error) {
+	usernames := make(map[uint]string, len(ids))
+	for _, id := range ids {
+		usernames[id] = "user"
+	}
+	return usernames, nil
+}
+
+func (r *memoryTodoListRepository) CreateTodoListMember(member *models.TodoListMember) error {
+	r.members = append(r.members, *member)
+	return nil
+}
+
+func (r *memoryTodoRepository) GetRecurrenceByID(id uint) (*models.TodoRecurrence, error) {
+	if recurrence, ok := r.recurrences[id]; ok {
+		return recurrence, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryTodoRepository) GetOccurrences(recurrenceID uint, page, pageSize int) ([]models.Todo, int64, error) {
+	todos := make([]models.Todo, 0)
+	for _, todo := range r.todos {
+		if todo.RecurrenceID != nil && *todo.RecurrenceID == recurrenceID {
+			todos = append(todos, *todo)
+		}
+	}
+	return todos, int64(len(todos)), nil
+}
+
+type memoryFriendRepository map[uint][]uint
+
+func (r memoryFriendRepository) GetFriendIDs(userID uint) ([]uint, error) {
+	return r[userID], nil
+}
+
+// 清单1、3属于用户1，清单1中用户2是编辑者，用户3是查看者，用户4还没有接受邀请，用户5不是成员
+func newSharedTodoService(now time.Time) (*TodoService, *memoryTodoRepository, *memoryTodoListRepository) {
+	id := func(v uint) *uint { return &v }
+	lists := []models.TodoList{{ID: 1, UserID: 1, Name: "小组作业"}, {ID: 2, UserID: 2, Name: "编辑者的清单"}, {ID: 3, UserID: 1, Name: "个人"}}
+	service, repo, listRepo := newMemoryTodoService(now, lists,
+		models.Todo{ID: 1, UserID: 1, ListID: id(1), Event: "写报告", DueAt: &now},
+		models.Todo{ID: 2, UserID: 1, ParentID: id(1), Event: "查资料"},
+		models.Todo{ID: 3, UserID: 1, Event: "收集箱里的待办"},
+	)
+	listRepo.members = []models.TodoListMember{
+		{ListID: 1, UserID: 2, Role: TodoListRoleEditor, Status: TodoListMemberAccepted},
+		{ListID: 1, UserID: 3, Role: TodoListRoleViewer, Status: TodoListMemberAccepted},
+		{ListID: 1, UserID: 4, Role: TodoListRoleEditor, Status: TodoListMemberPending},
+	}
+	return service, repo, listRepo
+}
+
+func TestTodoAccess(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	service, _, _ := newSharedTodoService(now)
+
+	tests := []struct {
+		name   string
+		userID uint
+		todoID uint
+		edit   bool
+		ok     bool
+	}{
+		{"创建者可以编辑", 1, 1, true, true},
+		{"编辑者可以编辑", 2, 1, true, true},
+		{"编辑者可以编辑子任务", 2, 2, true, true},
+		{"查看者可以查看", 3, 1, false, true},
+		{"查看者不能编辑", 3, 1, true, false},
+		{"未接受邀请不能查看", 4, 1, false, false},
+		{"非成员不能查看", 5, 2, false, false},
+		{"不在清单中的待办只有创建者可以访问", 2, 3, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.getAccessibleTodo(tt.userID, tt.todoID, tt.edit)
+			assert.Equal(t, tt.ok, err == nil)
+		})
+	}
+}
+
+func TestSharedTodoEditing(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	list := uint(2)
+
+	// 查看者、未接受邀请和非成员不能修改
+	service, repo, listRepo := newSharedTodoService(now)
+	for _, userID := range []uint{3, 4, 5} {
+		assert.NotEqual(t, nil, service.ValidateTodo(userID, 1))
+		assert.NotEqual(t, nil, service.MoveTodo(userID, 1, nil, nil))
+		results, _, err := service.BatchCompleteTodos(userID, []uint{1}, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, results[0].Success)
+		assert.NotEqual(t, "删除成功", service.DeleteTodo(userID, 1, ""))
+	}
+	assert.Equal(t, false, repo.todos[1].Completed)
+	assert.Equal(t, false, repo.todos[1].DeletedAt.Valid)
+	assert.Equal(t, 0, len(listRepo.activities))
+
+	// 编辑者可以批量完成，记录完成的用户和清单动态
+	assert.Equal(t, nil, service.ValidateTodo(2, 1))
+	results, changed, err := service.BatchCompleteTodos(2, []uint{1}, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success)
+	assert.Equal(t, []uint{1}, changed)
+	assert.Equal(t, uint(2), repo.todos[1].UpdatedBy)
+	assert.Equal(t, uint(2), repo.todos[2].UpdatedBy)
+	assert.Equal(t, 1, len(listRepo.activities))
+	assert.Equal(t, TodoActionComplete, listRepo.activities[0].Action)
+
+	// 编辑者不能把待办移到收集箱或自己的清单
+	assert.NotEqual(t, nil, service.MoveTodo(2, 1, new(uint), nil))
+	assert.NotEqual(t, nil, service.MoveTodo(2, 1, &list, nil))
+	results, err = service.BatchMoveTodos(2, []uint{1}, 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, results[0].Success)
+
+	// 删除成功后才记录清单动态
+	assert.Equal(t, "删除成功", service.DeleteTodo(2, 1, ""))
+	assert.Equal(t, true, repo.todos[1].DeletedAt.Valid)
+	assert.Equal(t, 2, len(listRepo.activities))
+	assert.Equal(t, TodoActionDelete, listRepo.activities[1].Action)
+
+	// 编辑者可以从回收站恢复清单中的待办，查看者不能
+	_, err = service.RestoreTodo(3, 1)
+	assert.NotEqual(t, nil, err)
+	_, err = service.RestoreTodo(2, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, repo.todos[1].DeletedAt.Valid)
+	assert.Equal(t, 3, len(listRepo.activities))
+	assert.Equal(t, TodoActionRestore, listRepo.activities[2].Action)
+
+	// 批量设置标签和删除成功后逐个记录，子任务记录到上级所在的清单
+	results, err = service.BatchTagTodos(2, []uint{1}, []string{"作业"}, TodoTagModeAdd)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success)
+	assert.Equal(t, 4, len(listRepo.activities))
+	assert.Equal(t, TodoActionTag, listRepo.activities[3].Action)
+	results, err = service.BatchDeleteTodos(2, []uint{1, 2})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success && results[1].Success)
+	assert.Equal(t, 6, len(listRepo.activities))
+	for _, activity := range listRepo.activities[4:] {
+		assert.Equal(t, TodoActionDelete, activity.Action)
+		assert.Equal(t, uint(1), activity.ListID)
+	}
+	assert.Equal(t, uint(2), listRepo.activities[5].TodoID)
+
+	// 移到其它清单时原清单和新清单都记录
+	_, err = service.RestoreTodo(1, 1)
+	assert.Equal(t, nil, err)
+	results, err = service.BatchMoveTodos(1, []uint{1}, 3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, results[0].Success)
+	assert.Equal(t, 9, len(listRepo.activities))
+	assert.Equal(t, TodoActionMove, listRepo.activities[7].Action)
+	assert.Equal(t, uint(1), listRepo.activities[7].ListID)
+	assert.Equal(t, uint(3), listRepo.activities[8].ListID)
+}
+
+func TestSharedRecurrenceHistory(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	service, repo, _ := newSharedTodoService(now)
+	list := uint(1)
+	recurrenceID := uint(1)
+	repo.recurrences = map[uint]*models.TodoRecurrence{
+		1: {ID: 1, UserID: 1, RRule: "FREQ=DAILY", StartAt: now, ListID: &list, LastOccurrenceAt: now, Active: true},
+		2: {ID: 2, UserID: 1, RRule: "FREQ=DAILY", StartAt: now, LastOccurrenceAt: now, Active: true},
+	}
+	repo.todos[1].RecurrenceID = &recurrenceID
+
+	// 共享清单中的重复待办，已接受邀请的成员都可以查看完成情况
+	for _, userID := range []uint{1, 2, 3} {
+		_, todos, total, err := service.GetRecurrenceHistory(userID, 1, 1, 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, uint(1), todos[0].ID)
+	}
+	for _, userID := range []uint{4, 5} {
+		_, _, _, err := service.GetRecurrenceHistory(userID, 1, 1, 10)
+		assert.NotEqual(t, nil, err)
+	}
+
+	// 不在清单中的重复待办只有创建者可以查看
+	_, _, _, err := service.GetRecurrenceHistory(2, 2, 1, 10)
+	assert.NotEqual(t, nil, err)
+	_, _, _, err = service.GetRecurrenceHistory(1, 2, 1, 10)
+	assert.Equal(t, nil, err)
+}
+
+func TestSharedTodoReminders(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	_, repo, listRepo := newSharedTodoService(now)
+	cfg := &config.NotificationConfig{MaxRemindersPerTodo: 5, MaxOffsetMinutes: 60}
+	service := NewReminderService(&memoryReminderRepository{}, repo, listRepo, &memoryNotifier{}, cfg)
+
+	// 查看者可以查看提醒，不能添加
+	_, err := service.GetTodoReminders(3, 1)
+	assert.Equal(t, nil, err)
+	_, err = service.AddTodoReminder(3, 1, 10)
+	assert.NotEqual(t, nil, err)
+	_, err = service.GetTodoReminders(4, 1)
+	assert.NotEqual(t, nil, err)
+
+	// 编辑者可以添加和删除提醒
+	info, err := service.AddTodoReminder(2, 1, 10)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, service.DeleteTodoReminder(3, info.ID))
+	assert.NotEqual(t, nil, service.DeleteTodoReminder(5, info.ID))
+	assert.Equal(t, nil, service.DeleteTodoReminder(2, info.ID))
+}
+
+func TestInviteTodoListMember(t *testing.T) {
+	logger.Log = logger.NewLogger(logger.ParseLevel("error"), io.Discard, "", 0)
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	_, _, listRepo := newSharedTodoService(now)
+	friends := memoryFriendRepository{6: {1}, 7: {8}}
+	notifier := &memoryNotifier{}
+	service := NewTodoListService(listRepo, friends, notifier)
+
+	// 只能邀请已经把创建者加为好友的用户
+	_, err := service.InviteTodoListMember(1, 1, 7, TodoListRoleEditor)
+	assert.NotEqual(t, nil, err)
+	_, err = service.InviteTodoListMember(1, 1, 8, TodoListRoleEditor)
+	assert.NotEqual(t, nil, err)
+	info, err := service.InviteTodoListMember(1, 1, 6, TodoListRoleViewer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, TodoListMemberPending, info.Status)
+	assert.Equal(t, 1, len(notifier.sent))
+
+	// 只有创建者可以邀请
+	_, err = service.InviteTodoListMember(2, 1, 6, TodoListRoleViewer)
+	assert.NotEqual(t, nil, err)
+}
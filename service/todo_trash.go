@@ -0,0 +1,96 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 每次清理回收站最多彻底删除的待办数量
+const trashPurgeBatchSize = 500
+
+// 分页查询回收站，随上级一起删除的子任务包含在上级的 subtasks 中
+func (s *TodoService) GetTrash(userID uint, page, pageSize int) ([]TodoInfo, int64, error) {
+	todos, total, err := s.todoRepository.GetDeletedTodos(userID, page, pageSize)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询回收站失败: %w", err)
+	}
+	infos := make([]TodoInfo, 0, len(todos))
+	for _, todo := range todos {
+		descendants, err := s.todoRepository.GetDeletedDescendants(todo.ID, todo.DeletedAt.Time)
+		if err != nil {
+			return nil, 0, fmt.Errorf("查询子任务失败: %w", err)
+		}
+		infos = append(infos, buildTodoTree(todo, descendants))
+	}
+	return infos, total, nil
+}
+
+// 从回收站恢复待办及与其一起删除的子任务
+func (s *TodoService) RestoreTodo(userID, todoID uint) (*TodoInfo, error) {
+	todo, err := s.todoRepository.GetDeletedTodoByID(todoID)
+	if err != nil {
+		return nil, errors.New("回收站中没有该待办事项")
+	}
+	// 上级还在回收站时需要先恢复上级
+	var ancestors []models.Todo
+	if todo.ParentID != nil {
+		if ancestors, err = s.getAncestors(todo); err != nil {
+			return nil, errors.New("请先恢复上级待办事项")
+		}
+	}
+	if err := s.checkTodoAccess(userID, todo, true); err != nil {
+		return nil, err
+	}
+	descendants, err := s.todoRepository.GetDeletedDescendants(todo.ID, todo.DeletedAt.Time)
+	if err != nil {
+		return nil, fmt.Errorf("查询子任务失败: %w", err)
+	}
+	ids := []uint{todo.ID}
+	for _, descendant := range descendants {
+		ids = append(ids, descendant.ID)
+	}
+	if err := s.todoRepository.RestoreTodos(ids); err != nil {
+		return nil, fmt.Errorf("恢复待办事项失败: %w", err)
+	}
+	// 恢复了未完成的子任务，已完成的上级恢复为未完成
+	if !todo.Completed {
+		if err := s.reopenTodos(ancestors); err != nil {
+			return nil, err
+		}
+	}
+	todo.DeletedAt.Valid = false
+	s.recordActivity(userID, todo, TodoActionRestore)
+	info := buildTodoTree(*todo, descendants)
+	return &info, nil
+}
+
+// 彻底删除回收站中超过保留天数的待办，返回删除的数量
+func (s *TodoService) PurgeTrash(now time.Time) (int, error) {
+	before := now.AddDate(0, 0, -s.cfg.TrashRetentionDays)
+	count := 0
+	for {
+		ids, err := s.todoRepository.GetExpiredTodoIDs(before, trashPurgeBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("查询过期待办失败: %w", err)
+		}
+		if len(ids) == 0 {
+			return count, nil
+		}
+		if err := s.todoRepository.PurgeTodos(ids); err != nil {
+			return count, fmt.Errorf("彻底删除待办失败: %w", err)
+		}
+		count += len(ids)
+		if len(ids) < trashPurgeBatchSize {
+			return count, nil
+		}
+	}
+}
+
+func deletedAt(todo models.Todo) *time.Time {
+	if !todo.DeletedAt.Valid {
+		return nil
+	}
+	return &todo.DeletedAt.Time
+}
@@ -9,6 +9,7 @@ import (
 	"strings"
 	"time"
 
+	"2026-FM247-BackEnd/logger"
 	"2026-FM247-BackEnd/models"
 	"2026-FM247-BackEnd/storage"
 	"2026-FM247-BackEnd/utils"
@@ -23,19 +24,27 @@ type UserRepository interface {
 	UpdatePassword(userid uint, newpassword string) error
 	DeleteUser(userid uint) error
 	UpdateAvatarURL(userID uint, avatarURL string) error
+	UpdateDailyGoal(userID uint, dailyGoal int) error
+}
+
+// 注销账号后清理不在mysql中的用户数据，例如redis中的排行榜
+type UserDataCleaner interface {
+	RemoveUser(userID uint) error
 }
 
 type UserService struct {
 	userRepo  UserRepository
 	storage   storage.Storage
 	tokenRepo TokenBlacklistRepository
+	cleaners  []UserDataCleaner
 }
 
-func NewUserService(userRepo UserRepository, tokenRepo TokenBlacklistRepository, storage storage.Storage) *UserService {
+func NewUserService(userRepo UserRepository, tokenRepo TokenBlacklistRepository, storage storage.Storage, cleaners ...UserDataCleaner) *UserService {
 	return &UserService{
 		userRepo:  userRepo,
 		storage:   storage,
 		tokenRepo: tokenRepo,
+		cleaners:  cleaners,
 	}
 }
 
@@ -116,6 +125,12 @@ func (u *UserService) CancelUser(userID uint, password string) (err error, messa
 	if err != nil {
 		return err, "注销失败"
 	}
+	// 账号已经删除，清理失败只记录日志
+	for _, cleaner := range u.cleaners {
+		if err := cleaner.RemoveUser(userID); err != nil {
+			logger.Log.Errorf("清理注销用户的数据失败: user=%d err=%v", userID, err)
+		}
+	}
 	return nil, "注销成功"
 }
 
@@ -199,11 +214,26 @@ func (s *UserService) GetUserInfo(userID uint) (*UserInfo, error) {
 		Avatar:     fullURL,
 		Experience: user.Experience,
 		Level:      user.Level,
+		DailyGoal:  user.DailyGoal,
 		CreatedAt:  user.CreatedAt,
+
+		LeaderboardOptOut: user.LeaderboardOptOut,
 	}
 	return userInfo, nil
 }
 
+// 更新每日学习目标
+func (u *UserService) UpdateDailyGoal(userID uint, dailyGoal int) (message string) {
+	if dailyGoal <= 0 || dailyGoal > 24*60 {
+		return "每日目标需在1到1440分钟之间"
+	}
+	err := u.userRepo.UpdateDailyGoal(userID, dailyGoal)
+	if err != nil {
+		return "更新每日目标失败"
+	}
+	return "更新每日目标成功"
+}
+
 // 上传头像方法。返回文件完整url
 func (s *UserService) UploadAvatar(ctx context.Context, userID uint, file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
 	//验证文件类型
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/logger"
+	"2026-FM247-BackEnd/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// 年度回顾中展示的最常播放数量
+const yearReviewFavouriteLimit = 3
+
+// 今年的回顾快照超过该时长后查看时重新生成，学习数据由 flusher 异步落库，快照不必实时
+const yearReviewSnapshotTTL = time.Hour
+
+type YearReviewRepository interface {
+	SaveYearReview(review *models.YearReview) error
+	GetYearReview(userID uint, year int) (*models.YearReview, error)
+	GetYearReviewByToken(token string) (*models.YearReview, error)
+	ClaimReviewRun(year int) (bool, error)
+	ReleaseReviewRun(year int) error
+}
+
+// 查询某段时间内有学习记录的用户，与学习报告共用
+type ActiveUserRepository interface {
+	GetActiveUserIDs(startDate, endDate time.Time) ([]uint, error)
+}
+
+type FavouriteProvider interface {
+	GetFavourites(userID uint, mediaType string, year, limit int) ([]MediaPlayInfo, error)
+}
+
+type YearReviewService struct {
+	repo            YearReviewRepository
+	studyRepo       StudyDataRepository
+	achievementRepo AchievementRepository
+	userRepo        UserRepository
+	activeUsers     ActiveUserRepository
+	plays           FavouriteProvider
+	stop            chan struct{}
+	done            chan struct{}
+}
+
+func NewYearReviewService(repo YearReviewRepository, studyRepo StudyDataRepository, achievementRepo AchievementRepository, userRepo UserRepository, activeUsers ActiveUserRepository, plays FavouriteProvider) *YearReviewService {
+	return &YearReviewService{
+		repo:            repo,
+		studyRepo:       studyRepo,
+		achievementRepo: achievementRepo,
+		userRepo:        userRepo,
+		activeUsers:     activeUsers,
+		plays:           plays,
+		stop:            make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// 启动定时任务，每年1月为上一年有学习记录的用户生成年度回顾
+func (s *YearReviewService) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(reportCheckInterval)
+		defer ticker.Stop()
+		for {
+			s.runScheduled(reportNow())
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *YearReviewService) Stop() {
+	close(s.stop)
+	<-s.done
+}
+
+func (s *YearReviewService) runScheduled(now time.Time) {
+	if now.Month() != time.January {
+		return
+	}
+	year := now.Year() - 1
+	claimed, err := s.repo.ClaimReviewRun(year)
+	if err != nil {
+		logger.Log.Errorf("检查年度回顾生成任务失败: year=%d err=%v", year, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	count, err := s.GenerateAll(year, now)
+	if err != nil {
+		logger.Log.Errorf("生成年度回顾失败: year=%d err=%v", year, err)
+		if err := s.repo.ReleaseReviewRun(year); err != nil {
+			logger.Log.Errorf("释放年度回顾生成任务失败: year=%d err=%v", year, err)
+		}
+		return
+	}
+	logger.Log.Infof("已生成%d份%d年度回顾", count, year)
+}
+
+// 为某年有学习记录的所有用户生成年度回顾，单个用户失败只记录日志
+func (s *YearReviewService) GenerateAll(year int, now time.Time) (int, error) {
+	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+	endDate := time.Date(year, 12, 31, 0, 0, 0, 0, now.Location())
+	userIDs, err := s.activeUsers.GetActiveUserIDs(startDate, endDate)
+	if err != nil {
+		return 0, fmt.Errorf("查询活跃用户失败: %w", err)
+	}
+	count := 0
+	for _, userID := range userIDs {
+		if _, err := s.Generate(userID, year, now); err != nil {
+			logger.Log.Errorf("生成年度回顾失败: user=%d year=%d err=%v", userID, year, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// 生成并保存年度回顾快照，今年的回顾统计到今天为止
+func (s *YearReviewService) Generate(userID uint, year int, now time.Time) (*YearReviewInfo, error) {
+	if year > now.Year() {
+		return nil, errors.New("年份不能晚于今年")
+	}
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, now.Location())
+	endDate := time.Date(year, 12, 31, 0, 0, 0, 0, now.Location())
+	if year == now.Year() {
+		endDate = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	dataList, err := s.studyRepo.GetStudyDataSummary(userID, startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询学习数据失败: %w", err)
+	}
+	hourly, err := s.studyRepo.GetHourlyStudyData(userID, year)
+	if err != nil {
+		return nil, fmt.Errorf("查询分时段学习数据失败: %w", err)
+	}
+
+	info := &YearReviewInfo{
+		Year:        year,
+		Username:    user.Username,
+		GeneratedAt: now,
+	}
+	monthly := make(map[time.Month]int)
+	for _, data := range dataList {
+		info.TotalStudyTime += data.StudyTime
+		info.TotalTomatoes += data.Tomatoes
+		if data.StudyTime > 0 {
+			info.ActiveDays++
+		}
+		monthly[data.Date.Month()] += data.StudyTime
+	}
+	info.TotalHours = math.Round(float64(info.TotalStudyTime)/60*10) / 10
+	for month := time.January; month <= time.December; month++ {
+		if monthly[month] > info.BusiestMonthStudyTime {
+			info.BusiestMonth = int(month)
+			info.BusiestMonthStudyTime = monthly[month]
+		}
+	}
+	info.LongestStreak = longestStreak(dataList)
+	bestHourTime := 0
+	for _, data := range hourly {
+		if data.StudyTime > bestHourTime {
+			hour := data.Hour
+			info.MostProductiveHour = &hour
+			bestHourTime = data.StudyTime
+		}
+	}
+
+	if info.FavouriteMusic, err = s.plays.GetFavourites(userID, MediaTypeMusic, year, yearReviewFavouriteLimit); err != nil {
+		return nil, err
+	}
+	if info.FavouriteAmbientSounds, err = s.plays.GetFavourites(userID, MediaTypeAmbient, year, yearReviewFavouriteLimit); err != nil {
+		return nil, err
+	}
+	if info.Achievements, err = s.yearAchievements(userID, year); err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("序列化年度回顾失败: %w", err)
+	}
+	review := &models.YearReview{
+		UserID:     userID,
+		Year:       year,
+		ShareToken: strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Content:    string(content),
+	}
+	if err := s.repo.SaveYearReview(review); err != nil {
+		return nil, fmt.Errorf("保存年度回顾失败: %w", err)
+	}
+	info.ShareToken = review.ShareToken
+	return info, nil
+}
+
+// 获取自己的年度回顾，还没有生成或今年的快照已过期时立即生成
+func (s *YearReviewService) GetYearReview(userID uint, year int, now time.Time) (*YearReviewInfo, error) {
+	review, err := s.repo.GetYearReview(userID, year)
+	if err != nil {
+		return nil, fmt.Errorf("查询年度回顾失败: %w", err)
+	}
+	if review == nil || (year == now.Year() && now.Sub(review.UpdatedAt) > yearReviewSnapshotTTL) {
+		return s.Generate(userID, year, now)
+	}
+	info, err := decodeYearReview(review)
+	if err != nil {
+		return nil, err
+	}
+	info.ShareToken = review.ShareToken
+	return info, nil
+}
+
+// 通过分享链接查看年度回顾，只读且不返回分享token
+func (s *YearReviewService) GetSharedYearReview(token string) (*YearReviewInfo, error) {
+	review, err := s.repo.GetYearReviewByToken(token)
+	if err != nil {
+		return nil, errors.New("年度回顾不存在")
+	}
+	return decodeYearReview(review)
+}
+
+func decodeYearReview(review *models.YearReview) (*YearReviewInfo, error) {
+	var info YearReviewInfo
+	if err := json.Unmarshal([]byte(review.Content), &info); err != nil {
+		return nil, fmt.Errorf("解析年度回顾失败: %w", err)
+	}
+	return &info, nil
+}
+
+// 当年解锁的成就
+func (s *YearReviewService) yearAchievements(userID uint, year int) ([]YearReviewAchievement, error) {
+	catalogue, err := s.achievementRepo.GetAllAchievements()
+	if err != nil {
+		return nil, fmt.Errorf("查询成就失败: %w", err)
+	}
+	unlocked, err := s.achievementRepo.GetUserAchievements(userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询已解锁成就失败: %w", err)
+	}
+	byCode := make(map[string]models.Achievement, len(catalogue))
+	for _, achievement := range catalogue {
+		byCode[achievement.Code] = achievement
+	}
+	achievements := make([]YearReviewAchievement, 0)
+	for _, userAchievement := range unlocked {
+		achievement, ok := byCode[userAchievement.AchievementCode]
+		if !ok || userAchievement.UnlockedAt.Year() != year {
+			continue
+		}
+		achievements = append(achievements, YearReviewAchievement{
+			Code:       achievement.Code,
+			Name:       achievement.Name,
+			Icon:       achievement.Icon,
+			UnlockedAt: userAchievement.UnlockedAt,
+		})
+	}
+	return achievements, nil
+}
+
+// 最长的连续学习天数
+func longestStreak(dataList []models.DailyStudyData) int {
+	longest, current := 0, 0
+	var previous time.Time
+	for _, data := range dataList {
+		if data.StudyTime <= 0 {
+			continue
+		}
+		if current > 0 && data.Date.Format("2006-01-02") == previous.AddDate(0, 0, 1).Format("2006-01-02") {
+			current++
+		} else {
+			current = 1
+		}
+		previous = data.Date
+		longest = max(longest, current)
+	}
+	return longest
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"2026-FM247-BackEnd/models"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestLongestStreak(t *testing.T) {
+	day := func(m time.Month, d int) time.Time {
+		return time.Date(2026, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name     string
+		dataList []models.DailyStudyData
+		want     int
+	}{
+		{"空数据", nil, 0},
+		{"单天", []models.DailyStudyData{{Date: day(1, 1), StudyTime: 30}}, 1},
+		{"跨月连续", []models.DailyStudyData{
+			{Date: day(1, 30), StudyTime: 30},
+			{Date: day(1, 31), StudyTime: 30},
+			{Date: day(2, 1), StudyTime: 30},
+			{Date: day(2, 5), StudyTime: 30},
+		}, 3},
+		{"学习时长为0的天打断连续", []models.DailyStudyData{
+			{Date: day(3, 1), StudyTime: 30},
+			{Date: day(3, 2), StudyTime: 0},
+			{Date: day(3, 3), StudyTime: 30},
+			{Date: day(3, 4), StudyTime: 30},
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, longestStreak(tt.dataList))
+		})
+	}
+}
@@ -28,3 +28,16 @@ func ValidatePhoneNumber(phone string) bool {
 	}
 	return phone[0] == '1'
 }
+
+func ValidateHexColor(color string) bool {
+	// 形如 #RRGGBB 的十六进制颜色
+	if len(color) != 7 || color[0] != '#' {
+		return false
+	}
+	for _, ch := range color[1:] {
+		if !((ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'f') || (ch >= 'A' && ch <= 'F')) {
+			return false
+		}
+	}
+	return true
+}
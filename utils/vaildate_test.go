@@ -28,3 +28,23 @@ func TestValidateUsername(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateHexColor(t *testing.T) {
+	tests := []struct {
+		name  string
+		color string
+		want  bool
+	}{
+		{"正常颜色", "#409EFF", true},
+		{"小写", "#a1b2c3", true},
+		{"缺少井号", "409EFF", false},
+		{"长度不对", "#FFF", false},
+		{"非法字符", "#GGGGGG", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ValidateHexColor(tt.color))
+		})
+	}
+}